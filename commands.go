@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"leadgentracker/internals/migration"
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/service"
+)
+
+const ActorCommandLine = "cli"
+
+// commands are the maintenance subcommands, run as "lead-gen-tracker <command> [flags]"
+var commands = map[string]func(repos *repositories, services *services, args []string) error{
+	"rebuild-stats": rebuildStatsCommand,
+	"migrate":       migrateCommand,
+}
+
+// runCommand executes a subcommand against the configured storage and returns the process exit code
+func runCommand(name string, args []string) int {
+	command, exists := commands[name]
+	if !exists {
+		log.Printf("unknown command: %s", name)
+		return 2
+	}
+
+	repos := configureRepositories()
+	defer repos.close()
+
+	if err := command(repos, configureServices(repos), args); err != nil {
+		log.Printf("%s failed: %s", name, err)
+		return 1
+	}
+	return 0
+}
+
+// commandContext attributes the mutations of a command to the command line in the audit log
+func commandContext() context.Context {
+	return service.WithAuditContext(context.Background(), ActorCommandLine, model.RequestMetadata{})
+}
+
+func rebuildStatsCommand(repos *repositories, services *services, args []string) error {
+	flags := flag.NewFlagSet("rebuild-stats", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only print the differences without writing them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	result, err := services.stats.RebuildStats(commandContext(), *dryRun)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Changes) == 0 {
+		fmt.Println("stats are consistent with the stored leads, nothing to change")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "DATE\tFIELD\tCURRENT\tREBUILT")
+	for _, change := range result.Changes {
+		date := change.Date
+		if date == "" {
+			date = "total"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%d\t%d\n", date, change.Field, change.Current, change.Rebuilt)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if result.DryRun {
+		fmt.Printf("dry run: %d counters would change\n", len(result.Changes))
+	} else {
+		fmt.Printf("stats rebuilt: %d counters changed\n", len(result.Changes))
+	}
+	return nil
+}
+
+// migrateCommand runs "migrate up", "migrate down [-steps n]" or "migrate status"
+func migrateCommand(repos *repositories, services *services, args []string) error {
+	if repos.migrations == nil {
+		return fmt.Errorf("migrations only apply to the %s storage backend", StorageBackendMongo)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate action, expected up, down or status")
+	}
+
+	ctx := context.Background()
+	switch action := args[0]; action {
+	case "up":
+		applied, err := repos.migrations.Up(ctx)
+		printMigrations("applied", applied)
+		return err
+	case "down":
+		flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := flags.Int("steps", 1, "number of migrations to roll back")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *steps < 1 {
+			return fmt.Errorf("steps must be positive")
+		}
+		rolledBack, err := repos.migrations.Down(ctx, *steps)
+		printMigrations("rolled back", rolledBack)
+		return err
+	case "status":
+		statuses, err := repos.migrations.Status(ctx)
+		if err != nil {
+			return err
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "VERSION\tAPPLIED\tDESCRIPTION")
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\n", strconv.Itoa(status.Migration.Version), applied, status.Migration.Description)
+		}
+		return writer.Flush()
+	default:
+		return fmt.Errorf("unknown migrate action: %s", action)
+	}
+}
+
+func printMigrations(verb string, migrations []migration.Migration) {
+	if len(migrations) == 0 {
+		fmt.Printf("no migrations %s\n", verb)
+		return
+	}
+	for _, m := range migrations {
+		fmt.Printf("%s migration %d: %s\n", verb, m.Version, m.Description)
+	}
+}
@@ -0,0 +1,78 @@
+// Package export writes leads in formats other tools can read
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"leadgentracker/internals/model"
+)
+
+// leadColumns are the built-in columns of a lead export, the custom fields follow them
+var leadColumns = []string{
+	"name", "url", "profileType", "outreachType", "stage", "temperature", "score",
+	"dateAdded", "followupSent", "followupDueAt", "tags", "notes",
+}
+
+// WriteLeadsCSV writes the leads as CSV with a header row. Every custom field
+// gets a column labelled with its key, holding the value the way it is entered.
+func WriteLeadsCSV(w io.Writer, leads []model.Lead, fields []model.CustomField) error {
+	writer := csv.NewWriter(w)
+
+	header := append([]string(nil), leadColumns...)
+	for _, field := range fields {
+		header = append(header, field.Key)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, lead := range leads {
+		followupDueAt := ""
+		if lead.FollowupDueAt != nil {
+			followupDueAt = lead.FollowupDueAt.Format("2006-01-02")
+		}
+
+		record := []string{
+			lead.Name,
+			lead.URL,
+			string(lead.ProfileType),
+			string(lead.OutreachType),
+			string(lead.ConnectionStatus),
+			string(lead.LeadTemperature),
+			strconv.Itoa(lead.Score),
+			lead.Date.Format("2006-01-02"),
+			strconv.FormatBool(lead.FollowupSent),
+			followupDueAt,
+			strings.Join(lead.Tags, ", "),
+			lead.Notes.Text(),
+		}
+		for _, field := range fields {
+			record = append(record, field.Format(&lead))
+		}
+		for i := range record {
+			record[i] = escapeFormula(record[i])
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// escapeFormula keeps spreadsheets from running a cell as a formula. Cells
+// starting with a character that starts a formula get a leading quote, which
+// spreadsheets hide, unless they are a plain number like a negative score.
+func escapeFormula(cell string) string {
+	if cell == "" || !strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return cell
+	}
+	if _, err := strconv.ParseFloat(cell, 64); err == nil {
+		return cell
+	}
+	return "'" + cell
+}
@@ -0,0 +1,335 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/service"
+)
+
+// AdminHandler serves maintenance endpoints. They are only enabled when an
+// admin token is configured and expect it as a bearer token or as the
+// password of basic auth.
+type AdminHandler struct {
+	ss    *service.StatsService
+	ps    *service.PipelineService
+	qs    *service.SequenceService
+	cs    *service.ScoringService
+	fs    *service.CustomFieldService
+	b     *SSEBroadcaster
+	token string
+}
+
+func NewAdminHandler(statsService *service.StatsService, pipelineService *service.PipelineService, sequenceService *service.SequenceService, scoringService *service.ScoringService, customFieldService *service.CustomFieldService, sseBroadcaster *SSEBroadcaster, token string) *AdminHandler {
+	return &AdminHandler{
+		ss:    statsService,
+		ps:    pipelineService,
+		qs:    sequenceService,
+		cs:    scoringService,
+		fs:    customFieldService,
+		b:     sseBroadcaster,
+		token: token,
+	}
+}
+
+// RebuildStats recomputes the stats counters from the leads. With dryRun=true
+// it only reports the differences.
+func (h *AdminHandler) RebuildStats(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	dryRun := false
+	if dryRunStr := r.URL.Query().Get("dryRun"); dryRunStr != "" {
+		parsed, err := strconv.ParseBool(dryRunStr)
+		if err != nil {
+			log.Printf("invalid dryRun value provided: %s", dryRunStr)
+			http.Error(w, "invalid dryRun value", http.StatusBadRequest)
+			return
+		}
+		dryRun = parsed
+	}
+
+	log.Printf("rebuilding stats (dry run: %t)", dryRun)
+	result, err := h.ss.RebuildStats(r.Context(), dryRun)
+	if err != nil {
+		log.Printf("failed to rebuild stats: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	if !dryRun && len(result.Changes) > 0 {
+		h.b.Broadcast("refreshLeadStats")
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// Pipelines lists the pipeline of every outreach type on GET and replaces the
+// pipeline sent as JSON body on PUT
+func (h *AdminHandler) Pipelines(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		pipelines, err := h.ps.GetAll(r.Context())
+		if err != nil {
+			log.Printf("failed to fetch pipelines: %s", err)
+			http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, pipelines)
+	case http.MethodPut:
+		var pipeline model.Pipeline
+		if err := json.NewDecoder(r.Body).Decode(&pipeline); err != nil {
+			log.Printf("invalid pipeline provided: %s", err)
+			http.Error(w, "invalid pipeline", http.StatusBadRequest)
+			return
+		}
+
+		log.Printf("saving %s pipeline", pipeline.OutreachType)
+		err := h.ps.Save(r.Context(), &pipeline)
+		if errors.Is(err, service.ErrInvalidPipeline) {
+			log.Printf("rejected pipeline: %s", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("failed to save pipeline: %s", err)
+			http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+			return
+		}
+
+		h.b.Broadcast("refreshLeadList")
+		writeJSON(w, http.StatusOK, pipeline)
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPut)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Scoring returns the scoring config on GET and replaces it with the config
+// sent as JSON body on PUT, which rescores every lead
+func (h *AdminHandler) Scoring(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		config, err := h.cs.Get(r.Context())
+		if err != nil {
+			log.Printf("failed to fetch scoring config: %s", err)
+			http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, config)
+	case http.MethodPut:
+		var config model.ScoringConfig
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			log.Printf("invalid scoring config provided: %s", err)
+			http.Error(w, "invalid scoring config", http.StatusBadRequest)
+			return
+		}
+
+		log.Println("saving scoring config")
+		err := h.cs.Save(r.Context(), &config)
+		if errors.Is(err, service.ErrInvalidScoring) {
+			log.Printf("rejected scoring config: %s", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("failed to save scoring config: %s", err)
+			http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+			return
+		}
+
+		h.b.Broadcast("refreshLeadList")
+		writeJSON(w, http.StatusOK, config)
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPut)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Sequences lists the sequences on GET, creates the sequence sent as JSON body
+// on POST, replaces the sequence with the id query parameter on PUT and
+// deletes it on DELETE
+func (h *AdminHandler) Sequences(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		sequences, err := h.qs.GetAll(r.Context())
+		if err != nil {
+			log.Printf("failed to fetch sequences: %s", err)
+			http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, sequences)
+	case http.MethodPost, http.MethodPut:
+		var sequence model.Sequence
+		if err := json.NewDecoder(r.Body).Decode(&sequence); err != nil {
+			log.Printf("invalid sequence provided: %s", err)
+			http.Error(w, "invalid sequence", http.StatusBadRequest)
+			return
+		}
+
+		var err error
+		if r.Method == http.MethodPost {
+			log.Printf("creating sequence %s", sequence.Name)
+			err = h.qs.Create(r.Context(), &sequence)
+		} else {
+			id, ok := parseID(w, r, "id", "sequence", "")
+			if !ok {
+				return
+			}
+			sequence.ID = id
+			log.Printf("updating sequence %s", id.Hex())
+			err = h.qs.Update(r.Context(), &sequence)
+		}
+		if !handleEntityError(w, r, err, sequenceErrors, "") {
+			return
+		}
+
+		status := http.StatusOK
+		if r.Method == http.MethodPost {
+			status = http.StatusCreated
+		}
+		writeJSON(w, status, sequence)
+	case http.MethodDelete:
+		id, ok := parseID(w, r, "id", "sequence", "")
+		if !ok {
+			return
+		}
+
+		log.Printf("deleting sequence %s", id.Hex())
+		if !handleEntityError(w, r, h.qs.Delete(r.Context(), id), sequenceErrors, "") {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost+", "+http.MethodPut+", "+http.MethodDelete)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// CustomFields lists the custom fields on GET, creates the field sent as JSON
+// body on POST, replaces the field with the id query parameter on PUT and
+// deletes it together with its values on DELETE
+func (h *AdminHandler) CustomFields(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		fields, err := h.fs.GetAll(r.Context())
+		if err != nil {
+			log.Printf("failed to fetch custom fields: %s", err)
+			http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, fields)
+	case http.MethodPost, http.MethodPut:
+		var field model.CustomField
+		if err := json.NewDecoder(r.Body).Decode(&field); err != nil {
+			log.Printf("invalid custom field provided: %s", err)
+			http.Error(w, "invalid custom field", http.StatusBadRequest)
+			return
+		}
+
+		var err error
+		if r.Method == http.MethodPost {
+			log.Printf("creating custom field %s", field.Key)
+			err = h.fs.Create(r.Context(), &field)
+		} else {
+			id, ok := parseID(w, r, "id", "custom field", "")
+			if !ok {
+				return
+			}
+			field.ID = id
+			log.Printf("updating custom field %s", id.Hex())
+			err = h.fs.Update(r.Context(), &field)
+		}
+		if !handleEntityError(w, r, err, customFieldErrors, "") {
+			return
+		}
+
+		status := http.StatusOK
+		if r.Method == http.MethodPost {
+			status = http.StatusCreated
+		}
+		writeJSON(w, status, field)
+		h.b.Broadcast("refreshLeadList")
+	case http.MethodDelete:
+		id, ok := parseID(w, r, "id", "custom field", "")
+		if !ok {
+			return
+		}
+
+		log.Printf("deleting custom field %s", id.Hex())
+		if !handleEntityError(w, r, h.fs.Delete(r.Context(), id), customFieldErrors, "") {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		h.b.Broadcast("refreshLeadList")
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost+", "+http.MethodPut+", "+http.MethodDelete)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// RequireAdmin serves the request with next only when it carries the admin token
+func (h *AdminHandler) RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.authorize(w, r) {
+			next(w, r)
+		}
+	}
+}
+
+func (h *AdminHandler) authorize(w http.ResponseWriter, r *http.Request) bool {
+	if h.token == "" {
+		http.Error(w, "admin endpoints are disabled", http.StatusForbidden)
+		return false
+	}
+
+	// Browsers opening the audit log can't send a bearer token, they send the
+	// token as the basic auth password instead
+	given := r.Header.Get("Authorization")
+	if _, password, ok := r.BasicAuth(); ok {
+		given = "Bearer " + password
+	}
+	expected := "Bearer " + h.token
+	if subtle.ConstantTimeCompare([]byte(given), []byte(expected)) != 1 {
+		log.Printf("[WARNING] unauthorized admin request to %s", r.URL.Path)
+		w.Header().Set("WWW-Authenticate", `Basic realm="admin"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("[ERROR] Failed to encode JSON response: %v", err)
+	}
+}
@@ -0,0 +1,570 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/model/dto"
+	"leadgentracker/internals/openapi"
+	"leadgentracker/internals/repository"
+	"leadgentracker/internals/service"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// apiMaxBodySize caps the request bodies the API decodes
+const apiMaxBodySize = 1 << 20
+
+// Codes of the API's error bodies, see apiErrorResponse
+const (
+	APIErrorInvalidRequest    = "invalid_request"
+	APIErrorValidationFailed  = "validation_failed"
+	APIErrorNotFound          = "not_found"
+	APIErrorMethodNotAllowed  = "method_not_allowed"
+	APIErrorDuplicateLead     = "duplicate_lead"
+	APIErrorInvalidTransition = "invalid_transition"
+	APIErrorLeadChanged       = "lead_changed"
+	APIErrorInternal          = "internal_error"
+)
+
+// APIHandler serves the versioned JSON API for scripts and the browser
+// extension. It is backed by the same services as the HTMX handlers, so every
+// write is validated, counted in the stats and audited the same way. Request
+// bodies are validated against the OpenAPI document the API serves.
+type APIHandler struct {
+	ls   *service.LeadService
+	ss   *service.StatsService
+	ps   *service.PipelineService
+	fs   *service.CustomFieldService
+	b    *SSEBroadcaster
+	spec *openapi.Document
+}
+
+func NewAPIHandler(leadService *service.LeadService, statsService *service.StatsService, pipelineService *service.PipelineService, customFieldService *service.CustomFieldService, sseBroadcaster *SSEBroadcaster) *APIHandler {
+	return &APIHandler{
+		ls:   leadService,
+		ss:   statsService,
+		ps:   pipelineService,
+		fs:   customFieldService,
+		b:    sseBroadcaster,
+		spec: openapi.NewDocument(),
+	}
+}
+
+// apiErrorResponse is the body of every failed API request
+type apiErrorResponse struct {
+	Error apiError `json:"error"`
+}
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Fields are the values of the request body that don't match the spec
+	Fields []openapi.FieldError `json:"fields,omitempty"`
+	// Duplicate is the existing lead a rejected create request matched
+	Duplicate *duplicateMatch `json:"duplicate,omitempty"`
+}
+
+// apiLeadList is a page of the lead list
+type apiLeadList struct {
+	Leads      []model.Lead `json:"leads"`
+	Page       int          `json:"page"`
+	PerPage    int          `json:"perPage"`
+	TotalPages int          `json:"totalPages"`
+}
+
+// apiCreateLeadRequest is the body of a create request, the fields match the
+// ones of the /add-lead form. Its schema is CreateLeadRequest in openapi.
+type apiCreateLeadRequest struct {
+	ProfileType  constants.ProfileType         `json:"profileType"`
+	OutreachType constants.OutreachType        `json:"outreachType"`
+	URL          string                        `json:"url"`
+	Name         string                        `json:"name"`
+	PictureURL   string                        `json:"pictureUrl"`
+	OnDuplicate  constants.DuplicateResolution `json:"onDuplicate"`
+	SequenceID   *primitive.ObjectID           `json:"sequenceId"`
+	CampaignID   *primitive.ObjectID           `json:"campaignId"`
+}
+
+// apiCreateLeadResponse tells which lead a create request ended up in, see dto.CreateLeadResult
+type apiCreateLeadResponse struct {
+	Lead       *model.Lead                   `json:"lead"`
+	Resolution constants.DuplicateResolution `json:"resolution,omitempty"`
+	Duplicate  *duplicateMatch               `json:"duplicate,omitempty"`
+}
+
+// apiUpdateLeadRequest is the body of a partial update, fields left out keep
+// their current value and null clears the nullable ones. Custom field values
+// are entered as on the lead form, an empty value clears the field. Its schema
+// is UpdateLeadRequest in openapi.
+type apiUpdateLeadRequest struct {
+	ConnectionStatus *constants.ConnectionStatus `json:"connectionStatus"`
+	FollowupSent     *bool                       `json:"followupSent"`
+	// FollowupDueAt is dated as YYYY-MM-DD
+	FollowupDueAt optional[string]             `json:"followupDueAt"`
+	Tags          *[]string                    `json:"tags"`
+	CustomFields  map[string]string            `json:"customFields"`
+	CompanyID     optional[primitive.ObjectID] `json:"companyId"`
+	CampaignID    optional[primitive.ObjectID] `json:"campaignId"`
+}
+
+// optional tells a field left out of a JSON body apart from one set to null
+type optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (o *optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+	o.Value = new(T)
+	return json.Unmarshal(data, o.Value)
+}
+
+// apiBulkRequest is the body of a bulk action, see dto.BulkLeadAction. Its
+// schema is BulkLeadRequest in openapi.
+type apiBulkRequest struct {
+	IDs              []primitive.ObjectID       `json:"ids"`
+	Action           constants.BulkAction       `json:"action"`
+	LeadTemperature  constants.LeadTemperature  `json:"leadTemperature"`
+	ConnectionStatus constants.ConnectionStatus `json:"connectionStatus"`
+	Tag              string                     `json:"tag"`
+}
+
+// apiBulkResult is what a bulk action did to each lead, see dto.BulkResult
+type apiBulkResult struct {
+	Action    constants.BulkAction `json:"action"`
+	Updated   int                  `json:"updated"`
+	Unchanged int                  `json:"unchanged"`
+	Failed    int                  `json:"failed"`
+	Items     []apiBulkItemResult  `json:"items"`
+}
+
+type apiBulkItemResult struct {
+	ID      primitive.ObjectID    `json:"id"`
+	Name    string                `json:"name,omitempty"`
+	Outcome constants.BulkOutcome `json:"outcome"`
+	Reason  string                `json:"reason,omitempty"`
+}
+
+// apiStats are the outreach counters shown in the header of the lead list
+type apiStats struct {
+	Total *model.Stats `json:"total"`
+	Today *model.Stats `json:"today"`
+}
+
+// Leads lists the leads matching the filters of the lead list with GET and
+// creates a lead with POST
+func (h *APIHandler) Leads(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listLeads(w, r)
+	case http.MethodPost:
+		h.createLead(w, r)
+	default:
+		writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
+	}
+}
+
+// Lead reads, partially updates or trashes the lead with the id path value
+func (h *APIHandler) Lead(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, APIErrorInvalidRequest, fmt.Sprintf("invalid lead ID: %s", r.PathValue("id")))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getLead(w, r, id)
+	case http.MethodPatch:
+		h.updateLead(w, r, id)
+	case http.MethodDelete:
+		h.deleteLead(w, r, id)
+	default:
+		writeMethodNotAllowed(w, r, http.MethodGet, http.MethodPatch, http.MethodDelete)
+	}
+}
+
+// BulkLeads applies an action to many leads at once with POST
+func (h *APIHandler) BulkLeads(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+
+	var request apiBulkRequest
+	if !h.decodeJSON(w, r, "bulkUpdateLeads", &request) {
+		return
+	}
+
+	log.Printf("applying bulk action %s to %d leads through the API", request.Action, len(request.IDs))
+	result, err := h.ls.ApplyBulkAction(r.Context(), &dto.BulkLeadAction{
+		IDs:              request.IDs,
+		Action:           request.Action,
+		LeadTemperature:  request.LeadTemperature,
+		ConnectionStatus: request.ConnectionStatus,
+		Tag:              request.Tag,
+	})
+	if errors.Is(err, service.ErrInvalidBulkAction) {
+		writeAPIError(w, http.StatusBadRequest, APIErrorInvalidRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("failed to apply bulk action: %s", err)
+		writeAPIError(w, http.StatusInternalServerError, APIErrorInternal, constants.ErrorMessage)
+		return
+	}
+
+	if result.Count(constants.BulkOutcomeUpdated) > 0 {
+		h.b.Broadcast("refreshLeadList,refreshLeadStats,refreshFollowups")
+	}
+
+	response := apiBulkResult{
+		Action:    result.Action,
+		Updated:   result.Count(constants.BulkOutcomeUpdated),
+		Unchanged: result.Count(constants.BulkOutcomeUnchanged),
+		Failed:    result.Count(constants.BulkOutcomeFailed),
+		Items:     make([]apiBulkItemResult, len(result.Items)),
+	}
+	for i, item := range result.Items {
+		response.Items[i] = apiBulkItemResult(item)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// Stats returns the total outreach counters and the ones of today
+func (h *APIHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+
+	totalStats, err := h.ss.GetTotalStats(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch total stats: %s", err)
+		writeAPIError(w, http.StatusInternalServerError, APIErrorInternal, constants.ErrorMessage)
+		return
+	}
+
+	todayStats, err := h.ss.GetCurrentDayStats(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch today's stats: %s", err)
+		writeAPIError(w, http.StatusInternalServerError, APIErrorInternal, constants.ErrorMessage)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, apiStats{Total: totalStats, Today: todayStats})
+}
+
+// Spec serves the OpenAPI document of the API
+func (h *APIHandler) Spec(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.spec)
+}
+
+// NotFound answers requests to unknown API routes, which would otherwise be
+// served the index page
+func (h *APIHandler) NotFound(w http.ResponseWriter, r *http.Request) {
+	writeAPIError(w, http.StatusNotFound, APIErrorNotFound, fmt.Sprintf("no API route %s", r.URL.Path))
+}
+
+// listLeads reads the filters with dto.NewLeadFilter like the lead list, and
+// the page size from the optional perPage parameter
+func (h *APIHandler) listLeads(w http.ResponseWriter, r *http.Request) {
+	pipelines, err := h.ps.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch pipelines: %s", err)
+		writeAPIError(w, http.StatusInternalServerError, APIErrorInternal, constants.ErrorMessage)
+		return
+	}
+
+	fields, err := h.fs.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch custom fields: %s", err)
+		writeAPIError(w, http.StatusInternalServerError, APIErrorInternal, constants.ErrorMessage)
+		return
+	}
+
+	// Unlike the lead list, the API rejects invalid filters instead of ignoring them
+	filter, err := dto.NewLeadFilter(r.URL.Query(), pipelines, fields)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, APIErrorInvalidRequest, err.Error())
+		return
+	}
+	if perPageStr := r.URL.Query().Get("perPage"); perPageStr != "" {
+		perPage, err := strconv.Atoi(perPageStr)
+		if err != nil || perPage < 1 || perPage > dto.MaxLeadsPerPage {
+			writeAPIError(w, http.StatusBadRequest, APIErrorInvalidRequest, fmt.Sprintf("perPage must be a number from 1 to %d", dto.MaxLeadsPerPage))
+			return
+		}
+		filter.LeadsPerPage = perPage
+	}
+
+	leads, totalPages, err := h.ls.GetAllLeadsPaged(r.Context(), filter)
+	if err != nil {
+		log.Printf("failed to fetch leads: %s", err)
+		writeAPIError(w, http.StatusInternalServerError, APIErrorInternal, constants.ErrorMessage)
+		return
+	}
+	if leads == nil {
+		leads = []model.Lead{}
+	}
+
+	writeJSON(w, http.StatusOK, apiLeadList{Leads: leads, Page: filter.Page, PerPage: filter.LeadsPerPage, TotalPages: totalPages})
+}
+
+func (h *APIHandler) getLead(w http.ResponseWriter, r *http.Request, id primitive.ObjectID) {
+	lead, err := h.ls.GetLead(r.Context(), id)
+	if errors.Is(err, repository.ErrLeadNotFound) {
+		writeAPIError(w, http.StatusNotFound, APIErrorNotFound, fmt.Sprintf("lead %s not found", id.Hex()))
+		return
+	}
+	if err != nil {
+		log.Printf("failed to fetch lead: %s", err)
+		writeAPIError(w, http.StatusInternalServerError, APIErrorInternal, constants.ErrorMessage)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, lead)
+}
+
+func (h *APIHandler) createLead(w http.ResponseWriter, r *http.Request) {
+	var request apiCreateLeadRequest
+	if !h.decodeJSON(w, r, "createLead", &request) {
+		return
+	}
+	if request.OnDuplicate == "" {
+		request.OnDuplicate = constants.DuplicateResolutionReject
+	}
+
+	log.Printf("adding new lead through the API: %s", request.URL)
+	result, err := h.ls.CreateLead(r.Context(), &dto.NewLeadProperties{
+		ProfileType:  request.ProfileType,
+		OutreachType: request.OutreachType,
+		Url:          request.URL,
+		Name:         request.Name,
+		PictureUrl:   request.PictureURL,
+		OnDuplicate:  request.OnDuplicate,
+		SequenceID:   request.SequenceID,
+		CampaignID:   request.CampaignID,
+	})
+	var duplicateErr *service.DuplicateLeadError
+	if errors.As(err, &duplicateErr) {
+		writeJSON(w, http.StatusConflict, apiErrorResponse{Error: apiError{
+			Code:      APIErrorDuplicateLead,
+			Message:   "a lead with this profile URL already exists",
+			Duplicate: newDuplicateMatch(duplicateErr.Existing),
+		}})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidSequence) || errors.Is(err, repository.ErrSequenceNotFound) {
+		writeAPIError(w, http.StatusBadRequest, APIErrorInvalidRequest, "invalid sequence")
+		return
+	}
+	if errors.Is(err, repository.ErrCampaignNotFound) {
+		writeAPIError(w, http.StatusBadRequest, APIErrorInvalidRequest, "campaign not found")
+		return
+	}
+	if err != nil {
+		log.Printf("failed to create new lead: %s", err)
+		writeAPIError(w, http.StatusInternalServerError, APIErrorInternal, constants.ErrorMessage)
+		return
+	}
+
+	// Only a new lead is created, a merge or link changes an existing one
+	status := http.StatusCreated
+	if result.Resolution == constants.DuplicateResolutionMerge {
+		status = http.StatusOK
+		h.b.Broadcast("refreshLeadList,refreshLeadStats,refreshFollowups")
+	} else {
+		h.b.Broadcast("refreshLeadList,refreshLeadStats,renderNewLeadNotification")
+	}
+
+	response := apiCreateLeadResponse{Lead: result.Lead, Resolution: result.Resolution}
+	if result.Duplicate != nil {
+		response.Duplicate = newDuplicateMatch(result.Duplicate)
+	}
+	if status == http.StatusCreated {
+		w.Header().Set("Location", openapi.BasePath+"/leads/"+result.Lead.ID.Hex())
+	}
+	writeJSON(w, status, response)
+}
+
+// updateLead applies the fields of the body on top of the current lead, see
+// apiUpdateLeadRequest. Like the lead form, it can't change the profile details.
+func (h *APIHandler) updateLead(w http.ResponseWriter, r *http.Request, id primitive.ObjectID) {
+	var request apiUpdateLeadRequest
+	if !h.decodeJSON(w, r, "updateLead", &request) {
+		return
+	}
+
+	current, err := h.ls.GetLead(r.Context(), id)
+	if errors.Is(err, repository.ErrLeadNotFound) {
+		writeAPIError(w, http.StatusNotFound, APIErrorNotFound, fmt.Sprintf("lead %s not found", id.Hex()))
+		return
+	}
+	if err != nil {
+		log.Printf("failed to fetch lead: %s", err)
+		writeAPIError(w, http.StatusInternalServerError, APIErrorInternal, constants.ErrorMessage)
+		return
+	}
+
+	updateProps := &dto.UpdateLeadProperties{
+		ID:               id,
+		ConnectionStatus: current.ConnectionStatus,
+		FollowupSent:     current.FollowupSent,
+		FollowupDueAt:    current.FollowupDueAt,
+		Tags:             current.Tags,
+		CustomFieldInput: request.CustomFields,
+		CompanyID:        current.CompanyID,
+		CampaignID:       current.CampaignID,
+	}
+	if request.ConnectionStatus != nil {
+		updateProps.ConnectionStatus = *request.ConnectionStatus
+	}
+	if request.FollowupSent != nil {
+		updateProps.FollowupSent = *request.FollowupSent
+	}
+	if request.FollowupDueAt.Set {
+		updateProps.FollowupDueAt = nil
+		if request.FollowupDueAt.Value != nil {
+			dueAt, err := time.ParseInLocation("2006-01-02", *request.FollowupDueAt.Value, time.Local)
+			if err != nil {
+				writeAPIError(w, http.StatusBadRequest, APIErrorInvalidRequest, fmt.Sprintf("invalid follow-up date: %s", *request.FollowupDueAt.Value))
+				return
+			}
+			updateProps.FollowupDueAt = &dueAt
+		}
+	}
+	if request.Tags != nil {
+		updateProps.Tags = model.NormalizeTags(*request.Tags)
+	}
+	if request.CompanyID.Set {
+		updateProps.CompanyID = request.CompanyID.Value
+	}
+	if request.CampaignID.Set {
+		updateProps.CampaignID = request.CampaignID.Value
+	}
+
+	log.Printf("updating lead %s through the API", id.Hex())
+	lead, err := h.ls.UpdateLead(r.Context(), updateProps)
+	var transitionErr *service.TransitionError
+	if errors.As(err, &transitionErr) {
+		writeAPIError(w, http.StatusUnprocessableEntity, APIErrorInvalidTransition, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrInvalidCustomFieldValue) {
+		writeAPIError(w, http.StatusBadRequest, APIErrorInvalidRequest, err.Error())
+		return
+	}
+	if errors.Is(err, repository.ErrCompanyNotFound) {
+		writeAPIError(w, http.StatusBadRequest, APIErrorInvalidRequest, "company not found")
+		return
+	}
+	if errors.Is(err, repository.ErrCampaignNotFound) {
+		writeAPIError(w, http.StatusBadRequest, APIErrorInvalidRequest, "campaign not found")
+		return
+	}
+	if errors.Is(err, service.ErrLeadChanged) {
+		writeAPIError(w, http.StatusConflict, APIErrorLeadChanged, "the lead was changed meanwhile, read it again and retry")
+		return
+	}
+	if errors.Is(err, repository.ErrLeadNotFound) {
+		writeAPIError(w, http.StatusNotFound, APIErrorNotFound, fmt.Sprintf("lead %s not found", id.Hex()))
+		return
+	}
+	if err != nil {
+		log.Printf("failed to update lead: %s", err)
+		writeAPIError(w, http.StatusInternalServerError, APIErrorInternal, constants.ErrorMessage)
+		return
+	}
+
+	h.b.Broadcast("refreshLeadList,refreshFollowups")
+	writeJSON(w, http.StatusOK, lead)
+}
+
+// deleteLead moves the lead to the trash, like the delete button of the lead list
+func (h *APIHandler) deleteLead(w http.ResponseWriter, r *http.Request, id primitive.ObjectID) {
+	log.Printf("deleting lead %s through the API", id.Hex())
+	err := h.ls.DeleteLead(r.Context(), id)
+	if errors.Is(err, repository.ErrLeadNotFound) {
+		writeAPIError(w, http.StatusNotFound, APIErrorNotFound, fmt.Sprintf("lead %s not found", id.Hex()))
+		return
+	}
+	if err != nil {
+		log.Printf("failed to delete lead: %s", err)
+		writeAPIError(w, http.StatusInternalServerError, APIErrorInternal, constants.ErrorMessage)
+		return
+	}
+
+	h.b.Broadcast("refreshLeadList,refreshLeadStats,refreshFollowups")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeJSON validates the request body against the spec of the operation,
+// reads it into value and tells whether it succeeded
+func (h *APIHandler) decodeJSON(w http.ResponseWriter, r *http.Request, operationID string, value any) bool {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, apiMaxBodySize))
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, APIErrorInvalidRequest, fmt.Sprintf("failed to read the request body: %s", err))
+		return false
+	}
+
+	fieldErrs, err := h.spec.ValidateRequestBody(operationID, body)
+	if errors.Is(err, openapi.ErrInvalidSpec) {
+		log.Printf("failed to validate request body: %s", err)
+		writeAPIError(w, http.StatusInternalServerError, APIErrorInternal, constants.ErrorMessage)
+		return false
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, APIErrorInvalidRequest, fmt.Sprintf("invalid JSON body: %s", err))
+		return false
+	}
+	if len(fieldErrs) > 0 {
+		log.Printf("rejected API request: %d invalid fields, first %s", len(fieldErrs), fieldErrs[0])
+		writeJSON(w, http.StatusBadRequest, apiErrorResponse{Error: apiError{
+			Code:    APIErrorValidationFailed,
+			Message: "the request body doesn't match the API spec",
+			Fields:  fieldErrs,
+		}})
+		return false
+	}
+
+	// The body matches the spec, so it matches value too
+	if err := json.Unmarshal(body, value); err != nil {
+		log.Printf("failed to decode validated request body: %s", err)
+		writeAPIError(w, http.StatusBadRequest, APIErrorInvalidRequest, fmt.Sprintf("invalid JSON body: %s", err))
+		return false
+	}
+	return true
+}
+
+// writeAPIError writes the error body, the callers log the cause of server errors
+func writeAPIError(w http.ResponseWriter, status int, code string, message string) {
+	if status < http.StatusInternalServerError {
+		log.Printf("rejected API request: %s", message)
+	}
+	writeJSON(w, status, apiErrorResponse{Error: apiError{Code: code, Message: message}})
+}
+
+func writeMethodNotAllowed(w http.ResponseWriter, r *http.Request, allowed ...string) {
+	for _, method := range allowed {
+		w.Header().Add("Allow", method)
+	}
+	writeAPIError(w, http.StatusMethodNotAllowed, APIErrorMethodNotAllowed, fmt.Sprintf("method %s not allowed on %s", r.Method, r.URL.Path))
+}
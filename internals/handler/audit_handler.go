@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/model/dto"
+	"leadgentracker/internals/service"
+	"leadgentracker/views"
+)
+
+const (
+	MsgAuditFilterWarning = "Invalid audit filters provided. Please try again."
+	MsgAuditListError     = "Failed to fetch the audit log. Please try again."
+)
+
+type AuditHandler struct {
+	as *service.AuditService
+}
+
+func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+	return &AuditHandler{
+		as: auditService,
+	}
+}
+
+// auditEntriesResponse is the JSON representation of a page of audit entries
+type auditEntriesResponse struct {
+	Entries    []model.AuditEntry `json:"entries"`
+	Page       int                `json:"page"`
+	TotalPages int                `json:"totalPages"`
+}
+
+func (h *AuditHandler) ServeAuditLog(w http.ResponseWriter, r *http.Request) {
+	log.Println("serving audit log page")
+	filter, err := dto.NewAuditFilter(r.URL.Query())
+	if err != nil {
+		log.Printf("[WARNING] Invalid audit filter values provided: %s", err)
+	}
+
+	entries, totalPages, err := h.as.GetEntriesPaged(r.Context(), filter)
+	if err != nil {
+		log.Printf("failed to fetch audit entries: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	if err := views.AuditLogPage(entries, totalPages, filter).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render audit log page: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *AuditHandler) GetAuditEntries(w http.ResponseWriter, r *http.Request) {
+	filter, err := dto.NewAuditFilter(r.URL.Query())
+	if err != nil {
+		log.Printf("[WARNING] Invalid audit filter values provided: %s", err)
+		renderNotification(w, r, views.NotificationWarning, MsgAuditFilterWarning)
+	}
+
+	entries, totalPages, err := h.as.GetEntriesPaged(r.Context(), filter)
+	if err != nil {
+		log.Printf("failed to fetch audit entries: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgAuditListError)
+		return
+	}
+
+	if err := views.AuditLog(entries, totalPages, filter).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render audit log: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgAuditListError)
+		return
+	}
+}
+
+func (h *AuditHandler) GetAuditEntriesJSON(w http.ResponseWriter, r *http.Request) {
+	filter, err := dto.NewAuditFilter(r.URL.Query())
+	if err != nil {
+		log.Printf("[WARNING] Invalid audit filter values provided: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, totalPages, err := h.as.GetEntriesPaged(r.Context(), filter)
+	if err != nil {
+		log.Printf("failed to fetch audit entries: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, auditEntriesResponse{
+		Entries:    entries,
+		Page:       filter.Page,
+		TotalPages: totalPages,
+	})
+}
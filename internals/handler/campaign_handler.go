@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/service"
+	"leadgentracker/views"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MsgCampaignAddSuccess    = "Campaign added successfully!"
+	MsgCampaignSaveError     = "Failed to save the campaign. Please try again."
+	MsgCampaignInvalid       = "Invalid campaign details. Please check the name."
+	MsgCampaignDeleteSuccess = "Campaign deleted!"
+	MsgCampaignDeleteError   = "Failed to delete the campaign. Please try again."
+)
+
+type CampaignHandler struct {
+	cms *service.CampaignService
+	b   *SSEBroadcaster
+}
+
+func NewCampaignHandler(campaignService *service.CampaignService, sseBroadcaster *SSEBroadcaster) *CampaignHandler {
+	return &CampaignHandler{
+		cms: campaignService,
+		b:   sseBroadcaster,
+	}
+}
+
+func (h *CampaignHandler) ServeCampaigns(w http.ResponseWriter, r *http.Request) {
+	log.Println("serving campaigns page")
+	campaigns, stats, err := h.fetchCampaigns(r)
+	if err != nil {
+		log.Printf("failed to fetch campaigns: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	if err := views.CampaignsPage(campaigns, stats).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render campaigns page: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *CampaignHandler) AddCampaign(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		log.Printf("failed to parse form: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgCampaignSaveError)
+		return
+	}
+
+	campaign := &model.Campaign{
+		Name:        r.FormValue(constants.FormFieldKeyName),
+		Description: r.FormValue(constants.FormFieldDescription),
+	}
+	log.Printf("adding campaign %s", campaign.Name)
+	if !handleEntityError(w, r, h.cms.Create(r.Context(), campaign), campaignErrors, MsgCampaignSaveError) {
+		return
+	}
+
+	if !h.renderCampaignList(w, r, MsgCampaignSaveError) {
+		return
+	}
+	renderNotification(w, r, views.NotificationSuccess, MsgCampaignAddSuccess)
+
+	// The lead cards offer the new campaign
+	h.b.Broadcast("refreshLeadList")
+}
+
+// DeleteCampaign removes the campaign with the id query parameter, keeping its leads
+func (h *CampaignHandler) DeleteCampaign(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "id", "campaign", MsgCampaignDeleteError)
+	if !ok {
+		return
+	}
+
+	log.Printf("deleting campaign %s", id.Hex())
+	if !handleEntityError(w, r, h.cms.Delete(r.Context(), id), campaignErrors, MsgCampaignDeleteError) {
+		return
+	}
+
+	if !h.renderCampaignList(w, r, MsgCampaignDeleteError) {
+		return
+	}
+	renderNotification(w, r, views.NotificationSuccess, MsgCampaignDeleteSuccess)
+	h.b.Broadcast("refreshLeadList")
+}
+
+// renderCampaignList renders the campaigns with their stats and tells whether it succeeded
+func (h *CampaignHandler) renderCampaignList(w http.ResponseWriter, r *http.Request, message string) bool {
+	campaigns, stats, err := h.fetchCampaigns(r)
+	if err != nil {
+		log.Printf("failed to fetch campaigns: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, message)
+		return false
+	}
+
+	if err := views.CampaignList(campaigns, stats).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render campaign list: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, message)
+		return false
+	}
+	return true
+}
+
+func (h *CampaignHandler) fetchCampaigns(r *http.Request) (model.Campaigns, map[primitive.ObjectID]model.CampaignStats, error) {
+	campaigns, err := h.cms.GetAll(r.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+	stats, err := h.cms.GetStats(r.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+	return campaigns, stats, nil
+}
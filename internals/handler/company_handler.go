@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/repository"
+	"leadgentracker/internals/service"
+	"leadgentracker/views"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MsgCompanyAddSuccess    = "Company added successfully!"
+	MsgCompanyUpdateSuccess = "Company updated successfully!"
+	MsgCompanySaveError     = "Failed to save the company. Please try again."
+	MsgCompanyInvalid       = "Invalid company details. Please check the name, domain and LinkedIn URL."
+	MsgCompanyDeleteError   = "Failed to delete the company. Please try again."
+)
+
+type CompanyHandler struct {
+	cs *service.CompanyService
+	ps *service.PipelineService
+	b  *SSEBroadcaster
+}
+
+func NewCompanyHandler(companyService *service.CompanyService, pipelineService *service.PipelineService, sseBroadcaster *SSEBroadcaster) *CompanyHandler {
+	return &CompanyHandler{
+		cs: companyService,
+		ps: pipelineService,
+		b:  sseBroadcaster,
+	}
+}
+
+func (h *CompanyHandler) ServeCompanies(w http.ResponseWriter, r *http.Request) {
+	log.Println("serving companies page")
+	companies, leadCounts, err := h.fetchCompanies(r)
+	if err != nil {
+		log.Printf("failed to fetch companies: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	if err := views.CompaniesPage(companies, leadCounts).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render companies page: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *CompanyHandler) AddCompany(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		log.Printf("failed to parse form: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgCompanySaveError)
+		return
+	}
+
+	company := companyFromForm(r)
+	log.Printf("adding company %s", company.Name)
+	if !handleEntityError(w, r, h.cs.Create(r.Context(), company), companyErrors, MsgCompanySaveError) {
+		return
+	}
+
+	companies, leadCounts, err := h.fetchCompanies(r)
+	if err != nil {
+		log.Printf("failed to fetch companies: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgCompanySaveError)
+		return
+	}
+
+	if err := views.CompanyList(companies, leadCounts).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render company list: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgCompanySaveError)
+		return
+	}
+	renderNotification(w, r, views.NotificationSuccess, MsgCompanyAddSuccess)
+
+	// The lead cards offer the new company
+	h.b.Broadcast("refreshLeadList")
+}
+
+// ServeCompany renders the page of the company with the id query parameter,
+// listing its leads and their stats
+func (h *CompanyHandler) ServeCompany(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "id", "company", "")
+	if !ok {
+		return
+	}
+
+	log.Printf("serving company page of %s", id.Hex())
+	overview, err := h.cs.GetOverview(r.Context(), id)
+	if errors.Is(err, repository.ErrCompanyNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("failed to fetch company: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	pipelines, err := h.ps.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch pipelines: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	if err := views.CompanyPage(overview, pipelines).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render company page: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *CompanyHandler) UpdateCompany(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		log.Printf("failed to parse form: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgCompanySaveError)
+		return
+	}
+
+	id, ok := parseID(w, r, "id", "company", MsgCompanySaveError)
+	if !ok {
+		return
+	}
+
+	company := companyFromForm(r)
+	company.ID = id
+	log.Printf("updating company %s", id.Hex())
+	if !handleEntityError(w, r, h.cs.Update(r.Context(), company), companyErrors, MsgCompanySaveError) {
+		return
+	}
+
+	if err := views.CompanyDetails(company).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render company: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgCompanySaveError)
+		return
+	}
+	renderNotification(w, r, views.NotificationSuccess, MsgCompanyUpdateSuccess)
+
+	// The lead cards show the company name
+	h.b.Broadcast("refreshLeadList")
+}
+
+// DeleteCompany removes the company, keeping its leads, and sends the browser
+// back to the companies page
+func (h *CompanyHandler) DeleteCompany(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "id", "company", MsgCompanyDeleteError)
+	if !ok {
+		return
+	}
+
+	log.Printf("deleting company %s", id.Hex())
+	if !handleEntityError(w, r, h.cs.Delete(r.Context(), id), companyErrors, MsgCompanyDeleteError) {
+		return
+	}
+
+	w.Header().Set("HX-Redirect", "/companies")
+	w.WriteHeader(http.StatusNoContent)
+	h.b.Broadcast("refreshLeadList")
+}
+
+func (h *CompanyHandler) fetchCompanies(r *http.Request) (model.Companies, map[primitive.ObjectID]int, error) {
+	companies, err := h.cs.GetAll(r.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+	leadCounts, err := h.cs.CountLeads(r.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+	return companies, leadCounts, nil
+}
+
+func companyFromForm(r *http.Request) *model.Company {
+	return &model.Company{
+		Name:        r.FormValue(constants.FormFieldKeyName),
+		Domain:      r.FormValue(constants.FormFieldDomain),
+		LinkedInURL: r.FormValue(constants.FormFieldLinkedInURL),
+		Notes:       r.FormValue(constants.FormFieldKeyNotes),
+	}
+}
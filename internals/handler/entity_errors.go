@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/repository"
+	"leadgentracker/internals/service"
+	"leadgentracker/views"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// entityErrors tells handleEntityError how to answer the errors of a change
+// to one kind of entity. The notification messages are optional, the one
+// passed to handleEntityError is shown in their place.
+type entityErrors struct {
+	// name is the entity as it is named in the logs and responses
+	name string
+	// invalid is the service error of a rejected change, answered with 400
+	invalid        error
+	invalidMessage string
+	// notFound are the repository errors answered with 404
+	notFound        []error
+	notFoundMessage string
+}
+
+var (
+	companyErrors = entityErrors{
+		name:           "company",
+		invalid:        service.ErrInvalidCompany,
+		invalidMessage: MsgCompanyInvalid,
+		notFound:       []error{repository.ErrCompanyNotFound},
+	}
+	campaignErrors = entityErrors{
+		name:           "campaign",
+		invalid:        service.ErrInvalidCampaign,
+		invalidMessage: MsgCampaignInvalid,
+		notFound:       []error{repository.ErrCampaignNotFound},
+	}
+	noteErrors = entityErrors{
+		name:            "note",
+		invalid:         service.ErrInvalidNote,
+		invalidMessage:  MsgNoteInvalid,
+		notFound:        []error{repository.ErrNoteNotFound, repository.ErrLeadNotFound},
+		notFoundMessage: MsgNoteNotFound,
+	}
+	messageTemplateErrors = entityErrors{
+		name:           "template",
+		invalid:        service.ErrInvalidMessageTemplate,
+		invalidMessage: MsgTemplateInvalid,
+		notFound:       []error{repository.ErrMessageTemplateNotFound},
+	}
+	// templateUseErrors are the errors of previewing or using a template for a lead
+	templateUseErrors = entityErrors{
+		name:           "lead or template",
+		invalid:        service.ErrInvalidMessageTemplate,
+		invalidMessage: MsgTemplateUseInvalid,
+		notFound:       []error{repository.ErrLeadNotFound, repository.ErrMessageTemplateNotFound},
+	}
+	sequenceErrors = entityErrors{
+		name:     "sequence",
+		invalid:  service.ErrInvalidSequence,
+		notFound: []error{repository.ErrSequenceNotFound},
+	}
+	customFieldErrors = entityErrors{
+		name:     "custom field",
+		invalid:  service.ErrInvalidCustomField,
+		notFound: []error{repository.ErrCustomFieldNotFound},
+	}
+)
+
+// handleEntityError writes the error response of a failed change and tells
+// whether the change succeeded. An empty message leaves out the notification,
+// as the JSON endpoints do.
+func handleEntityError(w http.ResponseWriter, r *http.Request, err error, entity entityErrors, message string) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, entity.invalid):
+		log.Printf("rejected %s: %s", entity.name, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		notifyError(w, r, message, entity.invalidMessage)
+	case slices.ContainsFunc(entity.notFound, func(target error) bool { return errors.Is(err, target) }):
+		log.Printf("%s not found: %s", entity.name, err)
+		http.Error(w, entity.name+" not found", http.StatusNotFound)
+		notifyError(w, r, message, entity.notFoundMessage)
+	default:
+		log.Printf("failed to save %s: %s", entity.name, err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		notifyError(w, r, message, "")
+	}
+	return false
+}
+
+// parseID reads the ID of the entity from the form or query value key and
+// answers an invalid one like handleEntityError
+func parseID(w http.ResponseWriter, r *http.Request, key string, entity string, message string) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(r.FormValue(key))
+	if err != nil {
+		log.Printf("invalid %s ID provided: %s", entity, r.FormValue(key))
+		http.Error(w, "invalid "+entity+" ID", http.StatusBadRequest)
+		notifyError(w, r, message, "")
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// notifyError shows the specific message, or the general one when there is
+// none. Nothing is shown without a general message.
+func notifyError(w http.ResponseWriter, r *http.Request, message string, specific string) {
+	if message == "" {
+		return
+	}
+	if specific != "" {
+		message = specific
+	}
+	renderNotification(w, r, views.NotificationError, message)
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"leadgentracker/internals/importer"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/model/dto"
+	"leadgentracker/internals/service"
+	"leadgentracker/views"
+)
+
+// maxImportSize caps the CSV files that can be imported
+const maxImportSize = 5 << 20
+
+const (
+	MsgImportFileError     = "The file could not be read. Please upload a CSV file with a header row."
+	MsgImportMappingError  = "Check the column mapping, then preview the import again."
+	MsgImportPreviewError  = "Failed to preview the import. Please try again."
+	MsgImportError         = "Failed to import the leads. Please try again."
+	MsgImportPreviewResult = "Check the preview, then import the leads."
+)
+
+// ImportHandler imports leads from CSV files. The file is read on upload and
+// sent back with the preview, so the column mapping can be changed and
+// previewed again before the leads are imported.
+type ImportHandler struct {
+	ls *service.LeadService
+	fs *service.CustomFieldService
+	b  *SSEBroadcaster
+}
+
+func NewImportHandler(leadService *service.LeadService, customFieldService *service.CustomFieldService, sseBroadcaster *SSEBroadcaster) *ImportHandler {
+	return &ImportHandler{
+		ls: leadService,
+		fs: customFieldService,
+		b:  sseBroadcaster,
+	}
+}
+
+func (h *ImportHandler) ServeImport(w http.ResponseWriter, r *http.Request) {
+	log.Println("serving import page")
+	if err := views.ImportPage().Render(r.Context(), w); err != nil {
+		log.Printf("failed to render import page: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+}
+
+// PreviewImport does a dry run of the import and renders the column mapping
+// with what the import would do to each row. An uploaded file gets the mapping
+// its column names suggest.
+func (h *ImportHandler) PreviewImport(w http.ResponseWriter, r *http.Request) {
+	leadImport, csvText, ok := h.readImport(w, r)
+	if !ok {
+		return
+	}
+	fields, err := h.fs.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch custom fields: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgImportPreviewError)
+		return
+	}
+	if leadImport.Mapping == nil {
+		leadImport.Mapping = importer.GuessMapping(leadImport.Header, fields)
+	}
+
+	leadImport.DryRun = true
+	log.Printf("previewing import of %d rows", len(leadImport.Rows))
+	result, err := h.ls.ImportLeads(r.Context(), leadImport)
+	mappingError := ""
+	if errors.Is(err, service.ErrInvalidImport) {
+		// The mapping is rendered again, so it can be fixed
+		log.Printf("rejected import mapping: %s", err)
+		mappingError = err.Error()
+	} else if err != nil {
+		log.Printf("failed to preview import: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgImportPreviewError)
+		return
+	}
+
+	if err := views.ImportPreview(leadImport, csvText, fields, result, mappingError).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render import preview: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgImportPreviewError)
+		return
+	}
+	if mappingError != "" {
+		renderNotification(w, r, views.NotificationWarning, MsgImportMappingError)
+		return
+	}
+	renderNotification(w, r, views.NotificationSuccess, MsgImportPreviewResult)
+}
+
+// CommitImport imports the valid rows with the previewed mapping and renders what it did to each row
+func (h *ImportHandler) CommitImport(w http.ResponseWriter, r *http.Request) {
+	leadImport, _, ok := h.readImport(w, r)
+	if !ok {
+		return
+	}
+
+	log.Printf("importing %d rows", len(leadImport.Rows))
+	result, err := h.ls.ImportLeads(r.Context(), leadImport)
+	if errors.Is(err, service.ErrInvalidImport) {
+		log.Printf("rejected import mapping: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgImportMappingError)
+		return
+	}
+	if err != nil {
+		log.Printf("failed to import leads: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgImportError)
+		return
+	}
+
+	if err := views.ImportResults(result).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render import results: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgImportError)
+		return
+	}
+
+	notification := views.NotificationSuccess
+	if result.Count(constants.ImportOutcomeFailed) > 0 {
+		notification = views.NotificationWarning
+	}
+	renderNotification(w, r, notification, importResultMessage(result))
+	h.b.Broadcast("refreshLeadList,refreshLeadStats,refreshFollowups")
+}
+
+// readImport reads the import of an uploaded file or of the CSV text sent
+// back with the preview, together with its mapping. The mapping of an
+// uploaded file is nil. It writes the error response when the import can't
+// be read.
+func (h *ImportHandler) readImport(w http.ResponseWriter, r *http.Request) (*dto.LeadImport, string, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	var csvText string
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile(constants.FormFieldImportFile)
+		if err != nil {
+			log.Printf("failed to read uploaded file: %s", err)
+			http.Error(w, "invalid file", http.StatusBadRequest)
+			renderNotification(w, r, views.NotificationError, MsgImportFileError)
+			return nil, "", false
+		}
+		defer file.Close()
+
+		content, err := io.ReadAll(file)
+		if err != nil {
+			log.Printf("failed to read uploaded file: %s", err)
+			http.Error(w, "invalid file", http.StatusBadRequest)
+			renderNotification(w, r, views.NotificationError, MsgImportFileError)
+			return nil, "", false
+		}
+		csvText = string(content)
+	} else {
+		if err := r.ParseForm(); err != nil {
+			log.Printf("failed to parse form: %s", err)
+			http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
+			renderNotification(w, r, views.NotificationError, MsgImportFileError)
+			return nil, "", false
+		}
+		csvText = r.FormValue(constants.FormFieldImportCSV)
+	}
+
+	leadImport, err := importer.ReadCSV(strings.NewReader(csvText))
+	if err != nil {
+		log.Printf("rejected import file: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgImportFileError)
+		return nil, "", false
+	}
+
+	if mapping, mapped := r.Form[constants.FormFieldImportMapping]; mapped {
+		leadImport.Mapping = mapping
+	}
+	leadImport.ProfileType = constants.ProfileType(r.FormValue(constants.FormFieldKeyProfileType))
+	leadImport.OutreachType = constants.OutreachType(r.FormValue(constants.FormFieldKeyOutreachType))
+	leadImport.OnDuplicate = constants.DuplicateResolution(r.FormValue(constants.FormFieldOnDuplicate))
+	return leadImport, csvText, true
+}
+
+// importResultMessage sums up the outcomes of an import
+func importResultMessage(result *dto.ImportResult) string {
+	return fmt.Sprintf("%d created, %d merged, %d skipped, %d invalid, %d failed.",
+		result.Count(constants.ImportOutcomeCreated),
+		result.Count(constants.ImportOutcomeMerged),
+		result.Count(constants.ImportOutcomeSkipped),
+		result.Count(constants.ImportOutcomeInvalid),
+		result.Count(constants.ImportOutcomeFailed))
+}
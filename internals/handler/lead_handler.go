@@ -1,12 +1,19 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"leadgentracker/internals/export"
+	"leadgentracker/internals/model"
 	"leadgentracker/internals/model/constants"
 	"leadgentracker/internals/model/dto"
+	"leadgentracker/internals/repository"
 	"leadgentracker/internals/service"
 	"leadgentracker/views"
 
@@ -16,23 +23,48 @@ import (
 const (
 	MsgLeadUpdateSuccess     = "Lead updated successfully!"
 	MsgLeadUpdateError       = "Failed to update lead. Please try again."
-	MsgLeadDeleteSuccess     = "Lead deleted successfully!"
+	MsgLeadTransitionError   = "This status change is not allowed for the lead."
+	MsgLeadCustomFieldError  = "A custom field value is invalid. Please check it and try again."
+	MsgLeadCompanyError      = "The selected company no longer exists. Please reload and try again."
+	MsgLeadCampaignError     = "The selected campaign no longer exists. Please reload and try again."
+	MsgLeadChangedError      = "The lead was changed meanwhile. Please reload and try again."
+	MsgLeadNotFoundError     = "The lead no longer exists or is in the trash. Please reload."
+	MsgLeadDeleteSuccess     = "Lead moved to the trash!"
 	MsgLeadDeleteError       = "Failed to delete lead. Please try again."
 	MsgLeadListFilterWarning = "Invalid filters provided. Please try again."
 	MsgLeadListError         = "Failed to fetch leads. Please try again."
+	MsgLeadRestoreSuccess    = "Lead restored successfully!"
+	MsgLeadRestoreError      = "Failed to restore lead. Please try again."
+	MsgLeadPurgeSuccess      = "Lead deleted permanently!"
+	MsgLeadPurgeError        = "Failed to delete lead permanently. Please try again."
+	MsgSequenceStepSuccess   = "Sequence step marked as done!"
+	MsgSequenceStepError     = "Failed to complete the sequence step. Please try again."
+	MsgTrashListError        = "Failed to fetch the trash. Please try again."
+	MsgBulkActionInvalid     = "Select the leads and the value to apply, then try again."
+	MsgBulkActionError       = "Failed to apply the bulk action. Please try again."
 )
 
 type LeadHandler struct {
-	ls *service.LeadService
-	ss *service.StatsService
-	b  *SSEBroadcaster
+	ls  *service.LeadService
+	ss  *service.StatsService
+	ps  *service.PipelineService
+	fs  *service.CustomFieldService
+	cs  *service.CompanyService
+	cms *service.CampaignService
+	ts  *service.MessageTemplateService
+	b   *SSEBroadcaster
 }
 
-func NewLeadHandler(leadService *service.LeadService, statsService *service.StatsService, sseBroadcaster *SSEBroadcaster) *LeadHandler {
+func NewLeadHandler(leadService *service.LeadService, statsService *service.StatsService, pipelineService *service.PipelineService, customFieldService *service.CustomFieldService, companyService *service.CompanyService, campaignService *service.CampaignService, templateService *service.MessageTemplateService, sseBroadcaster *SSEBroadcaster) *LeadHandler {
 	return &LeadHandler{
-		ls: leadService,
-		ss: statsService,
-		b:  sseBroadcaster,
+		ls:  leadService,
+		ss:  statsService,
+		ps:  pipelineService,
+		fs:  customFieldService,
+		cs:  companyService,
+		cms: campaignService,
+		ts:  templateService,
+		b:   sseBroadcaster,
 	}
 }
 
@@ -61,10 +93,59 @@ func (h *LeadHandler) ServeIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	pipelines, err := h.ps.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch pipelines: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	followups, err := h.ls.GetFollowupQueue(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch follow-ups: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	tags, err := h.ls.GetTagCounts(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch tags: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	fields, err := h.fs.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch custom fields: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	companies, err := h.cs.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch companies: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	campaigns, err := h.cms.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch campaigns: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	templates, err := h.ts.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch message templates: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
 	log.Printf("Fetched %d leands and total pages %d", len(leads), totalPages)
 
 	// Render the Index page with data
-	if err := views.Index(totalStats, todayStats, leads, totalPages, filter).Render(r.Context(), w); err != nil {
+	if err := views.Index(totalStats, todayStats, followups, leads, totalPages, filter, pipelines, tags, fields, companies, campaigns, templates).Render(r.Context(), w); err != nil {
 		log.Printf("failed to render index page: %s", err)
 		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
 		return
@@ -88,35 +169,115 @@ func (h *LeadHandler) AddLead(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.ls.CreateLead(r.Context(), &dto.NewLeadProperties{
+	onDuplicate := constants.DuplicateResolution(r.FormValue(constants.FormFieldOnDuplicate))
+	if onDuplicate == "" {
+		onDuplicate = constants.DuplicateResolutionReject
+	}
+	if err := constants.ValidateDuplicateResolution(onDuplicate); err != nil {
+		log.Printf("invalid duplicate resolution provided: %s", err)
+		http.Error(w, "invalid fields provided", http.StatusBadRequest)
+		return
+	}
+
+	// Without a sequence ID the lead gets the default sequence of its outreach type
+	var sequenceID *primitive.ObjectID
+	if sequenceStr := r.FormValue(constants.FormFieldSequenceID); sequenceStr != "" {
+		parsed, err := primitive.ObjectIDFromHex(sequenceStr)
+		if err != nil {
+			log.Printf("invalid sequence ID provided: %s: %s", sequenceStr, err)
+			http.Error(w, "invalid fields provided", http.StatusBadRequest)
+			return
+		}
+		sequenceID = &parsed
+	}
+
+	// Without a campaign ID the lead is not part of any campaign
+	var campaignID *primitive.ObjectID
+	if campaignStr := r.FormValue(constants.FormFieldCampaignID); campaignStr != "" {
+		parsed, err := primitive.ObjectIDFromHex(campaignStr)
+		if err != nil {
+			log.Printf("invalid campaign ID provided: %s: %s", campaignStr, err)
+			http.Error(w, "invalid fields provided", http.StatusBadRequest)
+			return
+		}
+		campaignID = &parsed
+	}
+
+	result, err := h.ls.CreateLead(r.Context(), &dto.NewLeadProperties{
 		ProfileType:  profileType,
 		OutreachType: outreachType,
 		Url:          r.FormValue(constants.FormFieldKeyUrl),
 		Name:         r.FormValue(constants.FormFieldKeyName),
 		PictureUrl:   r.FormValue(constants.FormFieldPictureUrl),
+		OnDuplicate:  onDuplicate,
+		SequenceID:   sequenceID,
+		CampaignID:   campaignID,
 	})
+	var duplicateErr *service.DuplicateLeadError
+	if errors.As(err, &duplicateErr) {
+		log.Printf("rejected duplicate lead: %s", err)
+		writeJSON(w, http.StatusConflict, addLeadResponse{
+			Error:     "a lead with this profile URL already exists",
+			Duplicate: newDuplicateMatch(duplicateErr.Existing),
+		})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidSequence) || errors.Is(err, repository.ErrSequenceNotFound) {
+		log.Printf("rejected lead sequence: %s", err)
+		http.Error(w, "invalid sequence", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repository.ErrCampaignNotFound) {
+		log.Printf("rejected lead campaign: %s", err)
+		http.Error(w, "invalid campaign", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		log.Printf("failed to create new lead: %s", err)
 		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
 		return
 	}
 
-	if err := h.ss.UpdateStats(r.Context(), outreachType); err != nil {
-		log.Printf("failed to update stats: %s", err)
-		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
-		return
+	// Set HTMX triggers to refresh lead stats and lead list
+	if result.Resolution == constants.DuplicateResolutionMerge {
+		// A merge may restore the existing lead from the trash
+		h.b.Broadcast("refreshLeadList,refreshLeadStats,refreshFollowups")
+	} else {
+		h.b.Broadcast("refreshLeadList,refreshLeadStats,renderNewLeadNotification")
 	}
 
-	// Set HTMX triggers to refresh lead stats and lead list
-	h.b.Broadcast("refreshLeadList,refreshLeadStats,renderNewLeadNotification")
-	w.WriteHeader(http.StatusOK)
+	response := addLeadResponse{LeadID: result.Lead.ID.Hex(), Resolution: result.Resolution}
+	if result.Duplicate != nil {
+		response.Duplicate = newDuplicateMatch(result.Duplicate)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// addLeadResponse tells the caller of /add-lead which lead the request ended
+// up in and which existing lead matched its profile URL, if any
+type addLeadResponse struct {
+	LeadID     string                        `json:"leadId,omitempty"`
+	Resolution constants.DuplicateResolution `json:"resolution,omitempty"`
+	Duplicate  *duplicateMatch               `json:"duplicate,omitempty"`
+	Error      string                        `json:"error,omitempty"`
+}
+
+type duplicateMatch struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Trashed bool   `json:"trashed"`
+}
+
+func newDuplicateMatch(lead *model.Lead) *duplicateMatch {
+	return &duplicateMatch{ID: lead.ID.Hex(), Name: lead.Name, URL: lead.URL, Trashed: lead.IsTrashed()}
 }
 
 func (h *LeadHandler) UpdateLead(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseForm(); err != nil {
 		log.Printf("failed to parse form: %s", err)
 		http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
-		h.renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
+		renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
 		return
 	}
 
@@ -124,7 +285,7 @@ func (h *LeadHandler) UpdateLead(w http.ResponseWriter, r *http.Request) {
 	if id == "" {
 		log.Printf("missing ID in URL: %s", r.URL.Query().Encode())
 		http.Error(w, "missing lead ID", http.StatusBadRequest)
-		h.renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
+		renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
 		return
 	}
 
@@ -132,28 +293,146 @@ func (h *LeadHandler) UpdateLead(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Printf("invalid hex ID provided: %s: %s", id, err)
 		http.Error(w, "invalid ID provided", http.StatusBadRequest)
-		h.renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
+		renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
 		return
 	}
 
 	connectionStatus := constants.ConnectionStatus(r.FormValue(constants.FormFieldConnectionStatus))
-	leadTemperature := constants.LeadTemperature(r.FormValue(constants.FormFieldKeyLeadTemperature))
+
+	// An empty due date leaves the follow-up unscheduled
+	var followupDueAt *time.Time
+	if dueStr := r.FormValue(constants.FormFieldFollowupDueAt); dueStr != "" {
+		dueAt, err := time.ParseInLocation("2006-01-02", dueStr, time.Local)
+		if err != nil {
+			log.Printf("invalid follow-up date provided: %s: %s", dueStr, err)
+			http.Error(w, "invalid follow-up date", http.StatusBadRequest)
+			renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
+			return
+		}
+		followupDueAt = &dueAt
+	}
+
+	// An empty company unlinks the lead
+	var companyID *primitive.ObjectID
+	if companyStr := r.FormValue(constants.FormFieldCompanyID); companyStr != "" {
+		parsed, err := primitive.ObjectIDFromHex(companyStr)
+		if err != nil {
+			log.Printf("invalid company ID provided: %s: %s", companyStr, err)
+			http.Error(w, "invalid company ID", http.StatusBadRequest)
+			renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
+			return
+		}
+		companyID = &parsed
+	}
+
+	// An empty campaign takes the lead out of its campaign
+	var campaignID *primitive.ObjectID
+	if campaignStr := r.FormValue(constants.FormFieldCampaignID); campaignStr != "" {
+		parsed, err := primitive.ObjectIDFromHex(campaignStr)
+		if err != nil {
+			log.Printf("invalid campaign ID provided: %s: %s", campaignStr, err)
+			http.Error(w, "invalid campaign ID", http.StatusBadRequest)
+			renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
+			return
+		}
+		campaignID = &parsed
+	}
 
 	// Create lead update from form values
 	updateProps := &dto.UpdateLeadProperties{
 		ID:               objectId,
 		ConnectionStatus: connectionStatus,
-		LeadTemperature:  leadTemperature,
 		FollowupSent:     r.FormValue(constants.FormFieldFollowupSent) != "",
-		Notes:            r.FormValue(constants.FormFieldKeyNotes),
+		FollowupDueAt:    followupDueAt,
+		Tags:             model.NormalizeTags(r.Form[constants.FormFieldTags]),
+		CustomFieldInput: customFieldInput(r),
+		CompanyID:        companyID,
+		CampaignID:       campaignID,
 	}
 
 	// Update the lead
 	updatedLead, err := h.ls.UpdateLead(r.Context(), updateProps)
+	var transitionErr *service.TransitionError
+	if errors.As(err, &transitionErr) {
+		log.Printf("rejected lead update: %s", err)
+		http.Error(w, "invalid status transition", http.StatusUnprocessableEntity)
+		renderNotification(w, r, views.NotificationError, MsgLeadTransitionError)
+		return
+	}
+	if errors.Is(err, service.ErrInvalidCustomFieldValue) {
+		log.Printf("rejected lead update: %s", err)
+		http.Error(w, "invalid custom field value", http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgLeadCustomFieldError)
+		return
+	}
+	if errors.Is(err, repository.ErrCompanyNotFound) {
+		log.Printf("rejected lead update: %s", err)
+		http.Error(w, "company not found", http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgLeadCompanyError)
+		return
+	}
+	if errors.Is(err, repository.ErrCampaignNotFound) {
+		log.Printf("rejected lead update: %s", err)
+		http.Error(w, "campaign not found", http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgLeadCampaignError)
+		return
+	}
+	if errors.Is(err, service.ErrLeadChanged) {
+		log.Printf("rejected lead update: %s", err)
+		http.Error(w, "lead was changed meanwhile", http.StatusConflict)
+		renderNotification(w, r, views.NotificationError, MsgLeadChangedError)
+		return
+	}
+	if errors.Is(err, repository.ErrLeadNotFound) {
+		log.Printf("lead not found: %s", err)
+		http.Error(w, "lead not found", http.StatusNotFound)
+		renderNotification(w, r, views.NotificationError, MsgLeadNotFoundError)
+		return
+	}
 	if err != nil {
 		log.Printf("failed to update lead: %s", err)
 		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
-		h.renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
+		renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
+		return
+	}
+
+	pipeline, err := h.ps.Get(r.Context(), updatedLead.OutreachType)
+	if err != nil {
+		log.Printf("failed to fetch pipeline: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
+		return
+	}
+
+	fields, err := h.fs.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch custom fields: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
+		return
+	}
+
+	companies, err := h.cs.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch companies: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
+		return
+	}
+
+	campaigns, err := h.cms.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch campaigns: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
+		return
+	}
+
+	templates, err := h.ts.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch message templates: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
 		return
 	}
 
@@ -163,19 +442,98 @@ func (h *LeadHandler) UpdateLead(w http.ResponseWriter, r *http.Request) {
 		if err != nil || parsedPage < 1 {
 			log.Printf("error while updating lead: invalid page number: %s [%v]", pageStr, err)
 			http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
-			h.renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
+			renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
 		}
 		page = parsedPage
 	}
 
 	// Render the updated lead details
-	if err := views.Lead(updatedLead, page).Render(r.Context(), w); err != nil {
+	if err := views.Lead(updatedLead, pipeline, fields, companies, campaigns, templates, page, nil).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render lead: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
+		return
+	}
+	renderNotification(w, r, views.NotificationSuccess, MsgLeadUpdateSuccess)
+
+	// The update may have scheduled, moved or completed a follow-up
+	h.b.Broadcast("refreshFollowups")
+}
+
+// CompleteSequenceStep marks the current sequence step of the lead as done
+// and renders the lead with its next step
+func (h *LeadHandler) CompleteSequenceStep(w http.ResponseWriter, r *http.Request) {
+	objectId, page, err := parseLeadAction(r)
+	if err != nil {
+		log.Printf("invalid sequence step request: %s", err)
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgSequenceStepError)
+		return
+	}
+
+	log.Printf("completing sequence step of lead with ID: %s", objectId.Hex())
+	lead, err := h.ls.CompleteSequenceStep(r.Context(), objectId)
+	if errors.Is(err, service.ErrNoSequenceStep) || errors.Is(err, repository.ErrLeadNotFound) {
+		// The step may have been completed in another tab meanwhile
+		log.Printf("rejected sequence step: %s", err)
+		http.Error(w, "no open sequence step", http.StatusConflict)
+		renderNotification(w, r, views.NotificationError, MsgSequenceStepError)
+		return
+	}
+	if err != nil {
+		log.Printf("failed to complete sequence step: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgSequenceStepError)
+		return
+	}
+
+	pipeline, err := h.ps.Get(r.Context(), lead.OutreachType)
+	if err != nil {
+		log.Printf("failed to fetch pipeline: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgSequenceStepError)
+		return
+	}
+
+	fields, err := h.fs.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch custom fields: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgSequenceStepError)
+		return
+	}
+
+	companies, err := h.cs.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch companies: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgSequenceStepError)
+		return
+	}
+
+	campaigns, err := h.cms.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch campaigns: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgSequenceStepError)
+		return
+	}
+
+	templates, err := h.ts.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch message templates: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgSequenceStepError)
+		return
+	}
+
+	if err := views.Lead(lead, pipeline, fields, companies, campaigns, templates, page, nil).Render(r.Context(), w); err != nil {
 		log.Printf("failed to render lead: %s", err)
 		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
-		h.renderNotification(w, r, views.NotificationError, MsgLeadUpdateError)
+		renderNotification(w, r, views.NotificationError, MsgSequenceStepError)
 		return
 	}
-	h.renderNotification(w, r, views.NotificationSuccess, MsgLeadUpdateSuccess)
+	renderNotification(w, r, views.NotificationSuccess, MsgSequenceStepSuccess)
 }
 
 func (h *LeadHandler) DeleteLead(w http.ResponseWriter, r *http.Request) {
@@ -183,7 +541,7 @@ func (h *LeadHandler) DeleteLead(w http.ResponseWriter, r *http.Request) {
 	if id == "" {
 		log.Printf("missing ID in URL: %s", r.URL.Query().Encode())
 		http.Error(w, "missing lead ID", http.StatusBadRequest)
-		h.renderNotification(w, r, views.NotificationError, MsgLeadDeleteError)
+		renderNotification(w, r, views.NotificationError, MsgLeadDeleteError)
 		return
 	}
 
@@ -192,7 +550,7 @@ func (h *LeadHandler) DeleteLead(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Printf("invalid hex ID provided: %s: %s", id, err)
 		http.Error(w, "invalid ID provided", http.StatusBadRequest)
-		h.renderNotification(w, r, views.NotificationError, MsgLeadDeleteError)
+		renderNotification(w, r, views.NotificationError, MsgLeadDeleteError)
 		return
 	}
 
@@ -200,7 +558,7 @@ func (h *LeadHandler) DeleteLead(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Printf("error while deleting lead: %s", err)
 		http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
-		h.renderNotification(w, r, views.NotificationError, MsgLeadDeleteError)
+		renderNotification(w, r, views.NotificationError, MsgLeadDeleteError)
 		return
 	}
 
@@ -210,7 +568,7 @@ func (h *LeadHandler) DeleteLead(w http.ResponseWriter, r *http.Request) {
 		if err != nil || parsedPage < 1 {
 			log.Printf("invalid page number provided: %s [%v]", pageStr, err)
 			http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
-			h.renderNotification(w, r, views.NotificationError, MsgLeadDeleteError)
+			renderNotification(w, r, views.NotificationError, MsgLeadDeleteError)
 			return
 		}
 		page = parsedPage
@@ -221,7 +579,7 @@ func (h *LeadHandler) DeleteLead(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Printf("error while deleting lead: failed to fetch remaining leads: %s", err)
 		http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
-		h.renderNotification(w, r, views.NotificationError, MsgLeadDeleteError)
+		renderNotification(w, r, views.NotificationError, MsgLeadDeleteError)
 	}
 
 	// if only one page left, go to first page
@@ -231,40 +589,262 @@ func (h *LeadHandler) DeleteLead(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			log.Printf("error while deleting lead: failed to fetch remaining leads: %s", err)
 			http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
-			h.renderNotification(w, r, views.NotificationError, MsgLeadDeleteError)
+			renderNotification(w, r, views.NotificationError, MsgLeadDeleteError)
 		}
 	}
 
-	if err := views.LeadList(leads, totalPages, filter).Render(r.Context(), w); err != nil {
+	pipelines, err := h.ps.GetAll(r.Context())
+	if err != nil {
+		log.Printf("error while deleting lead: failed to fetch pipelines: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
+		return
+	}
+
+	tags, err := h.ls.GetTagCounts(r.Context())
+	if err != nil {
+		log.Printf("error while deleting lead: failed to fetch tags: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
+		return
+	}
+
+	fields, err := h.fs.GetAll(r.Context())
+	if err != nil {
+		log.Printf("error while deleting lead: failed to fetch custom fields: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
+		return
+	}
+
+	companies, err := h.cs.GetAll(r.Context())
+	if err != nil {
+		log.Printf("error while deleting lead: failed to fetch companies: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
+		return
+	}
+
+	campaigns, err := h.cms.GetAll(r.Context())
+	if err != nil {
+		log.Printf("error while deleting lead: failed to fetch campaigns: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
+		return
+	}
+
+	templates, err := h.ts.GetAll(r.Context())
+	if err != nil {
+		log.Printf("error while deleting lead: failed to fetch message templates: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
+		return
+	}
+
+	if err := views.LeadList(leads, totalPages, filter, pipelines, tags, fields, companies, campaigns, templates).Render(r.Context(), w); err != nil {
 		log.Printf("failed to render lead list: %s", err)
 		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
-		h.renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
+		renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
+		return
+	}
+	renderNotification(w, r, views.NotificationSuccess, MsgLeadDeleteSuccess)
+
+	// The deleted lead no longer counts towards the stats or the follow-ups
+	h.b.Broadcast("refreshLeadStats,refreshFollowups")
+}
+
+// BulkUpdateLeads applies a bulk action to the selected leads and renders
+// what it did to each of them. The lead list is refreshed over SSE, so the
+// results stay visible next to it.
+func (h *LeadHandler) BulkUpdateLeads(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		log.Printf("failed to parse form: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgBulkActionError)
+		return
+	}
+
+	action := &dto.BulkLeadAction{
+		Action:           constants.BulkAction(r.FormValue(constants.FormFieldBulkAction)),
+		LeadTemperature:  constants.LeadTemperature(r.FormValue(constants.FormFieldLeadTemperature)),
+		ConnectionStatus: constants.ConnectionStatus(r.FormValue(constants.FormFieldConnectionStatus)),
+		Tag:              r.FormValue(constants.FormFieldTag),
+	}
+	for _, id := range r.Form[constants.FormFieldLeadIDs] {
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			log.Printf("invalid hex ID provided: %s: %s", id, err)
+			http.Error(w, "invalid ID provided", http.StatusBadRequest)
+			renderNotification(w, r, views.NotificationError, MsgBulkActionError)
+			return
+		}
+		action.IDs = append(action.IDs, objectID)
+	}
+
+	log.Printf("applying bulk action %s to %d leads", action.Action, len(action.IDs))
+	result, err := h.ls.ApplyBulkAction(r.Context(), action)
+	if errors.Is(err, service.ErrInvalidBulkAction) {
+		log.Printf("rejected bulk action: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgBulkActionInvalid)
+		return
+	}
+	if err != nil {
+		log.Printf("failed to apply bulk action: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, MsgBulkActionError)
 		return
 	}
-	h.renderNotification(w, r, views.NotificationSuccess, MsgLeadDeleteSuccess)
+
+	if err := views.BulkResults(result).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render bulk results: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgBulkActionError)
+		return
+	}
+
+	notification := views.NotificationSuccess
+	if result.Count(constants.BulkOutcomeFailed) > 0 {
+		notification = views.NotificationWarning
+	}
+	renderNotification(w, r, notification, bulkResultMessage(result))
+
+	if result.Count(constants.BulkOutcomeUpdated) > 0 {
+		h.b.Broadcast("refreshLeadList,refreshLeadStats,refreshFollowups")
+	}
+}
+
+// bulkResultMessage sums up the outcomes of a bulk action
+func bulkResultMessage(result *dto.BulkResult) string {
+	return fmt.Sprintf("%d updated, %d unchanged, %d failed.",
+		result.Count(constants.BulkOutcomeUpdated),
+		result.Count(constants.BulkOutcomeUnchanged),
+		result.Count(constants.BulkOutcomeFailed))
 }
 
 func (h *LeadHandler) GetAllLeads(w http.ResponseWriter, r *http.Request) {
 	log.Println("Received get all leads request")
 
-	filter, err := dto.NewLeadFilter(r.URL.Query())
+	pipelines, err := h.ps.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch pipelines: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
+		return
+	}
+
+	fields, err := h.fs.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch custom fields: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
+		return
+	}
+
+	filter, err := dto.NewLeadFilter(r.URL.Query(), pipelines, fields)
 	if err != nil {
 		log.Printf("[WARNING] Invalid filter values provided: %s", err)
-		h.renderNotification(w, r, views.NotificationWarning, MsgLeadListFilterWarning)
+		renderNotification(w, r, views.NotificationWarning, MsgLeadListFilterWarning)
 	}
 
 	leads, totalPages, err := h.ls.GetAllLeadsPaged(r.Context(), filter)
 	if err != nil {
 		log.Printf("failed to fetch leads: %s", err)
 		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
-		h.renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
+		renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
+		return
+	}
+
+	tags, err := h.ls.GetTagCounts(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch tags: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
+		return
+	}
+
+	companies, err := h.cs.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch companies: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
 		return
 	}
 
-	if err := views.LeadList(leads, totalPages, filter).Render(r.Context(), w); err != nil {
+	campaigns, err := h.cms.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch campaigns: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
+		return
+	}
+
+	templates, err := h.ts.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch message templates: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
+		return
+	}
+
+	if err := views.LeadList(leads, totalPages, filter, pipelines, tags, fields, companies, campaigns, templates).Render(r.Context(), w); err != nil {
 		log.Printf("failed to render lead list: %s", err)
 		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
-		h.renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
+		renderNotification(w, r, views.NotificationWarning, MsgLeadListError)
+		return
+	}
+}
+
+// ExportLeads writes every lead matching the filters of the lead list as CSV,
+// with one column per custom field
+func (h *LeadHandler) ExportLeads(w http.ResponseWriter, r *http.Request) {
+	pipelines, err := h.ps.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch pipelines: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	fields, err := h.fs.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch custom fields: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	filter, err := dto.NewLeadFilter(r.URL.Query(), pipelines, fields)
+	if err != nil {
+		log.Printf("invalid export filters provided: %s", err)
+		http.Error(w, "invalid filters", http.StatusBadRequest)
+		return
+	}
+
+	leads, err := h.ls.ExportLeads(r.Context(), filter)
+	if err != nil {
+		log.Printf("failed to fetch leads for export: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("exporting %d leads", len(leads))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="leads-%s.csv"`, time.Now().Format("2006-01-02")))
+	if err := export.WriteLeadsCSV(w, leads, fields); err != nil {
+		log.Printf("failed to write lead export: %s", err)
+	}
+}
+
+func (h *LeadHandler) GetFollowups(w http.ResponseWriter, r *http.Request) {
+	followups, err := h.ls.GetFollowupQueue(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch follow-ups: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	if err := views.FollowupQueue(followups).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render follow-ups: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
 		return
 	}
 }
@@ -292,7 +872,150 @@ func (h *LeadHandler) GetLeadStats(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *LeadHandler) renderNotification(w http.ResponseWriter, r *http.Request, nType views.NotificationType, message string) {
+func (h *LeadHandler) ServeTrash(w http.ResponseWriter, r *http.Request) {
+	log.Println("serving trash page")
+	page, err := parsePage(r)
+	if err != nil {
+		log.Printf("invalid page number provided: %s", err)
+		page = 1
+	}
+
+	leads, totalPages, err := h.ls.GetTrashPaged(r.Context(), page)
+	if err != nil {
+		log.Printf("failed to fetch trashed leads: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	if err := views.TrashPage(leads, totalPages, page, h.ls.Config().TrashRetention).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render trash page: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *LeadHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePage(r)
+	if err != nil {
+		log.Printf("invalid page number provided: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationWarning, MsgTrashListError)
+		return
+	}
+	h.renderTrash(w, r, page)
+}
+
+func (h *LeadHandler) RestoreLead(w http.ResponseWriter, r *http.Request) {
+	objectId, page, err := parseLeadAction(r)
+	if err != nil {
+		log.Printf("invalid restore request: %s", err)
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgLeadRestoreError)
+		return
+	}
+
+	log.Printf("restoring lead with ID: %s", objectId.Hex())
+	if err := h.ls.RestoreLead(r.Context(), objectId); err != nil {
+		log.Printf("error while restoring lead: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgLeadRestoreError)
+		return
+	}
+
+	if h.renderTrash(w, r, page) {
+		renderNotification(w, r, views.NotificationSuccess, MsgLeadRestoreSuccess)
+	}
+	h.b.Broadcast("refreshLeadList,refreshLeadStats,refreshFollowups")
+}
+
+func (h *LeadHandler) PurgeLead(w http.ResponseWriter, r *http.Request) {
+	objectId, page, err := parseLeadAction(r)
+	if err != nil {
+		log.Printf("invalid purge request: %s", err)
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgLeadPurgeError)
+		return
+	}
+
+	log.Printf("purging lead with ID: %s", objectId.Hex())
+	if err := h.ls.PurgeLead(r.Context(), objectId); err != nil {
+		log.Printf("error while purging lead: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgLeadPurgeError)
+		return
+	}
+
+	if h.renderTrash(w, r, page) {
+		renderNotification(w, r, views.NotificationSuccess, MsgLeadPurgeSuccess)
+	}
+}
+
+// renderTrash renders the given trash page, falling back to the last one
+// when it became empty, and tells whether rendering succeeded
+func (h *LeadHandler) renderTrash(w http.ResponseWriter, r *http.Request, page int) bool {
+	leads, totalPages, err := h.ls.GetTrashPaged(r.Context(), page)
+	if err == nil && page > totalPages {
+		page = totalPages
+		leads, totalPages, err = h.ls.GetTrashPaged(r.Context(), page)
+	}
+	if err != nil {
+		log.Printf("failed to fetch trashed leads: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgTrashListError)
+		return false
+	}
+
+	if err := views.TrashList(leads, totalPages, page, h.ls.Config().TrashRetention).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render trash: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, MsgTrashListError)
+		return false
+	}
+	return true
+}
+
+// customFieldInput collects the entered custom field values by field key
+func customFieldInput(r *http.Request) map[string]string {
+	input := make(map[string]string)
+	for name, values := range r.Form {
+		if key, ok := strings.CutPrefix(name, constants.FormFieldCustomFieldPrefix); ok && len(values) > 0 {
+			input[key] = values[0]
+		}
+	}
+	return input
+}
+
+// parsePage reads the optional page query parameter, defaulting to the first page
+func parsePage(r *http.Request) (int, error) {
+	pageStr := r.URL.Query().Get("page")
+	if pageStr == "" {
+		return 1, nil
+	}
+	page, err := strconv.Atoi(pageStr)
+	if err != nil || page < 1 {
+		return 0, fmt.Errorf("invalid page number: %s", pageStr)
+	}
+	return page, nil
+}
+
+// parseLeadAction reads the lead ID and page of an action on a listed lead
+func parseLeadAction(r *http.Request) (primitive.ObjectID, int, error) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		return primitive.NilObjectID, 0, fmt.Errorf("missing lead ID in URL: %s", r.URL.Query().Encode())
+	}
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, 0, fmt.Errorf("invalid hex ID provided: %s: %w", id, err)
+	}
+	page, err := parsePage(r)
+	if err != nil {
+		return primitive.NilObjectID, 0, err
+	}
+	return objectId, page, nil
+}
+
+func renderNotification(w http.ResponseWriter, r *http.Request, nType views.NotificationType, message string) {
 	notification := views.NotificationProps{
 		Type:    nType,
 		Message: message,
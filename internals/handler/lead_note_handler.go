@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/model/dto"
+	"leadgentracker/views"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MsgNoteAddSuccess    = "Note added!"
+	MsgNoteUpdateSuccess = "Note updated!"
+	MsgNoteDeleteSuccess = "Note deleted!"
+	MsgNoteSaveError     = "Failed to save the note. Please try again."
+	MsgNoteInvalid       = "A note needs some text."
+	MsgNoteNotFound      = "The note no longer exists. Please reload and try again."
+	MsgNoteDeleteError   = "Failed to delete the note. Please try again."
+)
+
+// AddNote logs a note on the lead and renders the lead's notes
+func (h *LeadHandler) AddNote(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		log.Printf("failed to parse form: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgNoteSaveError)
+		return
+	}
+
+	properties, err := noteFromForm(r, false)
+	if err != nil {
+		log.Printf("invalid note request: %s", err)
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgNoteSaveError)
+		return
+	}
+
+	log.Printf("adding note to lead %s", properties.LeadID.Hex())
+	lead, err := h.ls.AddNote(r.Context(), properties)
+	if !handleEntityError(w, r, err, noteErrors, MsgNoteSaveError) {
+		return
+	}
+	h.renderNotes(w, r, lead, MsgNoteAddSuccess, MsgNoteSaveError)
+}
+
+// UpdateNote edits a note of the lead and renders the lead's notes
+func (h *LeadHandler) UpdateNote(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		log.Printf("failed to parse form: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgNoteSaveError)
+		return
+	}
+
+	properties, err := noteFromForm(r, true)
+	if err != nil {
+		log.Printf("invalid note request: %s", err)
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgNoteSaveError)
+		return
+	}
+
+	log.Printf("updating note %s of lead %s", properties.NoteID.Hex(), properties.LeadID.Hex())
+	lead, err := h.ls.UpdateNote(r.Context(), properties)
+	if !handleEntityError(w, r, err, noteErrors, MsgNoteSaveError) {
+		return
+	}
+	h.renderNotes(w, r, lead, MsgNoteUpdateSuccess, MsgNoteSaveError)
+}
+
+// DeleteNote removes the note with the noteId query parameter from the lead
+// with the id query parameter and renders the lead's notes
+func (h *LeadHandler) DeleteNote(w http.ResponseWriter, r *http.Request) {
+	leadID, ok := parseID(w, r, "id", "lead", MsgNoteDeleteError)
+	if !ok {
+		return
+	}
+	noteID, ok := parseID(w, r, constants.FormFieldNoteID, "note", MsgNoteDeleteError)
+	if !ok {
+		return
+	}
+
+	log.Printf("deleting note %s of lead %s", noteID.Hex(), leadID.Hex())
+	lead, err := h.ls.DeleteNote(r.Context(), leadID, noteID)
+	if !handleEntityError(w, r, err, noteErrors, MsgNoteDeleteError) {
+		return
+	}
+	h.renderNotes(w, r, lead, MsgNoteDeleteSuccess, MsgNoteDeleteError)
+}
+
+func (h *LeadHandler) renderNotes(w http.ResponseWriter, r *http.Request, lead *model.Lead, successMessage string, errorMessage string) {
+	if err := views.LeadNotes(lead).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render notes: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, errorMessage)
+		return
+	}
+	renderNotification(w, r, views.NotificationSuccess, successMessage)
+}
+
+// noteFromForm reads the note of a parsed form, withNoteID when it edits an existing note
+func noteFromForm(r *http.Request, withNoteID bool) (*dto.NoteProperties, error) {
+	leadID, err := primitive.ObjectIDFromHex(r.FormValue("id"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid lead ID provided: %s: %w", r.FormValue("id"), err)
+	}
+
+	properties := &dto.NoteProperties{
+		LeadID: leadID,
+		Type:   constants.NoteType(r.FormValue(constants.FormFieldNoteType)),
+		Text:   r.FormValue(constants.FormFieldNoteText),
+	}
+	if withNoteID {
+		properties.NoteID, err = primitive.ObjectIDFromHex(r.FormValue(constants.FormFieldNoteID))
+		if err != nil {
+			return nil, fmt.Errorf("invalid note ID provided: %s: %w", r.FormValue(constants.FormFieldNoteID), err)
+		}
+	}
+	return properties, nil
+}
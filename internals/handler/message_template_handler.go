@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/service"
+	"leadgentracker/views"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MsgTemplateAddSuccess    = "Template added successfully!"
+	MsgTemplateUpdateSuccess = "Template updated successfully!"
+	MsgTemplateDeleteSuccess = "Template deleted!"
+	MsgTemplateSaveError     = "Failed to save the template. Please try again."
+	MsgTemplateInvalid       = "Invalid template. Please check the name and the placeholders of the message."
+	MsgTemplateDeleteError   = "Failed to delete the template. Please try again."
+	MsgTemplateUseError      = "Failed to mark the message as sent. Please try again."
+	MsgTemplatePreviewError  = "Failed to write the message. Please try again."
+	MsgTemplateUseInvalid    = "The template can't be used for this lead."
+)
+
+type MessageTemplateHandler struct {
+	ts *service.MessageTemplateService
+	fs *service.CustomFieldService
+	cs *service.CompanyService
+	b  *SSEBroadcaster
+}
+
+func NewMessageTemplateHandler(templateService *service.MessageTemplateService, customFieldService *service.CustomFieldService, companyService *service.CompanyService, sseBroadcaster *SSEBroadcaster) *MessageTemplateHandler {
+	return &MessageTemplateHandler{
+		ts: templateService,
+		fs: customFieldService,
+		cs: companyService,
+		b:  sseBroadcaster,
+	}
+}
+
+func (h *MessageTemplateHandler) ServeTemplates(w http.ResponseWriter, r *http.Request) {
+	log.Println("serving message templates page")
+	templates, usage, err := h.fetchTemplates(r)
+	if err != nil {
+		log.Printf("failed to fetch message templates: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	fields, err := h.fs.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch custom fields: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+
+	if err := views.MessageTemplatesPage(templates, usage, fields).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render message templates page: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *MessageTemplateHandler) AddTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		log.Printf("failed to parse form: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgTemplateSaveError)
+		return
+	}
+
+	messageTemplate := messageTemplateFromForm(r)
+	log.Printf("adding message template %s", messageTemplate.Name)
+	if !handleEntityError(w, r, h.ts.Create(r.Context(), messageTemplate), messageTemplateErrors, MsgTemplateSaveError) {
+		return
+	}
+	h.renderTemplateList(w, r, MsgTemplateAddSuccess, MsgTemplateSaveError)
+}
+
+func (h *MessageTemplateHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		log.Printf("failed to parse form: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, MsgTemplateSaveError)
+		return
+	}
+
+	id, ok := parseID(w, r, "id", "template", MsgTemplateSaveError)
+	if !ok {
+		return
+	}
+
+	messageTemplate := messageTemplateFromForm(r)
+	messageTemplate.ID = id
+	log.Printf("updating message template %s", id.Hex())
+	if !handleEntityError(w, r, h.ts.Update(r.Context(), messageTemplate), messageTemplateErrors, MsgTemplateSaveError) {
+		return
+	}
+	h.renderTemplateList(w, r, MsgTemplateUpdateSuccess, MsgTemplateSaveError)
+
+	// The messages of the lead cards are rendered with the updated template
+	h.b.Broadcast("refreshLeadList")
+}
+
+// DeleteTemplate removes the template with the id query parameter, the leads
+// written with it keep its name
+func (h *MessageTemplateHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "id", "template", MsgTemplateDeleteError)
+	if !ok {
+		return
+	}
+
+	log.Printf("deleting message template %s", id.Hex())
+	if !handleEntityError(w, r, h.ts.Delete(r.Context(), id), messageTemplateErrors, MsgTemplateDeleteError) {
+		return
+	}
+	h.renderTemplateList(w, r, MsgTemplateDeleteSuccess, MsgTemplateDeleteError)
+	h.b.Broadcast("refreshLeadList")
+}
+
+// UseTemplate writes the message to the lead with the template picked in the
+// lead's details and records the template on the lead
+// PreviewTemplate renders the message to the lead with the picked template,
+// without recording that it was sent
+func (h *MessageTemplateHandler) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	leadID, templateID, ok := parseLeadTemplateIDs(w, r, MsgTemplatePreviewError)
+	if !ok {
+		return
+	}
+
+	log.Printf("previewing message to lead %s with template %s", leadID.Hex(), templateID.Hex())
+	lead, messageTemplate, err := h.ts.Preview(r.Context(), leadID, templateID)
+	if !handleEntityError(w, r, err, templateUseErrors, MsgTemplatePreviewError) {
+		return
+	}
+	h.renderLeadMessage(w, r, lead, messageTemplate, MsgTemplatePreviewError)
+}
+
+// UseTemplate records that the message written with the template was sent to the lead
+func (h *MessageTemplateHandler) UseTemplate(w http.ResponseWriter, r *http.Request) {
+	leadID, templateID, ok := parseLeadTemplateIDs(w, r, MsgTemplateUseError)
+	if !ok {
+		return
+	}
+
+	log.Printf("marking message to lead %s with template %s as sent", leadID.Hex(), templateID.Hex())
+	lead, err := h.ts.Use(r.Context(), leadID, templateID)
+	if !handleEntityError(w, r, err, templateUseErrors, MsgTemplateUseError) {
+		return
+	}
+	h.renderLeadMessage(w, r, lead, nil, MsgTemplateUseError)
+}
+
+func (h *MessageTemplateHandler) renderLeadMessage(w http.ResponseWriter, r *http.Request, lead *model.Lead, preview *model.MessageTemplate, errorMessage string) {
+	templates, err := h.ts.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch message templates: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, errorMessage)
+		return
+	}
+
+	fields, err := h.fs.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch custom fields: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, errorMessage)
+		return
+	}
+
+	companies, err := h.cs.GetAll(r.Context())
+	if err != nil {
+		log.Printf("failed to fetch companies: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, errorMessage)
+		return
+	}
+
+	if err := views.LeadMessage(lead, preview, templates, companies, fields).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render message: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationError, errorMessage)
+		return
+	}
+}
+
+func (h *MessageTemplateHandler) renderTemplateList(w http.ResponseWriter, r *http.Request, successMessage string, errorMessage string) {
+	templates, usage, err := h.fetchTemplates(r)
+	if err != nil {
+		log.Printf("failed to fetch message templates: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, errorMessage)
+		return
+	}
+
+	if err := views.MessageTemplateList(templates, usage).Render(r.Context(), w); err != nil {
+		log.Printf("failed to render message template list: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusInternalServerError)
+		renderNotification(w, r, views.NotificationWarning, errorMessage)
+		return
+	}
+	renderNotification(w, r, views.NotificationSuccess, successMessage)
+}
+
+func (h *MessageTemplateHandler) fetchTemplates(r *http.Request) (model.MessageTemplates, map[primitive.ObjectID]int, error) {
+	templates, err := h.ts.GetAll(r.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+	usage, err := h.ts.CountUsage(r.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+	return templates, usage, nil
+}
+
+// parseLeadTemplateIDs reads the IDs of the lead and the template its message is written with
+func parseLeadTemplateIDs(w http.ResponseWriter, r *http.Request, errorMessage string) (primitive.ObjectID, primitive.ObjectID, bool) {
+	if err := r.ParseForm(); err != nil {
+		log.Printf("failed to parse form: %s", err)
+		http.Error(w, constants.ErrorMessage, http.StatusBadRequest)
+		renderNotification(w, r, views.NotificationError, errorMessage)
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	leadID, ok := parseID(w, r, "id", "lead", errorMessage)
+	if !ok {
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	templateID, ok := parseID(w, r, constants.FormFieldTemplateID, "template", errorMessage)
+	if !ok {
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return leadID, templateID, true
+}
+
+func messageTemplateFromForm(r *http.Request) *model.MessageTemplate {
+	return &model.MessageTemplate{
+		Name:         r.FormValue(constants.FormFieldKeyName),
+		OutreachType: constants.OutreachType(r.FormValue(constants.FormFieldKeyOutreachType)),
+		Body:         r.FormValue(constants.FormFieldBody),
+	}
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/service"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	HeaderRequestID    = "X-Request-ID"
+	HeaderForwardedFor = "X-Forwarded-For"
+	DefaultActorHeader = "X-Forwarded-User"
+	ActorAnonymous     = "anonymous"
+)
+
+// WithRequestMetadata attaches the actor and details of every request to its
+// context, so mutations can be attributed in the audit log. The actor is
+// taken from actorHeader as set by an authenticating reverse proxy. Anyone
+// can send that header, so it is only trusted on requests coming from one of
+// the trusted proxies, every other request is made by ActorAnonymous.
+func WithRequestMetadata(actorHeader string, trustedProxies []netip.Prefix, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(HeaderRequestID)
+		if requestID == "" {
+			requestID = primitive.NewObjectID().Hex()
+		}
+		w.Header().Set(HeaderRequestID, requestID)
+
+		actor := ActorAnonymous
+		if fromTrustedProxy(r, trustedProxies) {
+			if forwarded := strings.TrimSpace(r.Header.Get(actorHeader)); forwarded != "" {
+				actor = forwarded
+			}
+		}
+
+		ctx := service.WithAuditContext(r.Context(), actor, model.RequestMetadata{
+			RequestID:    requestID,
+			Method:       r.Method,
+			Path:         r.URL.Path,
+			RemoteAddr:   r.RemoteAddr,
+			ForwardedFor: r.Header.Get(HeaderForwardedFor),
+			UserAgent:    r.UserAgent(),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// fromTrustedProxy tells whether the request was sent from an address of one of the trusted proxies
+func fromTrustedProxy(r *http.Request, trustedProxies []netip.Prefix) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
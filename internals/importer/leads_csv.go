@@ -0,0 +1,132 @@
+// Package importer reads leads from files other tools write
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/model/dto"
+)
+
+// MaxRows caps the rows of a CSV file below its header
+const MaxRows = 2000
+
+const byteOrderMark = "\ufeff"
+
+// ErrInvalidCSV is returned for a file that isn't CSV, has no header or too many rows
+var ErrInvalidCSV = errors.New("invalid CSV file")
+
+// fieldAliases are other common column names of the import fields, normalized
+// like column names are matched, see matchKey
+var fieldAliases = map[string]constants.ImportField{
+	"fullname":         constants.ImportFieldName,
+	"linkedin":         constants.ImportFieldURL,
+	"linkedinurl":      constants.ImportFieldURL,
+	"profile":          constants.ImportFieldURL,
+	"profileurl":       constants.ImportFieldURL,
+	"status":           constants.ImportFieldStage,
+	"connectionstatus": constants.ImportFieldStage,
+	"date":             constants.ImportFieldDateAdded,
+	"added":            constants.ImportFieldDateAdded,
+	"tag":              constants.ImportFieldTags,
+	"note":             constants.ImportFieldNotes,
+	"picture":          constants.ImportFieldPictureURL,
+}
+
+// ReadCSV reads a CSV file with a header row. The cells are trimmed and rows
+// without any value are left out, the others keep the number spreadsheets
+// show for them.
+func ReadCSV(r io.Reader) (*dto.LeadImport, error) {
+	// Excel starts UTF-8 files with a byte order mark
+	buffered := bufio.NewReader(r)
+	if bom, _ := buffered.Peek(len(byteOrderMark)); string(bom) == byteOrderMark {
+		buffered.Discard(len(byteOrderMark))
+	}
+
+	reader := csv.NewReader(buffered)
+	// Spreadsheets leave out the trailing empty cells of a row
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidCSV)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCSV, err)
+	}
+	leadImport := &dto.LeadImport{Header: trimmed(header)}
+	for number := 2; ; number++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCSV, err)
+		}
+
+		cells := trimmed(record)
+		if strings.Join(cells, "") == "" {
+			continue
+		}
+		if len(leadImport.Rows) == MaxRows {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidCSV, MaxRows)
+		}
+		leadImport.Rows = append(leadImport.Rows, dto.ImportRow{Number: number, Cells: cells})
+	}
+	return leadImport, nil
+}
+
+// GuessMapping maps the columns named like an import field, one of its
+// aliases or a custom field to that field. The lead export is read back as it
+// was written. Each field is mapped to the first column matching it only.
+func GuessMapping(header []string, fields []model.CustomField) []string {
+	targets := make(map[string]string)
+	for alias, field := range fieldAliases {
+		targets[alias] = string(field)
+	}
+	for _, field := range constants.ImportFields {
+		targets[matchKey(string(field))] = string(field)
+	}
+	for _, field := range fields {
+		target := constants.FormFieldCustomFieldPrefix + field.Key
+		targets[matchKey(field.Label)] = target
+		targets[matchKey(field.Key)] = target
+		targets[matchKey(target)] = target
+	}
+
+	mapping := make([]string, len(header))
+	mapped := make(map[string]bool)
+	for i, column := range header {
+		target, exists := targets[matchKey(column)]
+		if exists && !mapped[target] {
+			mapping[i] = target
+			mapped[target] = true
+		}
+	}
+	return mapping
+}
+
+// matchKey ignores the case, spacing and punctuation of a column name, so
+// "Date Added" matches dateAdded
+func matchKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+func trimmed(values []string) []string {
+	for i, value := range values {
+		values[i] = strings.TrimSpace(value)
+	}
+	return values
+}
@@ -0,0 +1,147 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collectionMigrations = "migrations"
+
+// Migration is a versioned, reversible change of the stored documents. Once
+// released a migration must not change, later fixes go into a new version.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+	Down        func(ctx context.Context, db *mongo.Database) error
+}
+
+// Status tells whether a migration was applied and when
+type Status struct {
+	Migration Migration
+	AppliedAt *time.Time
+}
+
+type appliedMigration struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+}
+
+// Runner applies and rolls back migrations, recording the applied ones in the migrations collection
+type Runner struct {
+	db         *mongo.Database
+	col        *mongo.Collection
+	migrations []Migration
+}
+
+func NewRunner(db *mongo.Database, migrations []Migration) *Runner {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	return &Runner{
+		db:         db,
+		col:        db.Collection(collectionMigrations),
+		migrations: sorted,
+	}
+}
+
+// Up applies every pending migration in version order and returns the applied ones
+func (r *Runner) Up(ctx context.Context) ([]Migration, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range r.migrations {
+		if _, isApplied := applied[migration.Version]; isApplied {
+			continue
+		}
+
+		log.Printf("applying migration %d: %s", migration.Version, migration.Description)
+		if err := migration.Up(ctx, r.db); err != nil {
+			return done, fmt.Errorf("failed to apply migration %d: %w", migration.Version, err)
+		}
+
+		record := appliedMigration{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now()}
+		if _, err := r.col.InsertOne(ctx, record); err != nil {
+			return done, fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down rolls back the given number of most recently applied migrations and returns the rolled back ones
+func (r *Runner) Down(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(r.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := r.migrations[i]
+		if _, isApplied := applied[migration.Version]; !isApplied {
+			continue
+		}
+
+		log.Printf("rolling back migration %d: %s", migration.Version, migration.Description)
+		if err := migration.Down(ctx, r.db); err != nil {
+			return done, fmt.Errorf("failed to roll back migration %d: %w", migration.Version, err)
+		}
+
+		if _, err := r.col.DeleteOne(ctx, bson.M{"_id": migration.Version}); err != nil {
+			return done, fmt.Errorf("failed to remove record of migration %d: %w", migration.Version, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Status lists every known migration in version order
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(r.migrations))
+	for _, migration := range r.migrations {
+		status := Status{Migration: migration}
+		if record, isApplied := applied[migration.Version]; isApplied {
+			status.AppliedAt = &record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (r *Runner) applied(ctx context.Context) (map[int]appliedMigration, error) {
+	cursor, err := r.col.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []appliedMigration
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode applied migrations: %w", err)
+	}
+
+	applied := make(map[int]appliedMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
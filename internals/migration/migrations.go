@@ -0,0 +1,446 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migrations is the ordered list of all schema migrations. Field names are
+// spelled out instead of using the repository constants, so a migration
+// keeps working after the constants move on.
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "rename lead fields to their explicitly tagged camelCase names",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return renameFields(ctx, db.Collection("leads"), leadFieldRenames)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return renameFields(ctx, db.Collection("leads"), invert(leadFieldRenames))
+		},
+	},
+	{
+		Version:     2,
+		Description: "index leads by date for the paged lead list",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("leads").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "date", Value: -1}},
+				Options: options.Index().SetName("leads_date"),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("leads").Indexes().DropOne(ctx, "leads_date")
+			return err
+		},
+	},
+	{
+		Version:     3,
+		Description: "index audit entries by time and by entity",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("audit").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "timestamp", Value: -1}},
+					Options: options.Index().SetName("audit_timestamp"),
+				},
+				{
+					Keys:    bson.D{{Key: "entityId", Value: 1}, {Key: "timestamp", Value: -1}},
+					Options: options.Index().SetName("audit_entity"),
+				},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			// Only the indexes are dropped, the audit trail itself is kept
+			for _, name := range []string{"audit_timestamp", "audit_entity"} {
+				if _, err := db.Collection("audit").Indexes().DropOne(ctx, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     4,
+		Description: "key leads by normalized profile URL and mark existing duplicates",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			if err := backfillNormalizedURLs(ctx, db.Collection("leads")); err != nil {
+				return err
+			}
+			_, err := db.Collection("leads").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "normalizedUrl", Value: 1}},
+				Options: options.Index().
+					SetName("leads_normalized_url").
+					SetUnique(true).
+					SetPartialFilterExpression(bson.D{{Key: "normalizedUrl", Value: bson.D{{Key: "$type", Value: "string"}}}}),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			if _, err := db.Collection("leads").Indexes().DropOne(ctx, "leads_normalized_url"); err != nil {
+				return err
+			}
+			_, err := db.Collection("leads").UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"normalizedUrl": "", "duplicateOf": ""}})
+			return err
+		},
+	},
+	{
+		Version:     5,
+		Description: "full-text index leads by name, URL and notes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("leads").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "name", Value: "text"}, {Key: "url", Value: "text"}, {Key: "notes", Value: "text"}},
+				Options: options.Index().
+					SetName("leads_text").
+					SetWeights(bson.D{{Key: "name", Value: 10}, {Key: "url", Value: 5}, {Key: "notes", Value: 1}}),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("leads").Indexes().DropOne(ctx, "leads_text")
+			return err
+		},
+	},
+	{
+		Version:     6,
+		Description: "index scheduled follow-ups by due date",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("leads").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "followupSent", Value: 1}, {Key: "followupDueAt", Value: 1}},
+				Options: options.Index().
+					SetName("leads_followup_due").
+					SetPartialFilterExpression(bson.D{{Key: "followupDueAt", Value: bson.D{{Key: "$exists", Value: true}}}}),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("leads").Indexes().DropOne(ctx, "leads_followup_due")
+			return err
+		},
+	},
+	{
+		// The scores themselves are set by the rescoring on startup
+		Version:     7,
+		Description: "index leads by score for the lead list order",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("leads").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "score", Value: -1}, {Key: "date", Value: -1}},
+				Options: options.Index().SetName("leads_score"),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("leads").Indexes().DropOne(ctx, "leads_score")
+			return err
+		},
+	},
+	{
+		Version:     8,
+		Description: "index leads by tag",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("leads").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "tags", Value: 1}},
+				Options: options.Index().SetName("leads_tags"),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("leads").Indexes().DropOne(ctx, "leads_tags")
+			return err
+		},
+	},
+	{
+		Version:     9,
+		Description: "index leads by company",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("leads").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "companyId", Value: 1}},
+				Options: options.Index().SetName("leads_company"),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("leads").Indexes().DropOne(ctx, "leads_company")
+			return err
+		},
+	},
+	{
+		Version:     10,
+		Description: "index leads by campaign",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("leads").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "campaignId", Value: 1}},
+				Options: options.Index().SetName("leads_campaign"),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("leads").Indexes().DropOne(ctx, "leads_campaign")
+			return err
+		},
+	},
+	{
+		Version:     11,
+		Description: "turn the notes text of leads into a log of notes and search their text",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			if err := notesTextToLog(ctx, db.Collection("leads")); err != nil {
+				return err
+			}
+			return rebuildLeadsTextIndex(ctx, db.Collection("leads"), "notes.text")
+		},
+		// Lossy: the notes are joined into one text, their IDs, types, authors
+		// and times can't be restored by running Up again
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			if err := notesLogToText(ctx, db.Collection("leads")); err != nil {
+				return err
+			}
+			return rebuildLeadsTextIndex(ctx, db.Collection("leads"), "notes")
+		},
+	},
+	{
+		Version:     12,
+		Description: "lowercase the tags of leads",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return lowercaseTags(ctx, db.Collection("leads"))
+		},
+		// The spelling of the tags is lost, lowercased tags work the same without this migration
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return nil
+		},
+	},
+}
+
+// leadFieldRenames maps the lowercased names the driver derived from the
+// untagged model.Lead fields to the names set by its bson tags
+var leadFieldRenames = map[string]string{
+	"connectionstatus": "connectionStatus",
+	"leadtemperature":  "leadTemperature",
+	"profiletype":      "profileType",
+	"outreachtype":     "outreachType",
+	"followupsent":     "followupSent",
+	"pictureurl":       "pictureUrl",
+}
+
+func renameFields(ctx context.Context, col *mongo.Collection, renames map[string]string) error {
+	rename := bson.M{}
+	for from, to := range renames {
+		rename[from] = to
+	}
+
+	// $rename skips documents that lack the old field, so this is safe to re-run
+	if _, err := col.UpdateMany(ctx, bson.M{}, bson.M{"$rename": rename}); err != nil {
+		return fmt.Errorf("failed to rename fields of %s: %w", col.Name(), err)
+	}
+	return nil
+}
+
+// backfillNormalizedURLs gives the oldest lead of every profile URL the
+// normalized URL and marks the newer ones as its duplicates, so the unique
+// index can be built over data added before duplicates were detected
+func backfillNormalizedURLs(ctx context.Context, col *mongo.Collection) error {
+	filter := bson.M{"normalizedUrl": bson.M{"$exists": false}, "duplicateOf": bson.M{"$exists": false}}
+	cursor, err := col.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "date", Value: 1}}))
+	if err != nil {
+		return fmt.Errorf("failed to list leads: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	originals := make(map[string]primitive.ObjectID)
+	for cursor.Next(ctx) {
+		var lead struct {
+			ID  primitive.ObjectID `bson:"_id"`
+			URL string             `bson:"url"`
+		}
+		if err := cursor.Decode(&lead); err != nil {
+			return fmt.Errorf("failed to decode lead: %w", err)
+		}
+
+		normalizedURL := normalizeProfileURL(lead.URL)
+		if normalizedURL == "" {
+			continue
+		}
+
+		update := bson.M{"$set": bson.M{"normalizedUrl": normalizedURL}}
+		if original, exists := originals[normalizedURL]; exists {
+			update = bson.M{"$set": bson.M{"duplicateOf": original}}
+		} else {
+			originals[normalizedURL] = lead.ID
+		}
+		if _, err := col.UpdateByID(ctx, lead.ID, update); err != nil {
+			return fmt.Errorf("failed to update lead %s: %w", lead.ID.Hex(), err)
+		}
+	}
+	return cursor.Err()
+}
+
+// normalizeProfileURL is model.NormalizeProfileURL as it was when migration 4
+// was added. It is kept here, so the keys the migration writes don't change
+// when the model learns new ways to write profile links.
+func normalizeProfileURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return ""
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return strings.ToLower(strings.TrimRight(rawURL, "/"))
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	if strings.HasSuffix(host, ".linkedin.com") {
+		host = "linkedin.com"
+	}
+
+	path := strings.ToLower(strings.TrimRight(parsed.Path, "/"))
+	if host == "linkedin.com" {
+		segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+		if len(segments) > 2 && segments[0] == "in" {
+			path = "/in/" + segments[1]
+		}
+	}
+
+	return host + path
+}
+
+// notesTextToLog turns the notes text of every lead into a log holding it as
+// a single note dated when the lead was added, blank texts are dropped. The
+// note is written field by field, the way model.NotesFromText built it when
+// this migration was added.
+func notesTextToLog(ctx context.Context, col *mongo.Collection) error {
+	cursor, err := col.Find(ctx, bson.M{"notes": bson.M{"$type": "string"}})
+	if err != nil {
+		return fmt.Errorf("failed to list leads: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var lead struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Notes string             `bson:"notes"`
+			Date  time.Time          `bson:"date"`
+		}
+		if err := cursor.Decode(&lead); err != nil {
+			return fmt.Errorf("failed to decode lead: %w", err)
+		}
+
+		update := bson.M{"$unset": bson.M{"notes": ""}}
+		if text := strings.TrimSpace(lead.Notes); text != "" {
+			note := bson.M{"id": primitive.NewObjectID(), "text": text, "createdAt": lead.Date}
+			update = bson.M{"$set": bson.M{"notes": bson.A{note}}}
+		}
+		if _, err := col.UpdateByID(ctx, lead.ID, update); err != nil {
+			return fmt.Errorf("failed to update lead %s: %w", lead.ID.Hex(), err)
+		}
+	}
+	return cursor.Err()
+}
+
+// notesLogToText joins the texts of the notes of every lead back into a
+// single text, one note per line
+func notesLogToText(ctx context.Context, col *mongo.Collection) error {
+	cursor, err := col.Find(ctx, bson.M{"notes": bson.M{"$type": "array"}})
+	if err != nil {
+		return fmt.Errorf("failed to list leads: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var lead struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Notes []struct {
+				Text string `bson:"text"`
+			} `bson:"notes"`
+		}
+		if err := cursor.Decode(&lead); err != nil {
+			return fmt.Errorf("failed to decode lead: %w", err)
+		}
+
+		texts := make([]string, len(lead.Notes))
+		for i, note := range lead.Notes {
+			texts[i] = note.Text
+		}
+		if _, err := col.UpdateByID(ctx, lead.ID, bson.M{"$set": bson.M{"notes": strings.Join(texts, "\n")}}); err != nil {
+			return fmt.Errorf("failed to update lead %s: %w", lead.ID.Hex(), err)
+		}
+	}
+	return cursor.Err()
+}
+
+// lowercaseTags lowercases the tags of every lead and drops the tags that
+// only differed in case from an earlier one, the way model.NormalizeTags
+// stores tags since this migration
+func lowercaseTags(ctx context.Context, col *mongo.Collection) error {
+	cursor, err := col.Find(ctx, bson.M{"tags.0": bson.M{"$exists": true}})
+	if err != nil {
+		return fmt.Errorf("failed to list leads: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var lead struct {
+			ID   primitive.ObjectID `bson:"_id"`
+			Tags []string           `bson:"tags"`
+		}
+		if err := cursor.Decode(&lead); err != nil {
+			return fmt.Errorf("failed to decode lead: %w", err)
+		}
+
+		var tags []string
+		for _, tag := range lead.Tags {
+			if tag = strings.ToLower(tag); !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		if slices.Equal(tags, lead.Tags) {
+			continue
+		}
+		if _, err := col.UpdateByID(ctx, lead.ID, bson.M{"$set": bson.M{"tags": tags}}); err != nil {
+			return fmt.Errorf("failed to update lead %s: %w", lead.ID.Hex(), err)
+		}
+	}
+	return cursor.Err()
+}
+
+// rebuildLeadsTextIndex recreates the full-text index of migration 5 with the
+// notes read from notesField. A missing index is created, so a migration that
+// failed after dropping it can be re-run.
+func rebuildLeadsTextIndex(ctx context.Context, col *mongo.Collection, notesField string) error {
+	if _, err := col.Indexes().DropOne(ctx, "leads_text"); err != nil && !isIndexNotFound(err) {
+		return fmt.Errorf("failed to drop the leads text index: %w", err)
+	}
+	_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "name", Value: "text"}, {Key: "url", Value: "text"}, {Key: notesField, Value: "text"}},
+		Options: options.Index().
+			SetName("leads_text").
+			SetWeights(bson.D{{Key: "name", Value: 10}, {Key: "url", Value: 5}, {Key: notesField, Value: 1}}),
+	})
+	return err
+}
+
+// isIndexNotFound tells whether dropping an index failed because it doesn't exist
+func isIndexNotFound(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(27)
+}
+
+func invert(renames map[string]string) map[string]string {
+	inverted := make(map[string]string, len(renames))
+	for from, to := range renames {
+		inverted[to] = from
+	}
+	return inverted
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"leadgentracker/internals/model/constants"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditEntry records a single mutation, who made it and the state before and after it
+type AuditEntry struct {
+	ID         primitive.ObjectID    `json:"id" bson:"_id,omitempty"`
+	Timestamp  time.Time             `json:"timestamp" bson:"timestamp"`
+	Actor      string                `json:"actor" bson:"actor"`
+	Action     constants.AuditAction `json:"action" bson:"action"`
+	EntityType constants.AuditEntity `json:"entityType" bson:"entityType"`
+	EntityID   string                `json:"entityId" bson:"entityId"`
+	Request    RequestMetadata       `json:"request" bson:"request"`
+	Before     AuditSnapshot         `json:"before" bson:"before,omitempty"`
+	After      AuditSnapshot         `json:"after" bson:"after,omitempty"`
+}
+
+// RequestMetadata describes the HTTP request that caused a mutation. It is
+// empty for mutations made by commands and background jobs.
+type RequestMetadata struct {
+	RequestID    string `json:"requestId,omitempty" bson:"requestId,omitempty"`
+	Method       string `json:"method,omitempty" bson:"method,omitempty"`
+	Path         string `json:"path,omitempty" bson:"path,omitempty"`
+	RemoteAddr   string `json:"remoteAddr,omitempty" bson:"remoteAddr,omitempty"`
+	ForwardedFor string `json:"forwardedFor,omitempty" bson:"forwardedFor,omitempty"`
+	UserAgent    string `json:"userAgent,omitempty" bson:"userAgent,omitempty"`
+}
+
+// AuditSnapshot is a JSON encoded document. It is stored as text so every
+// storage backend keeps it readable, and embedded as-is in JSON output.
+type AuditSnapshot string
+
+func (s AuditSnapshot) MarshalJSON() ([]byte, error) {
+	if s == "" {
+		return []byte("null"), nil
+	}
+	return []byte(s), nil
+}
+
+func (s *AuditSnapshot) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*s = ""
+		return nil
+	}
+	compacted, err := json.Marshal(json.RawMessage(data))
+	if err != nil {
+		return err
+	}
+	*s = AuditSnapshot(compacted)
+	return nil
+}
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"leadgentracker/internals/model/constants"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Campaign is a distinct outreach effort leads are assigned to, so the
+// efforts can be compared. Leads link to it through their CampaignID.
+type Campaign struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name        string             `json:"name" bson:"name"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// Campaigns are the campaigns leads can be assigned to, the newest first
+type Campaigns []Campaign
+
+// Find returns the campaign with the ID, nil when the ID is nil or unknown
+func (c Campaigns) Find(id *primitive.ObjectID) *Campaign {
+	if id == nil {
+		return nil
+	}
+	for i := range c {
+		if c[i].ID == *id {
+			return &c[i]
+		}
+	}
+	return nil
+}
+
+// Normalize trims the campaign's name and description
+func (c *Campaign) Normalize() {
+	c.Name = strings.Join(strings.Fields(c.Name), " ")
+	c.Description = strings.TrimSpace(c.Description)
+}
+
+func (c *Campaign) Validate() error {
+	if c.Name == "" {
+		return errors.New("campaign has no name")
+	}
+	return nil
+}
+
+// CampaignStats sums up the leads of a campaign that are not in the trash.
+// Acceptance is only tracked for connection requests, responses for all leads.
+type CampaignStats struct {
+	Leads       int
+	Connections int
+	InMails     int
+	Accepted    int
+	Responded   int
+}
+
+// AcceptanceRate is the percentage of connection requests that were accepted
+func (s CampaignStats) AcceptanceRate() int {
+	return percentage(s.Accepted, s.Connections)
+}
+
+// ResponseRate is the percentage of leads that responded
+func (s CampaignStats) ResponseRate() int {
+	return percentage(s.Responded, s.Leads)
+}
+
+// CampaignStageCount counts the leads of a campaign outside the trash that
+// are at the same stage of the same outreach type, split by whether they were
+// moved to the accepted and the responded stage before
+type CampaignStageCount struct {
+	CampaignID       primitive.ObjectID
+	OutreachType     constants.OutreachType
+	ConnectionStatus constants.ConnectionStatus
+	MovedToAccepted  bool
+	MovedToResponded bool
+	Count            int
+}
+
+// NewCampaignStats sums up the stage counts of every campaign by campaign ID
+func NewCampaignStats(counts []CampaignStageCount, pipelines Pipelines) map[primitive.ObjectID]CampaignStats {
+	stats := make(map[primitive.ObjectID]CampaignStats)
+	for _, count := range counts {
+		campaignStats := stats[count.CampaignID]
+		campaignStats.Leads += count.Count
+		pipeline := pipelines.For(count.OutreachType)
+		if count.OutreachType == constants.OutreachTypeConnection {
+			campaignStats.Connections += count.Count
+			if count.MovedToAccepted || pipeline.AtOrAfter(count.ConnectionStatus, constants.ConnectionStatusAccepted) {
+				campaignStats.Accepted += count.Count
+			}
+		} else {
+			campaignStats.InMails += count.Count
+		}
+		if count.MovedToResponded || pipeline.AtOrAfter(count.ConnectionStatus, constants.ConnectionStatusResponded) {
+			campaignStats.Responded += count.Count
+		}
+		stats[count.CampaignID] = campaignStats
+	}
+	return stats
+}
+
+// AtOrAfter tells whether the stage is the one with the key or a later one of the pipeline
+func (p *Pipeline) AtOrAfter(stage constants.ConnectionStatus, key constants.ConnectionStatus) bool {
+	if stage == key {
+		return true
+	}
+	index := p.StageIndex(key)
+	return index >= 0 && p.StageIndex(stage) > index
+}
+
+// MovedTo tells whether the lead's history has a move to the stage
+func (l *Lead) MovedTo(key constants.ConnectionStatus) bool {
+	return slices.ContainsFunc(l.StatusHistory, func(change StatusChange) bool {
+		return change.Field == constants.LeadFieldConnectionStatus && change.NewValue == string(key)
+	})
+}
+
+func percentage(part int, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return part * 100 / total
+}
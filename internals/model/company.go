@@ -0,0 +1,134 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"leadgentracker/internals/model/constants"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Company is the account several leads work at. Leads link to it through
+// their CompanyID.
+type Company struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name        string             `json:"name" bson:"name"`
+	Domain      string             `json:"domain,omitempty" bson:"domain,omitempty"`
+	LinkedInURL string             `json:"linkedinUrl,omitempty" bson:"linkedinUrl,omitempty"`
+	Notes       string             `json:"notes,omitempty" bson:"notes,omitempty"`
+}
+
+// Companies are the companies leads can be linked to, ordered by name
+type Companies []Company
+
+// Find returns the company with the ID, nil when the ID is nil or unknown
+func (c Companies) Find(id *primitive.ObjectID) *Company {
+	if id == nil {
+		return nil
+	}
+	for i := range c {
+		if c[i].ID == *id {
+			return &c[i]
+		}
+	}
+	return nil
+}
+
+// Normalize trims the company's details and reduces the domain to its bare
+// host, see NormalizeDomain
+func (c *Company) Normalize() {
+	c.Name = strings.Join(strings.Fields(c.Name), " ")
+	c.Domain = NormalizeDomain(c.Domain)
+	c.LinkedInURL = strings.TrimSpace(c.LinkedInURL)
+	c.Notes = strings.TrimSpace(c.Notes)
+}
+
+// Validate checks that the normalized company has a name, a plausible domain
+// and a LinkedIn company page URL, the last two being optional
+func (c *Company) Validate() error {
+	if c.Name == "" {
+		return errors.New("company has no name")
+	}
+	if c.Domain != "" && (!strings.Contains(c.Domain, ".") || strings.ContainsAny(c.Domain, " /")) {
+		return fmt.Errorf("invalid domain: %s", c.Domain)
+	}
+	if c.LinkedInURL != "" && !strings.HasPrefix(NormalizeProfileURL(c.LinkedInURL), "linkedin.com/company/") {
+		return fmt.Errorf("invalid LinkedIn company URL: %s", c.LinkedInURL)
+	}
+	return nil
+}
+
+// NormalizeDomain reduces a domain or website URL to its lowercase host
+// without "www.": "https://www.Example.com/about" becomes "example.com"
+func NormalizeDomain(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return strings.ToLower(strings.TrimPrefix(raw, "https://"))
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+// CompanyStats aggregates the leads at a company that are not in the trash
+type CompanyStats struct {
+	Leads        int
+	Hot          int
+	AverageScore int
+	// FollowupsDue counts the follow-ups due today or overdue
+	FollowupsDue int
+	// Stages counts the leads by stage label, in pipeline order
+	Stages []StageCount
+}
+
+type StageCount struct {
+	Label string
+	Count int
+}
+
+// NewCompanyStats aggregates the leads of a company. Stages of both pipelines
+// with the same label are counted together.
+func NewCompanyStats(leads []Lead, pipelines Pipelines, now time.Time) CompanyStats {
+	stats := CompanyStats{Leads: len(leads)}
+	if len(leads) == 0 {
+		return stats
+	}
+
+	totalScore := 0
+	stageCounts := make(map[string]int)
+	for _, lead := range leads {
+		totalScore += lead.Score
+		if lead.LeadTemperature == constants.LeadTemperatureHot {
+			stats.Hot++
+		}
+		if state := lead.FollowupState(now); state == constants.FollowupStateDue || state == constants.FollowupStateOverdue {
+			stats.FollowupsDue++
+		}
+		stageCounts[pipelines.For(lead.OutreachType).Label(lead.ConnectionStatus)]++
+	}
+	stats.AverageScore = totalScore / len(leads)
+
+	for _, stage := range pipelines.Stages("") {
+		if count := stageCounts[stage.Label]; count > 0 {
+			stats.Stages = append(stats.Stages, StageCount{Label: stage.Label, Count: count})
+			delete(stageCounts, stage.Label)
+		}
+	}
+	// Leads at a stage their pipeline no longer has come last, under their stage key
+	for _, label := range slices.Sorted(maps.Keys(stageCounts)) {
+		stats.Stages = append(stats.Stages, StageCount{Label: label, Count: stageCounts[label]})
+	}
+	return stats
+}
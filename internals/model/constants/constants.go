@@ -1,20 +1,40 @@
 package constants
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+)
 
 type OutreachType string
 type ConnectionStatus string
 type InMailStatus string
 type LeadTemperature string
 type ProfileType string
+type LeadField string
+type AuditAction string
+type AuditEntity string
+type DuplicateResolution string
+type FollowupState string
+type ScoreSignal string
+type TagMatch string
+type CustomFieldType string
+type NoteType string
+type BulkAction string
+type BulkOutcome string
+type ImportField string
+type ImportOutcome string
 
 const (
 	OutreachTypeConnection OutreachType = "connection"
 	OutreachTypeInMail     OutreachType = "inMail"
 
-	ConnectionStatusPending   ConnectionStatus = "pending"
-	ConnectionStatusResponded ConnectionStatus = "responded"
-	ConnectionStatusAccepted  ConnectionStatus = "accepted"
+	// Stage keys of the default pipelines, see model.DefaultPipelines
+	ConnectionStatusPending       ConnectionStatus = "pending"
+	ConnectionStatusResponded     ConnectionStatus = "responded"
+	ConnectionStatusAccepted      ConnectionStatus = "accepted"
+	ConnectionStatusMessaged      ConnectionStatus = "messaged"
+	ConnectionStatusMeetingBooked ConnectionStatus = "meetingBooked"
+	ConnectionStatusClosed        ConnectionStatus = "closed"
 
 	LeadTemperatureCold LeadTemperature = "cold"
 	LeadTemperatureHot  LeadTemperature = "hot"
@@ -22,51 +42,198 @@ const (
 	ProfileTypePublic  ProfileType = "public"
 	ProfileTypePrivate ProfileType = "private"
 
+	LeadFieldConnectionStatus LeadField = "connectionStatus"
+	LeadFieldLeadTemperature  LeadField = "leadTemperature"
+	LeadFieldFollowupSent     LeadField = "followupSent"
+	LeadFieldSequenceStep     LeadField = "sequenceStep"
+
+	AuditActionCreate       AuditAction = "create"
+	AuditActionUpdate       AuditAction = "update"
+	AuditActionDelete       AuditAction = "delete"
+	AuditActionRestore      AuditAction = "restore"
+	AuditActionPurge        AuditAction = "purge"
+	AuditActionStatsUpdate  AuditAction = "statsUpdate"
+	AuditActionStatsRebuild AuditAction = "statsRebuild"
+
+	DuplicateResolutionReject DuplicateResolution = "reject"
+	DuplicateResolutionMerge  DuplicateResolution = "merge"
+	DuplicateResolutionCreate DuplicateResolution = "create"
+
+	FollowupStateNone      FollowupState = ""
+	FollowupStateScheduled FollowupState = "scheduled"
+	FollowupStateDue       FollowupState = "due"
+	FollowupStateOverdue   FollowupState = "overdue"
+
+	AuditEntityLead        AuditEntity = "lead"
+	AuditEntityStats       AuditEntity = "stats"
+	AuditEntityPipeline    AuditEntity = "pipeline"
+	AuditEntitySequence    AuditEntity = "sequence"
+	AuditEntityScoring     AuditEntity = "scoring"
+	AuditEntityCustomField AuditEntity = "customField"
+	AuditEntityCompany     AuditEntity = "company"
+	AuditEntityCampaign    AuditEntity = "campaign"
+	AuditEntityTemplate    AuditEntity = "messageTemplate"
+
+	CustomFieldTypeText   CustomFieldType = "text"
+	CustomFieldTypeNumber CustomFieldType = "number"
+	CustomFieldTypeDate   CustomFieldType = "date"
+	CustomFieldTypeSelect CustomFieldType = "select"
+
+	// TagMatchAny lists leads with any of the filtered tags, TagMatchAll only those with all of them
+	TagMatchAny TagMatch = "any"
+	TagMatchAll TagMatch = "all"
+
+	ScoreSignalProfileType ScoreSignal = "profileType"
+	ScoreSignalStage       ScoreSignal = "stage"
+	ScoreSignalFollowup    ScoreSignal = "followup"
+	ScoreSignalResponse    ScoreSignal = "response"
+	ScoreSignalKeyword     ScoreSignal = "keyword"
+
+	// NoteTypeNone marks a note that is not about a specific kind of contact
+	NoteTypeNone    NoteType = ""
+	NoteTypeCall    NoteType = "call"
+	NoteTypeMessage NoteType = "message"
+	NoteTypeMeeting NoteType = "meeting"
+
+	BulkActionSetTemperature   BulkAction = "setTemperature"
+	BulkActionSetStatus        BulkAction = "setStatus"
+	BulkActionMarkFollowupSent BulkAction = "markFollowupSent"
+	BulkActionAddTag           BulkAction = "addTag"
+	BulkActionDelete           BulkAction = "delete"
+
+	BulkOutcomeUpdated   BulkOutcome = "updated"
+	BulkOutcomeUnchanged BulkOutcome = "unchanged"
+	BulkOutcomeFailed    BulkOutcome = "failed"
+
+	// The import fields match the column names of the lead export
+	ImportFieldName          ImportField = "name"
+	ImportFieldURL           ImportField = "url"
+	ImportFieldProfileType   ImportField = "profileType"
+	ImportFieldOutreachType  ImportField = "outreachType"
+	ImportFieldStage         ImportField = "stage"
+	ImportFieldDateAdded     ImportField = "dateAdded"
+	ImportFieldFollowupSent  ImportField = "followupSent"
+	ImportFieldFollowupDueAt ImportField = "followupDueAt"
+	ImportFieldTags          ImportField = "tags"
+	ImportFieldNotes         ImportField = "notes"
+	ImportFieldPictureURL    ImportField = "pictureUrl"
+
+	ImportOutcomeCreated ImportOutcome = "created"
+	ImportOutcomeMerged  ImportOutcome = "merged"
+	ImportOutcomeSkipped ImportOutcome = "skipped"
+	ImportOutcomeInvalid ImportOutcome = "invalid"
+	ImportOutcomeFailed  ImportOutcome = "failed"
+
 	ErrorMessage string = "Something went wrong. Try again later."
 
-	FormFieldKeyProfileType     string = "profileType"
-	FormFieldKeyOutreachType    string = "outreachType"
-	FormFieldKeyUrl             string = "url"
-	FormFieldKeyName            string = "name"
-	FormFieldKeyNotes           string = "notes"
-	FormFieldConnectionStatus   string = "connectionStatus"
-	FormFieldKeyLeadTemperature string = "leadTemperature"
-	FormFieldFollowupSent       string = "followupSent"
-	FormFieldPictureUrl string = "pictureUrl"
+	FormFieldKeyProfileType   string = "profileType"
+	FormFieldKeyOutreachType  string = "outreachType"
+	FormFieldKeyUrl           string = "url"
+	FormFieldKeyName          string = "name"
+	FormFieldKeyNotes         string = "notes"
+	FormFieldConnectionStatus string = "connectionStatus"
+	FormFieldFollowupSent     string = "followupSent"
+	FormFieldPictureUrl       string = "pictureUrl"
+	FormFieldOnDuplicate      string = "onDuplicate"
+	FormFieldFollowupDueAt    string = "followupDueAt"
+	FormFieldSequenceID       string = "sequenceId"
+	FormFieldTags             string = "tags"
+	FormFieldCompanyID        string = "companyId"
+	FormFieldDomain           string = "domain"
+	FormFieldLinkedInURL      string = "linkedinUrl"
+	FormFieldCampaignID       string = "campaignId"
+	FormFieldDescription      string = "description"
+	FormFieldNoteID           string = "noteId"
+	FormFieldNoteType         string = "noteType"
+	FormFieldNoteText         string = "text"
+	FormFieldTemplateID       string = "templateId"
+	FormFieldBody             string = "body"
+	FormFieldLeadIDs          string = "ids"
+	FormFieldBulkAction       string = "action"
+	FormFieldLeadTemperature  string = "leadTemperature"
+	FormFieldTag              string = "tag"
+	FormFieldImportFile       string = "file"
+	FormFieldImportCSV        string = "csv"
+	FormFieldImportMapping    string = "mapping"
+	// FormFieldCustomFieldPrefix prefixes the key of a custom field in forms and filter query parameters
+	FormFieldCustomFieldPrefix string = "cf."
+)
+
+// The values of the enumerated types, the JSON API lists them in its spec
+var (
+	OutreachTypes        = []OutreachType{OutreachTypeConnection, OutreachTypeInMail}
+	ProfileTypes         = []ProfileType{ProfileTypePublic, ProfileTypePrivate}
+	LeadTemperatures     = []LeadTemperature{LeadTemperatureCold, LeadTemperatureHot}
+	DuplicateResolutions = []DuplicateResolution{DuplicateResolutionReject, DuplicateResolutionMerge, DuplicateResolutionCreate}
+	NoteTypes            = []NoteType{NoteTypeNone, NoteTypeCall, NoteTypeMessage, NoteTypeMeeting}
+	TagMatches           = []TagMatch{TagMatchAny, TagMatchAll}
+	BulkActions          = []BulkAction{BulkActionSetTemperature, BulkActionSetStatus, BulkActionMarkFollowupSent, BulkActionAddTag, BulkActionDelete}
+	BulkOutcomes         = []BulkOutcome{BulkOutcomeUpdated, BulkOutcomeUnchanged, BulkOutcomeFailed}
+	ImportFields         = []ImportField{ImportFieldName, ImportFieldURL, ImportFieldProfileType, ImportFieldOutreachType, ImportFieldStage, ImportFieldDateAdded, ImportFieldFollowupSent, ImportFieldFollowupDueAt, ImportFieldTags, ImportFieldNotes, ImportFieldPictureURL}
+	ScoreSignals         = []ScoreSignal{ScoreSignalProfileType, ScoreSignalStage, ScoreSignalFollowup, ScoreSignalResponse, ScoreSignalKeyword}
+	// DefaultStages are the stage keys of the default pipelines, custom pipelines may add others
+	DefaultStages = []ConnectionStatus{ConnectionStatusPending, ConnectionStatusAccepted, ConnectionStatusMessaged, ConnectionStatusResponded, ConnectionStatusMeetingBooked, ConnectionStatusClosed}
 )
 
 func ValidateOutReachType(value OutreachType) error {
-	switch value {
-	case OutreachTypeConnection, OutreachTypeInMail:
-		return nil
-	default:
+	if !slices.Contains(OutreachTypes, value) {
 		return fmt.Errorf("invalid outreach type: %s", value)
 	}
+	return nil
 }
 
-func ValidateConnectionStatus(value ConnectionStatus) error {
+func ValidateProfileType(value ProfileType) error {
+	if !slices.Contains(ProfileTypes, value) {
+		return fmt.Errorf("invalid profile type: %s", value)
+	}
+	return nil
+}
+
+func ValidateCustomFieldType(value CustomFieldType) error {
 	switch value {
-	case ConnectionStatusPending, ConnectionStatusResponded, ConnectionStatusAccepted:
+	case CustomFieldTypeText, CustomFieldTypeNumber, CustomFieldTypeDate, CustomFieldTypeSelect:
 		return nil
 	default:
-		return fmt.Errorf("invalid connection type: %s", value)
+		return fmt.Errorf("invalid custom field type: %s", value)
+	}
+}
+
+func ValidateNoteType(value NoteType) error {
+	if !slices.Contains(NoteTypes, value) {
+		return fmt.Errorf("invalid note type: %s", value)
 	}
+	return nil
 }
 
-func ValidateLeadTemperature(value LeadTemperature) error {
+func ValidateAuditAction(value AuditAction) error {
 	switch value {
-	case LeadTemperatureHot, LeadTemperatureCold:
+	case AuditActionCreate, AuditActionUpdate, AuditActionDelete, AuditActionRestore, AuditActionPurge,
+		AuditActionStatsUpdate, AuditActionStatsRebuild:
 		return nil
 	default:
-		return fmt.Errorf("invalid lead temperature type: %s", value)
+		return fmt.Errorf("invalid audit action: %s", value)
 	}
 }
 
-func ValidateProfileType(value ProfileType) error {
+func ValidateAuditEntity(value AuditEntity) error {
 	switch value {
-	case ProfileTypePublic, ProfileTypePrivate:
+	case AuditEntityLead, AuditEntityStats, AuditEntityPipeline, AuditEntitySequence, AuditEntityScoring, AuditEntityCustomField, AuditEntityCompany, AuditEntityCampaign, AuditEntityTemplate:
 		return nil
 	default:
-		return fmt.Errorf("invalid profile type: %s", value)
+		return fmt.Errorf("invalid audit entity: %s", value)
+	}
+}
+
+func ValidateDuplicateResolution(value DuplicateResolution) error {
+	if !slices.Contains(DuplicateResolutions, value) {
+		return fmt.Errorf("invalid duplicate resolution: %s", value)
+	}
+	return nil
+}
+
+func ValidateBulkAction(value BulkAction) error {
+	if !slices.Contains(BulkActions, value) {
+		return fmt.Errorf("invalid bulk action: %s", value)
 	}
+	return nil
 }
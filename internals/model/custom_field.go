@@ -0,0 +1,122 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"leadgentracker/internals/model/constants"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CustomField defines a field the team tracks on every lead in addition to
+// the built-in ones. Its values are stored on the lead under Key.
+type CustomField struct {
+	ID    primitive.ObjectID        `json:"id" bson:"_id,omitempty"`
+	Key   string                    `json:"key" bson:"key"`
+	Label string                    `json:"label" bson:"label"`
+	Type  constants.CustomFieldType `json:"type" bson:"type"`
+	// Options are the values a select field can take
+	Options []string `json:"options,omitempty" bson:"options,omitempty"`
+}
+
+// CustomFieldValue holds the value of a custom field in the member matching
+// its type: Text for text and select fields, Number and Date for the others.
+// Keeping numbers and dates typed lets them be filtered by range.
+type CustomFieldValue struct {
+	Text   string     `json:"text,omitempty" bson:"text,omitempty"`
+	Number *float64   `json:"number,omitempty" bson:"number,omitempty"`
+	Date   *time.Time `json:"date,omitempty" bson:"date,omitempty"`
+}
+
+// Validate checks that the field has a camelCase key, a label, a known type
+// and, for select fields, unique non-empty options
+func (f *CustomField) Validate() error {
+	if !keyPattern.MatchString(f.Key) {
+		return fmt.Errorf("invalid field key %q, keys are camelCase letters and digits", f.Key)
+	}
+	if strings.TrimSpace(f.Label) == "" {
+		return fmt.Errorf("field %s has no label", f.Key)
+	}
+	if err := constants.ValidateCustomFieldType(f.Type); err != nil {
+		return err
+	}
+
+	if f.Type != constants.CustomFieldTypeSelect {
+		if len(f.Options) > 0 {
+			return fmt.Errorf("only select fields have options, %s is a %s field", f.Key, f.Type)
+		}
+		return nil
+	}
+	if len(f.Options) == 0 {
+		return fmt.Errorf("select field %s needs at least one option", f.Key)
+	}
+	for i, option := range f.Options {
+		if strings.TrimSpace(option) == "" {
+			return fmt.Errorf("select field %s has an empty option", f.Key)
+		}
+		if slices.Contains(f.Options[:i], option) {
+			return fmt.Errorf("select field %s has the option %q twice", f.Key, option)
+		}
+	}
+	return nil
+}
+
+// Parse reads a value of the field as entered in a form, nil for an empty value
+func (f *CustomField) Parse(raw string) (*CustomFieldValue, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	switch f.Type {
+	case constants.CustomFieldTypeNumber:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", f.Label)
+		}
+		return &CustomFieldValue{Number: &number}, nil
+	case constants.CustomFieldTypeDate:
+		date, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a date", f.Label)
+		}
+		return &CustomFieldValue{Date: &date}, nil
+	case constants.CustomFieldTypeSelect:
+		if !slices.Contains(f.Options, raw) {
+			return nil, fmt.Errorf("%s has no option %q", f.Label, raw)
+		}
+		return &CustomFieldValue{Text: raw}, nil
+	case constants.CustomFieldTypeText:
+		return &CustomFieldValue{Text: raw}, nil
+	default:
+		return nil, errors.New("unknown field type")
+	}
+}
+
+// Format returns the value the way Parse reads it, empty when the lead has none
+func (f *CustomField) Format(lead *Lead) string {
+	value, exists := lead.CustomFields[f.Key]
+	if !exists {
+		return ""
+	}
+
+	switch f.Type {
+	case constants.CustomFieldTypeNumber:
+		if value.Number == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*value.Number, 'f', -1, 64)
+	case constants.CustomFieldTypeDate:
+		if value.Date == nil {
+			return ""
+		}
+		return value.Date.Format("2006-01-02")
+	default:
+		return value.Text
+	}
+}
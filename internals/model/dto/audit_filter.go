@@ -0,0 +1,89 @@
+package dto
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"leadgentracker/internals/model/constants"
+)
+
+const AuditEntriesPerPage = 20
+
+type AuditFilter struct {
+	Actor          string
+	Action         constants.AuditAction
+	EntityType     constants.AuditEntity
+	EntityID       string
+	Page           int
+	EntriesPerPage int
+}
+
+func (f AuditFilter) HasActiveFilters() bool {
+	return f.Actor != "" ||
+		f.Action != constants.AuditAction("") ||
+		f.EntityType != constants.AuditEntity("") ||
+		f.EntityID != ""
+}
+
+// QueryString encodes the filter as URL query, e.g. to link to another page of the same results
+func (f AuditFilter) QueryString(page int) string {
+	values := url.Values{}
+	if f.Actor != "" {
+		values.Set("actor", f.Actor)
+	}
+	if f.Action != "" {
+		values.Set("action", string(f.Action))
+	}
+	if f.EntityType != "" {
+		values.Set("entityType", string(f.EntityType))
+	}
+	if f.EntityID != "" {
+		values.Set("entityId", f.EntityID)
+	}
+	values.Set("page", strconv.Itoa(page))
+	return values.Encode()
+}
+
+func NewAuditFilter(urlValues url.Values) (*AuditFilter, error) {
+	filter := &AuditFilter{EntriesPerPage: AuditEntriesPerPage, Page: 1}
+	var errs []string
+
+	filter.Actor = strings.TrimSpace(urlValues.Get("actor"))
+	filter.EntityID = strings.TrimSpace(urlValues.Get("entityId"))
+
+	if action := urlValues.Get("action"); action != "" {
+		if err := constants.ValidateAuditAction(constants.AuditAction(action)); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			filter.Action = constants.AuditAction(action)
+		}
+	}
+
+	if entityType := urlValues.Get("entityType"); entityType != "" {
+		if err := constants.ValidateAuditEntity(constants.AuditEntity(entityType)); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			filter.EntityType = constants.AuditEntity(entityType)
+		}
+	}
+
+	if pageStr := urlValues.Get("page"); pageStr != "" {
+		page, err := strconv.Atoi(pageStr)
+		if err != nil {
+			errs = append(errs, "invalid page number")
+		} else if page < 1 {
+			errs = append(errs, "page number must be positive")
+		} else {
+			filter.Page = page
+		}
+	}
+
+	var err error
+	if len(errs) > 0 {
+		err = fmt.Errorf("filter validation errors: %s", strings.Join(errs, "; "))
+	}
+
+	return filter, err
+}
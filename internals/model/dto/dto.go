@@ -1,6 +1,9 @@
 package dto
 
 import (
+	"time"
+
+	"leadgentracker/internals/model"
 	"leadgentracker/internals/model/constants"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,12 +15,211 @@ type NewLeadProperties struct {
 	Url          string
 	Name         string
 	PictureUrl   string
+
+	// OnDuplicate decides what happens when a lead with the same profile URL exists
+	OnDuplicate constants.DuplicateResolution
+	// SequenceID attaches the lead to a sequence, nil attaches it to the default
+	// sequence of its outreach type if there is one
+	SequenceID *primitive.ObjectID
+	// CampaignID assigns the lead to a campaign, nil assigns it to none
+	CampaignID *primitive.ObjectID
+}
+
+// CreateLeadResult tells which lead a create request ended up in
+type CreateLeadResult struct {
+	Lead *model.Lead
+	// Duplicate is the existing lead with the same profile URL, nil when there was none
+	Duplicate  *model.Lead
+	Resolution constants.DuplicateResolution
+}
+
+// LeadProfileProperties are the profile details merged into an existing lead
+type LeadProfileProperties struct {
+	ID          primitive.ObjectID
+	ProfileType constants.ProfileType
+	Name        string
+	PictureUrl  string
+	CampaignID  *primitive.ObjectID
+}
+
+// LeadScoreProperties is a lead's score together with the temperature it decides
+type LeadScoreProperties struct {
+	ID primitive.ObjectID
+	// Version is the version of the lead the score was rated from, see model.Lead
+	Version         int
+	Score           int
+	Breakdown       []model.ScoreSignal
+	LeadTemperature constants.LeadTemperature
+	// TemperatureChange is appended to the status history when the score
+	// changes the temperature, nil when it stays the same
+	TemperatureChange *model.StatusChange
 }
 
 type UpdateLeadProperties struct {
-	ID               primitive.ObjectID
+	ID primitive.ObjectID
+	// Version is the version of the lead the update was made from, see model.Lead
+	Version          int
 	ConnectionStatus constants.ConnectionStatus
-	LeadTemperature  constants.LeadTemperature
 	FollowupSent     bool
-	Notes            string
+	// FollowupDueAt is the day a follow-up is due, nil to schedule none
+	FollowupDueAt *time.Time
+	Tags          []string
+	// CustomFieldInput holds the entered custom field values by field key, they
+	// are parsed into CustomFields, which replace the lead's values
+	CustomFieldInput map[string]string
+	CustomFields     map[string]model.CustomFieldValue
+	// CompanyID links the lead to a company, nil to unlink it
+	CompanyID *primitive.ObjectID
+	// CampaignID assigns the lead to a campaign, nil to unassign it
+	CampaignID *primitive.ObjectID
+
+	// StatusChanges are appended to the status history of the lead
+	StatusChanges []model.StatusChange
+}
+
+// NoteProperties is a note to add to a lead or an edit of one of its notes
+type NoteProperties struct {
+	LeadID primitive.ObjectID
+	// NoteID is the note to edit, it is ignored when adding one
+	NoteID primitive.ObjectID
+	Type   constants.NoteType
+	Text   string
+}
+
+// BulkLeadAction is a change applied to every selected lead at once
+type BulkLeadAction struct {
+	IDs    []primitive.ObjectID
+	Action constants.BulkAction
+	// LeadTemperature is set by BulkActionSetTemperature, empty hands the
+	// temperature back to the score
+	LeadTemperature  constants.LeadTemperature
+	ConnectionStatus constants.ConnectionStatus
+	Tag              string
+}
+
+// LeadBatchUpdate is the change of a single lead in a batch, nil and empty
+// properties keep their current value
+type LeadBatchUpdate struct {
+	ID primitive.ObjectID
+	// ExpectedStatus is the status the change was made for, the lead is left
+	// unchanged once it moved on. Empty changes the lead at any status.
+	ExpectedStatus   constants.ConnectionStatus
+	ConnectionStatus constants.ConnectionStatus
+	// FollowupSent only changes a lead whose follow-up isn't in that state yet
+	FollowupSent        *bool
+	FollowupDueAt       *time.Time
+	TemperatureOverride *constants.LeadTemperature
+	// LeadTemperature sets the temperature of a lead whose temperature is
+	// still ExpectedTemperature, empty keeps the temperature
+	LeadTemperature     constants.LeadTemperature
+	ExpectedTemperature constants.LeadTemperature
+	// AddTag is added to the tags of the lead, which must not carry it yet
+	AddTag string
+
+	// StatusChanges are appended to the status history of the lead
+	StatusChanges []model.StatusChange
+}
+
+// BulkResult reports what a bulk action did to each of the selected leads, in
+// the order they were selected
+type BulkResult struct {
+	Action constants.BulkAction
+	Items  []BulkItemResult
+}
+
+// BulkItemResult is the outcome of a bulk action for a single lead
+type BulkItemResult struct {
+	ID primitive.ObjectID
+	// Name is the name of the lead, empty when it doesn't exist
+	Name    string
+	Outcome constants.BulkOutcome
+	// Reason tells why the lead was left unchanged or failed
+	Reason string
+}
+
+// Count returns how many leads had the outcome
+func (r *BulkResult) Count(outcome constants.BulkOutcome) int {
+	count := 0
+	for _, item := range r.Items {
+		if item.Outcome == outcome {
+			count++
+		}
+	}
+	return count
+}
+
+// LeadImport is a CSV file of leads together with how to read its columns
+type LeadImport struct {
+	Header []string
+	Rows   []ImportRow
+	// Mapping is the lead field each column is read into, by column. A custom
+	// field is mapped to constants.FormFieldCustomFieldPrefix plus its key and
+	// an empty field ignores the column.
+	Mapping []string
+	// ProfileType and OutreachType are used for the rows without one, empty
+	// makes those rows invalid
+	ProfileType  constants.ProfileType
+	OutreachType constants.OutreachType
+	// OnDuplicate decides what happens to rows whose profile URL is tracked
+	// already, rejecting them skips the rows
+	OnDuplicate constants.DuplicateResolution
+	// DryRun only validates the rows and looks up their duplicates
+	DryRun bool
+}
+
+// ImportRow is a row of a CSV file below its header
+type ImportRow struct {
+	// Number is the row as spreadsheets number it, the header is row 1
+	Number int
+	Cells  []string
+}
+
+// ImportResult reports what an import did, or would do on a dry run, with each row of the file
+type ImportResult struct {
+	DryRun bool
+	Rows   []ImportRowResult
+}
+
+// ImportRowResult is the outcome of an import for a single row
+type ImportRowResult struct {
+	Row     int
+	Name    string
+	Outcome constants.ImportOutcome
+	// Errors tell why the row is invalid or failed
+	Errors []string
+	// Duplicate is the tracked lead with the row's profile URL, nil when there is none
+	Duplicate *model.Lead
+	// Ignored lists the columns with a value that merging the row into its
+	// duplicate leaves out, the duplicate keeps its own values for them
+	Ignored []string
+	// Lead is the imported lead, nil on a dry run and for rows that weren't imported
+	Lead *model.Lead
+}
+
+// Count returns how many rows had the outcome
+func (r *ImportResult) Count(outcome constants.ImportOutcome) int {
+	count := 0
+	for _, row := range r.Rows {
+		if row.Outcome == outcome {
+			count++
+		}
+	}
+	return count
+}
+
+// FollowupQueue lists the first of the leads with an overdue follow-up and
+// of those with a follow-up due today, each ordered by due date
+type FollowupQueue struct {
+	Overdue     []model.Lead
+	MoreOverdue bool
+	Due         []model.Lead
+	MoreDue     bool
+}
+
+// CompanyOverview is a company with the leads working there, ordered like the
+// lead list, and their aggregated stats
+type CompanyOverview struct {
+	Company *model.Company
+	Leads   []model.Lead
+	Stats   model.CompanyStats
 }
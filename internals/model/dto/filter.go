@@ -2,37 +2,158 @@ package dto
 
 import (
 	"fmt"
+	"leadgentracker/internals/model"
 	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/search"
+	"math"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 const LeadsPerPage = 6
 
+// MaxLeadsPerPage caps the page size clients of the JSON API can ask for
+const MaxLeadsPerPage = 100
+
 type LeadFilter struct {
-	SearchQuery     string
-	OutreachType    constants.OutreachType
-	LeadTemperature constants.LeadTemperature
-	DateAdded       time.Time
-	Page            int
-	LeadsPerPage    int
+	SearchQuery  string
+	OutreachType constants.OutreachType
+	// ConnectionStatus filters by pipeline stage, see NewLeadFilter for its validation
+	ConnectionStatus constants.ConnectionStatus
+	LeadTemperature  constants.LeadTemperature
+	DateAdded        time.Time
+	// Followup lists only leads with a follow-up in this state, ordered by due date
+	Followup constants.FollowupState
+	// Tags lists leads carrying the tags, TagMatch decides if any or all of them
+	Tags     []string
+	TagMatch constants.TagMatch
+	// CustomFields lists only leads whose custom field values match every filter
+	CustomFields []CustomFieldFilter
+	// CompanyID lists only the leads linked to the company
+	CompanyID *primitive.ObjectID
+	// CampaignID lists only the leads assigned to the campaign
+	CampaignID   *primitive.ObjectID
+	Page         int
+	LeadsPerPage int
+
+	// Trashed lists the deleted leads in the trash instead of the active ones
+	Trashed bool
 }
 
 func (f LeadFilter) HasActiveFilters() bool {
 	return f.SearchQuery != "" ||
 		f.OutreachType != constants.OutreachType("") ||
+		f.ConnectionStatus != constants.ConnectionStatus("") ||
 		f.LeadTemperature != constants.LeadTemperature("") ||
-		!f.DateAdded.IsZero()
+		!f.DateAdded.IsZero() ||
+		f.Followup != constants.FollowupStateNone ||
+		len(f.Tags) > 0 ||
+		len(f.CustomFields) > 0 ||
+		f.CompanyID != nil ||
+		f.CampaignID != nil
+}
+
+// CustomFieldFilter matches leads by a custom field. Text fields match when
+// they contain Text, select fields when they equal it. Number fields match
+// within Min and Max, date fields from the day From until the day To, each
+// bound being optional.
+type CustomFieldFilter struct {
+	Field model.CustomField
+	Text  string
+	Min   *float64
+	Max   *float64
+	From  *time.Time
+	To    *time.Time
+}
+
+// QueryValues returns the query parameters NewLeadFilter reads the filter
+// from, without the page
+func (f LeadFilter) QueryValues() url.Values {
+	values := url.Values{}
+	setIfNotEmpty := func(key string, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+
+	setIfNotEmpty("search", f.SearchQuery)
+	setIfNotEmpty("outreachType", string(f.OutreachType))
+	setIfNotEmpty("connectionStatus", string(f.ConnectionStatus))
+	setIfNotEmpty("leadTemperature", string(f.LeadTemperature))
+	if !f.DateAdded.IsZero() {
+		values.Set("dateAdded", f.DateAdded.Format("2006-01-02"))
+	}
+	setIfNotEmpty("followup", string(f.Followup))
+	for _, tag := range f.Tags {
+		values.Add("tag", tag)
+	}
+	if len(f.Tags) > 0 {
+		setIfNotEmpty("tagMatch", string(f.TagMatch))
+	}
+	if f.CompanyID != nil {
+		values.Set("company", f.CompanyID.Hex())
+	}
+	if f.CampaignID != nil {
+		values.Set("campaign", f.CampaignID.Hex())
+	}
+	for _, fieldFilter := range f.CustomFields {
+		param := constants.FormFieldCustomFieldPrefix + fieldFilter.Field.Key
+		setIfNotEmpty(param, fieldFilter.Text)
+		if fieldFilter.Min != nil {
+			values.Set(param+".min", strconv.FormatFloat(*fieldFilter.Min, 'f', -1, 64))
+		}
+		if fieldFilter.Max != nil {
+			values.Set(param+".max", strconv.FormatFloat(*fieldFilter.Max, 'f', -1, 64))
+		}
+		if fieldFilter.From != nil {
+			values.Set(param+".from", fieldFilter.From.Format("2006-01-02"))
+		}
+		if fieldFilter.To != nil {
+			values.Set(param+".to", fieldFilter.To.Format("2006-01-02"))
+		}
+	}
+	return values
+}
+
+// CustomFieldValue returns the filtered value of the custom field as it is
+// entered, suffix selects the bound of a range such as ".min"
+func (f LeadFilter) CustomFieldValue(key string, suffix string) string {
+	return f.QueryValues().Get(constants.FormFieldCustomFieldPrefix + key + suffix)
+}
+
+// SearchTerms returns the words of the search query, see search.Terms
+func (f LeadFilter) SearchTerms() []string {
+	return search.Terms(f.SearchQuery)
 }
 
 func NewPagedLeadFilter(page int) *LeadFilter {
 	return &LeadFilter{Page: page, LeadsPerPage: LeadsPerPage}
 }
 
-func NewLeadFilter(urlValues url.Values) (*LeadFilter, error) {
-	filter := &LeadFilter{LeadsPerPage: LeadsPerPage}
+func NewPagedFollowupFilter(state constants.FollowupState, perPage int) *LeadFilter {
+	return &LeadFilter{Page: 1, LeadsPerPage: perPage, Followup: state}
+}
+
+// NewCompanyLeadFilter lists every lead linked to the company on a single page
+func NewCompanyLeadFilter(companyID primitive.ObjectID) *LeadFilter {
+	return &LeadFilter{Page: 1, LeadsPerPage: math.MaxInt32, CompanyID: &companyID}
+}
+
+func NewPagedTrashFilter(page int) *LeadFilter {
+	return &LeadFilter{Page: page, LeadsPerPage: LeadsPerPage, Trashed: true}
+}
+
+// NewLeadFilter reads the filter from the query parameters. A stage is only
+// applied if it is part of the pipeline of the filtered outreach type, or of
+// any pipeline when no outreach type is filtered. Custom fields are filtered
+// by the parameters prefixed with FormFieldCustomFieldPrefix.
+func NewLeadFilter(urlValues url.Values, pipelines model.Pipelines, fields []model.CustomField) (*LeadFilter, error) {
+	filter := &LeadFilter{LeadsPerPage: LeadsPerPage, TagMatch: constants.TagMatchAny}
 	var errs []string
 
 	// Extract and validate search query
@@ -48,6 +169,18 @@ func NewLeadFilter(urlValues url.Values) (*LeadFilter, error) {
 		}
 	}
 
+	// Extract and validate pipeline stage
+	if status := constants.ConnectionStatus(urlValues.Get("connectionStatus")); status != "" {
+		switch {
+		case pipelines.HasStage(filter.OutreachType, status):
+			filter.ConnectionStatus = status
+		case !pipelines.HasStage("", status):
+			errs = append(errs, fmt.Sprintf("invalid pipeline stage: %s", status))
+		}
+		// A stage of another outreach type's pipeline is dropped, it is left
+		// over from before the outreach type filter changed
+	}
+
 	// Extract and validate lead temperature
 	if leadTemp := urlValues.Get("leadTemperature"); leadTemp != "" {
 		switch constants.LeadTemperature(leadTemp) {
@@ -73,6 +206,57 @@ func NewLeadFilter(urlValues url.Values) (*LeadFilter, error) {
 		}
 	}
 
+	// Extract and validate follow-up state
+	if followup := constants.FollowupState(urlValues.Get("followup")); followup != "" {
+		switch followup {
+		case constants.FollowupStateDue, constants.FollowupStateOverdue:
+			filter.Followup = followup
+		default:
+			errs = append(errs, fmt.Sprintf("invalid follow-up state: %s", followup))
+		}
+	}
+
+	// Extract and validate tags
+	filter.Tags = model.NormalizeTags(urlValues["tag"])
+	if tagMatch := constants.TagMatch(urlValues.Get("tagMatch")); tagMatch != "" {
+		switch tagMatch {
+		case constants.TagMatchAny, constants.TagMatchAll:
+			filter.TagMatch = tagMatch
+		default:
+			errs = append(errs, fmt.Sprintf("invalid tag match: %s", tagMatch))
+		}
+	}
+
+	// Extract and validate company
+	if companyStr := urlValues.Get("company"); companyStr != "" {
+		companyID, err := primitive.ObjectIDFromHex(companyStr)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("invalid company ID: %s", companyStr))
+		} else {
+			filter.CompanyID = &companyID
+		}
+	}
+
+	// Extract and validate campaign
+	if campaignStr := urlValues.Get("campaign"); campaignStr != "" {
+		campaignID, err := primitive.ObjectIDFromHex(campaignStr)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("invalid campaign ID: %s", campaignStr))
+		} else {
+			filter.CampaignID = &campaignID
+		}
+	}
+
+	// Extract and validate custom field filters
+	for _, field := range fields {
+		fieldFilter, err := newCustomFieldFilter(urlValues, field)
+		if err != nil {
+			errs = append(errs, err.Error())
+		} else if fieldFilter != nil {
+			filter.CustomFields = append(filter.CustomFields, *fieldFilter)
+		}
+	}
+
 	// Handle page number
 	if pageStr := urlValues.Get("page"); pageStr != "" {
 		page, err := strconv.Atoi(pageStr)
@@ -95,3 +279,46 @@ func NewLeadFilter(urlValues url.Values) (*LeadFilter, error) {
 
 	return filter, err
 }
+
+// newCustomFieldFilter reads the filter of the custom field, nil if it is not filtered
+func newCustomFieldFilter(urlValues url.Values, field model.CustomField) (*CustomFieldFilter, error) {
+	param := constants.FormFieldCustomFieldPrefix + field.Key
+	fieldFilter := &CustomFieldFilter{Field: field}
+
+	switch field.Type {
+	case constants.CustomFieldTypeNumber:
+		for suffix, bound := range map[string]**float64{".min": &fieldFilter.Min, ".max": &fieldFilter.Max} {
+			raw := urlValues.Get(param + suffix)
+			if raw == "" {
+				continue
+			}
+			number, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s bound of %s: %s", suffix[1:], field.Key, raw)
+			}
+			*bound = &number
+		}
+	case constants.CustomFieldTypeDate:
+		for suffix, bound := range map[string]**time.Time{".from": &fieldFilter.From, ".to": &fieldFilter.To} {
+			raw := urlValues.Get(param + suffix)
+			if raw == "" {
+				continue
+			}
+			date, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s date of %s: %s", suffix[1:], field.Key, raw)
+			}
+			*bound = &date
+		}
+	default:
+		fieldFilter.Text = strings.TrimSpace(urlValues.Get(param))
+		if fieldFilter.Text != "" && field.Type == constants.CustomFieldTypeSelect && !slices.Contains(field.Options, fieldFilter.Text) {
+			return nil, fmt.Errorf("invalid option of %s: %s", field.Key, fieldFilter.Text)
+		}
+	}
+
+	if fieldFilter.Text == "" && fieldFilter.Min == nil && fieldFilter.Max == nil && fieldFilter.From == nil && fieldFilter.To == nil {
+		return nil, nil
+	}
+	return fieldFilter, nil
+}
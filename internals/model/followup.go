@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"leadgentracker/internals/model/constants"
+)
+
+// StartOfDay returns midnight of the day t falls on, in t's location
+func StartOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// FollowupState tells whether the lead's follow-up is overdue, due on the day
+// of now or scheduled for later. Leads without a scheduled follow-up or with
+// the follow-up already sent have no state.
+func (l *Lead) FollowupState(now time.Time) constants.FollowupState {
+	if l.FollowupDueAt == nil || l.FollowupSent {
+		return constants.FollowupStateNone
+	}
+
+	today := StartOfDay(now)
+	switch {
+	case l.FollowupDueAt.Before(today):
+		return constants.FollowupStateOverdue
+	case l.FollowupDueAt.Before(today.AddDate(0, 0, 1)):
+		return constants.FollowupStateDue
+	default:
+		return constants.FollowupStateScheduled
+	}
+}
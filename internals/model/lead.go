@@ -9,15 +9,64 @@ import (
 )
 
 type Lead struct {
-	ID               primitive.ObjectID         `bson:"_id,ommitempty"`
-	ConnectionStatus constants.ConnectionStatus `json:"connectionStatus"`
-	LeadTemperature  constants.LeadTemperature  `json:"leadTemperature"`
-	ProfileType      constants.ProfileType      `json:"profileType"`
-	OutreachType     constants.OutreachType     `json:"outreachType"`
-	Date             time.Time                  `json:"date"`
-	URL              string                     `json:"url"`
-	Name             string                     `json:"name"`
-	FollowupSent     bool                       `json:"followupSent"`
-	Notes            string                     `json:"notes"`
-	PictureUrl       string                     `json:"pictureUrl"`
+	ID               primitive.ObjectID         `json:"id" bson:"_id,omitempty"`
+	ConnectionStatus constants.ConnectionStatus `json:"connectionStatus" bson:"connectionStatus"`
+	LeadTemperature  constants.LeadTemperature  `json:"leadTemperature" bson:"leadTemperature"`
+	ProfileType      constants.ProfileType      `json:"profileType" bson:"profileType"`
+	OutreachType     constants.OutreachType     `json:"outreachType" bson:"outreachType"`
+	Date             time.Time                  `json:"date" bson:"date"`
+	URL              string                     `json:"url" bson:"url"`
+	Name             string                     `json:"name" bson:"name"`
+	FollowupSent     bool                       `json:"followupSent" bson:"followupSent"`
+	// FollowupDueAt is midnight of the day the follow-up is due, nil when none is scheduled
+	FollowupDueAt *time.Time `json:"followupDueAt,omitempty" bson:"followupDueAt,omitempty"`
+	// Notes log the activity with the lead, see Note
+	Notes         Notes          `json:"notes,omitempty" bson:"notes,omitempty"`
+	PictureUrl    string         `json:"pictureUrl" bson:"pictureUrl"`
+	StatusHistory []StatusChange `json:"statusHistory" bson:"statusHistory,omitempty"`
+	DeletedAt     *time.Time     `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	// Tags segment leads freely, see NormalizeTags
+	Tags []string `json:"tags,omitempty" bson:"tags,omitempty"`
+	// CustomFields holds the values of the custom fields by field key, see CustomField
+	CustomFields map[string]CustomFieldValue `json:"customFields,omitempty" bson:"customFields,omitempty"`
+	// Score is rated from the lead's signals and decides its LeadTemperature, see
+	// ScoringConfig. ScoreBreakdown lists the signals that scored.
+	Score          int           `json:"score" bson:"score"`
+	ScoreBreakdown []ScoreSignal `json:"scoreBreakdown,omitempty" bson:"scoreBreakdown,omitempty"`
+	// TemperatureOverride is the temperature set by hand, which the score no
+	// longer changes. It is empty while the score decides the temperature.
+	TemperatureOverride constants.LeadTemperature `json:"temperatureOverride,omitempty" bson:"temperatureOverride,omitempty"`
+	// Sequence is the outreach sequence the lead was attached to on creation, nil when none
+	Sequence *SequenceProgress `json:"sequence,omitempty" bson:"sequence,omitempty"`
+	// CompanyID links the lead to the company it works at, nil when none
+	CompanyID *primitive.ObjectID `json:"companyId,omitempty" bson:"companyId,omitempty"`
+	// CampaignID assigns the lead to the outreach campaign it was reached in, nil when none
+	CampaignID *primitive.ObjectID `json:"campaignId,omitempty" bson:"campaignId,omitempty"`
+	// MessageTemplate is the template the last message to the lead was sent with, nil when none
+	MessageTemplate *MessageTemplateUsage `json:"messageTemplate,omitempty" bson:"messageTemplate,omitempty"`
+	// MessageTemplateHistory records every message sent to the lead with a template, the oldest first
+	MessageTemplateHistory []MessageTemplateUsage `json:"messageTemplateHistory,omitempty" bson:"messageTemplateHistory,omitempty"`
+
+	// NormalizedURL is the unique profile key, see NormalizeProfileURL. It is
+	// left empty on leads created despite being a duplicate, which instead
+	// reference the lead holding the key through DuplicateOf.
+	NormalizedURL string              `json:"normalizedUrl,omitempty" bson:"normalizedUrl,omitempty"`
+	DuplicateOf   *primitive.ObjectID `json:"duplicateOf,omitempty" bson:"duplicateOf,omitempty"`
+
+	// Version counts the writes of the lead other than of its score, so a
+	// score is only stored for the version of the lead it was rated from
+	Version int `json:"version,omitempty" bson:"version,omitempty"`
+}
+
+// IsTrashed tells whether the lead was deleted and waits in the trash to be restored or purged
+func (l *Lead) IsTrashed() bool {
+	return l.DeletedAt != nil
+}
+
+// StatusChange records a single transition of one of the tracked lead fields
+type StatusChange struct {
+	Field     constants.LeadField `json:"field" bson:"field"`
+	OldValue  string              `json:"oldValue" bson:"oldValue"`
+	NewValue  string              `json:"newValue" bson:"newValue"`
+	ChangedAt time.Time           `json:"changedAt" bson:"changedAt"`
 }
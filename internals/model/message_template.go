@@ -0,0 +1,151 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"leadgentracker/internals/model/constants"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MessageTemplate is a reusable connection note or InMail. Its body is a Go
+// text/template filled with the MessageData of a lead, e.g. "Hi {{.FirstName}}".
+type MessageTemplate struct {
+	ID   primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name string             `json:"name" bson:"name"`
+	// OutreachType restricts the template to leads of one outreach type, empty allows any
+	OutreachType constants.OutreachType `json:"outreachType,omitempty" bson:"outreachType,omitempty"`
+	Body         string                 `json:"body" bson:"body"`
+}
+
+// MessageTemplates are the templates of the library, ordered by name
+type MessageTemplates []MessageTemplate
+
+// Find returns the template with the ID, nil when the ID is unknown
+func (t MessageTemplates) Find(id primitive.ObjectID) *MessageTemplate {
+	for i := range t {
+		if t[i].ID == id {
+			return &t[i]
+		}
+	}
+	return nil
+}
+
+// For returns the templates that can be used for leads of the outreach type
+func (t MessageTemplates) For(outreachType constants.OutreachType) MessageTemplates {
+	var templates MessageTemplates
+	for _, messageTemplate := range t {
+		if messageTemplate.OutreachType == "" || messageTemplate.OutreachType == outreachType {
+			templates = append(templates, messageTemplate)
+		}
+	}
+	return templates
+}
+
+// MessageTemplateUsage records a message sent to a lead with a template. It
+// keeps the template's name, so it can be reported on after the template is
+// renamed or deleted.
+type MessageTemplateUsage struct {
+	TemplateID primitive.ObjectID `json:"templateId" bson:"templateId"`
+	Name       string             `json:"name" bson:"name"`
+	UsedAt     time.Time          `json:"usedAt" bson:"usedAt"`
+}
+
+// MessageData holds the values a template can fill in for a lead
+type MessageData struct {
+	Name      string
+	FirstName string
+	LastName  string
+	// Company is the name of the lead's company, empty when it has none
+	Company string
+	// Fields holds the custom field values by field key, as they are entered
+	Fields map[string]string
+}
+
+// NewMessageData collects the values of the lead, company is nil when the lead has none
+func NewMessageData(lead *Lead, company *Company, fields []CustomField) MessageData {
+	firstName, lastName := SplitName(lead.Name)
+	data := MessageData{
+		Name:      lead.Name,
+		FirstName: firstName,
+		LastName:  lastName,
+		Fields:    make(map[string]string, len(fields)),
+	}
+	if company != nil {
+		data.Company = company.Name
+	}
+	for _, field := range fields {
+		data.Fields[field.Key] = field.Format(lead)
+	}
+	return data
+}
+
+// namePrefixes are titles dropped from the front of a name before splitting it
+var namePrefixes = map[string]bool{"dr": true, "mr": true, "mrs": true, "ms": true, "prof": true}
+
+// SplitName derives the first and last name from a full profile name. Titles
+// in front and credentials after a comma, as in "Dr. Jane Doe, PhD", are
+// dropped. A single word is the first name.
+func SplitName(name string) (string, string) {
+	name, _, _ = strings.Cut(name, ",")
+	words := strings.Fields(name)
+	for len(words) > 1 && namePrefixes[strings.ToLower(strings.TrimSuffix(words[0], "."))] {
+		words = words[1:]
+	}
+
+	switch len(words) {
+	case 0:
+		return "", ""
+	case 1:
+		return words[0], ""
+	default:
+		return words[0], words[len(words)-1]
+	}
+}
+
+// Render fills the template in with the data. Unknown placeholders fail, so
+// no message goes out with a gap in it.
+func (t *MessageTemplate) Render(data MessageData) (string, error) {
+	parsed, err := template.New(t.Name).Option("missingkey=error").Parse(t.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %w", t.Name, err)
+	}
+
+	var message strings.Builder
+	if err := parsed.Execute(&message, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", t.Name, err)
+	}
+	return strings.TrimSpace(message.String()), nil
+}
+
+// Normalize trims the template's name and body
+func (t *MessageTemplate) Normalize() {
+	t.Name = strings.Join(strings.Fields(t.Name), " ")
+	t.Body = strings.TrimSpace(t.Body)
+}
+
+// Validate checks the template has a name and a body that renders for a lead
+// with every custom field in fields
+func (t *MessageTemplate) Validate(fields []CustomField) error {
+	if t.Name == "" {
+		return errors.New("template has no name")
+	}
+	if t.OutreachType != "" {
+		if err := constants.ValidateOutReachType(t.OutreachType); err != nil {
+			return err
+		}
+	}
+	if t.Body == "" {
+		return errors.New("template has no body")
+	}
+
+	sample := NewMessageData(&Lead{Name: "Jane Doe"}, &Company{Name: "Example"}, fields)
+	if _, err := t.Render(sample); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"leadgentracker/internals/model/constants"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Note is an entry of a lead's activity log, e.g. what was said on a call
+type Note struct {
+	ID     primitive.ObjectID `json:"id" bson:"id"`
+	Type   constants.NoteType `json:"type,omitempty" bson:"type,omitempty"`
+	Text   string             `json:"text" bson:"text"`
+	Author string             `json:"author,omitempty" bson:"author,omitempty"`
+	// CreatedAt is when the note was added, notes are kept in that order
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	// UpdatedAt is when the note was last edited, nil when it never was
+	UpdatedAt *time.Time `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
+}
+
+// Notes is the activity log of a lead, the oldest note first
+type Notes []Note
+
+// NotesFromText turns the single notes text leads used to have into a log
+// with one note added at the given time, nil when the text is blank
+func NotesFromText(text string, at time.Time) Notes {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	return Notes{{ID: primitive.NewObjectID(), Text: text, CreatedAt: at}}
+}
+
+// UnmarshalJSON also reads the single notes text of leads stored before notes
+// were logged. The note it becomes has no time, see NotesFromText.
+func (n *Notes) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*n = NotesFromText(text, time.Time{})
+		return nil
+	}
+	var notes []Note
+	if err := json.Unmarshal(data, &notes); err != nil {
+		return err
+	}
+	*n = notes
+	return nil
+}
+
+// Find returns the note with the ID, nil when the lead has none
+func (n Notes) Find(id primitive.ObjectID) *Note {
+	for i := range n {
+		if n[i].ID == id {
+			return &n[i]
+		}
+	}
+	return nil
+}
+
+// NewestFirst returns the notes in the order they are shown, the latest one first
+func (n Notes) NewestFirst() Notes {
+	notes := slices.Clone(n)
+	slices.Reverse(notes)
+	return notes
+}
+
+// Text joins the text of all notes, for searching and scoring them as one
+func (n Notes) Text() string {
+	texts := make([]string, len(n))
+	for i, note := range n {
+		texts[i] = note.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Normalize trims the note's text
+func (n *Note) Normalize() {
+	n.Text = strings.TrimSpace(n.Text)
+}
+
+func (n *Note) Validate() error {
+	if n.Text == "" {
+		return errors.New("note has no text")
+	}
+	return constants.ValidateNoteType(n.Type)
+}
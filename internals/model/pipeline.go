@@ -0,0 +1,199 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"leadgentracker/internals/model/constants"
+)
+
+// Pipeline is the ordered list of stages a lead of an outreach type moves
+// through. The stage keys are stored as the lead's ConnectionStatus.
+type Pipeline struct {
+	OutreachType constants.OutreachType `json:"outreachType" bson:"_id"`
+	Stages       []PipelineStage        `json:"stages" bson:"stages"`
+}
+
+type PipelineStage struct {
+	Key   constants.ConnectionStatus `json:"key" bson:"key"`
+	Label string                     `json:"label" bson:"label"`
+	// Transitions lists the stages a lead may move to from this one. Without
+	// transitions a lead may move to any later stage or one stage back.
+	Transitions []constants.ConnectionStatus `json:"transitions,omitempty" bson:"transitions,omitempty"`
+}
+
+// Pipelines holds the pipeline of every outreach type
+type Pipelines map[constants.OutreachType]*Pipeline
+
+// keyPattern is the camelCase format of stage and custom field keys
+var keyPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*$`)
+
+// DefaultPipelines are stored when no pipeline was configured for an outreach
+// type yet. They keep the stage keys leads were created with before pipelines
+// were configurable.
+func DefaultPipelines() []Pipeline {
+	return []Pipeline{
+		{
+			OutreachType: constants.OutreachTypeConnection,
+			Stages: []PipelineStage{
+				{Key: constants.ConnectionStatusPending, Label: "Invited"},
+				{Key: constants.ConnectionStatusAccepted, Label: "Accepted"},
+				{Key: constants.ConnectionStatusMessaged, Label: "Messaged"},
+				{Key: constants.ConnectionStatusResponded, Label: "Replied"},
+				{Key: constants.ConnectionStatusMeetingBooked, Label: "Meeting booked"},
+				{Key: constants.ConnectionStatusClosed, Label: "Closed"},
+			},
+		},
+		{
+			OutreachType: constants.OutreachTypeInMail,
+			Stages: []PipelineStage{
+				{Key: constants.ConnectionStatusPending, Label: "Sent"},
+				{Key: constants.ConnectionStatusResponded, Label: "Replied"},
+				{Key: constants.ConnectionStatusMeetingBooked, Label: "Meeting booked"},
+				{Key: constants.ConnectionStatusClosed, Label: "Closed"},
+			},
+		},
+	}
+}
+
+// Validate checks that the pipeline has at least one stage and that every
+// stage has a unique camelCase key and a label
+func (p *Pipeline) Validate() error {
+	if err := constants.ValidateOutReachType(p.OutreachType); err != nil {
+		return err
+	}
+	if len(p.Stages) == 0 {
+		return errors.New("a pipeline needs at least one stage")
+	}
+
+	seen := make(map[constants.ConnectionStatus]bool, len(p.Stages))
+	for _, stage := range p.Stages {
+		if !keyPattern.MatchString(string(stage.Key)) {
+			return fmt.Errorf("invalid stage key %q, keys are camelCase letters and digits", stage.Key)
+		}
+		if strings.TrimSpace(stage.Label) == "" {
+			return fmt.Errorf("stage %s has no label", stage.Key)
+		}
+		if seen[stage.Key] {
+			return fmt.Errorf("duplicate stage key: %s", stage.Key)
+		}
+		seen[stage.Key] = true
+	}
+
+	for _, stage := range p.Stages {
+		for _, target := range stage.Transitions {
+			if !seen[target] {
+				return fmt.Errorf("stage %s has a transition to the unknown stage %s", stage.Key, target)
+			}
+		}
+	}
+	return nil
+}
+
+// InitialStage is the stage new leads start in
+func (p *Pipeline) InitialStage() constants.ConnectionStatus {
+	if len(p.Stages) == 0 {
+		return constants.ConnectionStatusPending
+	}
+	return p.Stages[0].Key
+}
+
+// StageIndex returns the position of the stage in the pipeline, -1 if it is not part of it
+func (p *Pipeline) StageIndex(key constants.ConnectionStatus) int {
+	for i, stage := range p.Stages {
+		if stage.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *Pipeline) HasStage(key constants.ConnectionStatus) bool {
+	return p.StageIndex(key) >= 0
+}
+
+// CanMove tells whether a lead may move from one stage to another. Staying in
+// a stage is always allowed. A lead in a stage that is not part of the
+// pipeline may move to any of its stages, so it can be brought back in.
+func (p *Pipeline) CanMove(from constants.ConnectionStatus, to constants.ConnectionStatus) bool {
+	toIndex := p.StageIndex(to)
+	if toIndex < 0 {
+		return false
+	}
+
+	fromIndex := p.StageIndex(from)
+	if fromIndex < 0 || fromIndex == toIndex {
+		return true
+	}
+	if transitions := p.Stages[fromIndex].Transitions; transitions != nil {
+		return slices.Contains(transitions, to)
+	}
+	return toIndex > fromIndex || toIndex == fromIndex-1
+}
+
+// NextStages lists the stages a lead may move to from the given one, including that stage itself
+func (p *Pipeline) NextStages(from constants.ConnectionStatus) []PipelineStage {
+	var stages []PipelineStage
+	for _, stage := range p.Stages {
+		if p.CanMove(from, stage.Key) {
+			stages = append(stages, stage)
+		}
+	}
+	return stages
+}
+
+// Label returns the label of the stage, or its key for a stage that is not part of the pipeline
+func (p *Pipeline) Label(key constants.ConnectionStatus) string {
+	if i := p.StageIndex(key); i >= 0 {
+		return p.Stages[i].Label
+	}
+	return string(key)
+}
+
+// For returns the pipeline of the outreach type, an empty one if none is configured
+func (p Pipelines) For(outreachType constants.OutreachType) *Pipeline {
+	if pipeline, exists := p[outreachType]; exists {
+		return pipeline
+	}
+	return &Pipeline{OutreachType: outreachType}
+}
+
+// Stages lists the stages of the outreach type's pipeline. Without an
+// outreach type it lists the stages of all pipelines, each key once.
+func (p Pipelines) Stages(outreachType constants.OutreachType) []PipelineStage {
+	if outreachType != "" {
+		return p.For(outreachType).Stages
+	}
+
+	outreachTypes := make([]string, 0, len(p))
+	for outreachType := range p {
+		outreachTypes = append(outreachTypes, string(outreachType))
+	}
+	sort.Strings(outreachTypes)
+
+	var stages []PipelineStage
+	seen := make(map[constants.ConnectionStatus]bool)
+	for _, outreachType := range outreachTypes {
+		for _, stage := range p[constants.OutreachType(outreachType)].Stages {
+			if !seen[stage.Key] {
+				seen[stage.Key] = true
+				stages = append(stages, stage)
+			}
+		}
+	}
+	return stages
+}
+
+// HasStage tells whether the stage is part of the outreach type's pipeline, or of any pipeline without an outreach type
+func (p Pipelines) HasStage(outreachType constants.OutreachType, key constants.ConnectionStatus) bool {
+	for _, stage := range p.Stages(outreachType) {
+		if stage.Key == key {
+			return true
+		}
+	}
+	return false
+}
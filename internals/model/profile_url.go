@@ -0,0 +1,42 @@
+package model
+
+import (
+	"net/url"
+	"strings"
+)
+
+// NormalizeProfileURL reduces a profile URL to the key that identifies the
+// profile, so differently written links to the same profile compare equal:
+// "https://de.linkedin.com/in/Jane-Doe/?trk=x" becomes "linkedin.com/in/jane-doe".
+// It returns an empty string for an empty URL.
+func NormalizeProfileURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return ""
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return strings.ToLower(strings.TrimRight(rawURL, "/"))
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	// Country and mobile subdomains like de.linkedin.com serve the same profiles
+	if strings.HasSuffix(host, ".linkedin.com") {
+		host = "linkedin.com"
+	}
+
+	path := strings.ToLower(strings.TrimRight(parsed.Path, "/"))
+	if host == "linkedin.com" {
+		// Sub pages like /in/jane-doe/details/experience belong to the profile /in/jane-doe
+		segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+		if len(segments) > 2 && segments[0] == "in" {
+			path = "/in/" + segments[1]
+		}
+	}
+
+	return host + path
+}
@@ -0,0 +1,186 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"leadgentracker/internals/model/constants"
+)
+
+// ScoringConfig weighs the signals a lead's score is made of. A lead whose
+// score reaches HotThreshold is hot, any other lead is cold.
+type ScoringConfig struct {
+	ProfileTypes map[constants.ProfileType]int `json:"profileTypes" bson:"profileTypes"`
+	// Stages is keyed by stage key, which is shared by the pipelines of all outreach types
+	Stages    map[constants.ConnectionStatus]int `json:"stages" bson:"stages"`
+	Followups map[constants.FollowupState]int    `json:"followups" bson:"followups"`
+	Response  ResponseWeight                     `json:"response" bson:"response"`
+	Keywords  []KeywordWeight                    `json:"keywords" bson:"keywords"`
+
+	HotThreshold int `json:"hotThreshold" bson:"hotThreshold"`
+}
+
+// ResponseWeight scores a lead by how recently it responded. A response of
+// today is worth Points, which decrease linearly to none after DecayDays.
+type ResponseWeight struct {
+	Points    int `json:"points" bson:"points"`
+	DecayDays int `json:"decayDays" bson:"decayDays"`
+}
+
+// KeywordWeight adds Points to leads whose notes contain Keyword, ignoring
+// case. Text matched by a longer keyword doesn't match the keywords within it.
+type KeywordWeight struct {
+	Keyword string `json:"keyword" bson:"keyword"`
+	Points  int    `json:"points" bson:"points"`
+}
+
+// ScoreSignal is one part of a lead's score, Detail names what matched
+type ScoreSignal struct {
+	Signal constants.ScoreSignal `json:"signal" bson:"signal"`
+	Detail string                `json:"detail" bson:"detail"`
+	Points int                   `json:"points" bson:"points"`
+}
+
+// DefaultScoringConfig is used until a scoring config is saved
+func DefaultScoringConfig() ScoringConfig {
+	return ScoringConfig{
+		ProfileTypes: map[constants.ProfileType]int{
+			constants.ProfileTypePublic: 10,
+		},
+		Stages: map[constants.ConnectionStatus]int{
+			constants.ConnectionStatusAccepted:      10,
+			constants.ConnectionStatusMessaged:      15,
+			constants.ConnectionStatusResponded:     30,
+			constants.ConnectionStatusMeetingBooked: 45,
+		},
+		Followups: map[constants.FollowupState]int{
+			constants.FollowupStateDue:     5,
+			constants.FollowupStateOverdue: -10,
+		},
+		Response: ResponseWeight{Points: 30, DecayDays: 30},
+		Keywords: []KeywordWeight{
+			{Keyword: "interested", Points: 15},
+			{Keyword: "budget", Points: 10},
+			{Keyword: "not interested", Points: -40},
+		},
+		HotThreshold: 50,
+	}
+}
+
+// Validate checks that every weighted value is one the lead fields can hold
+func (c *ScoringConfig) Validate() error {
+	for profileType := range c.ProfileTypes {
+		if err := constants.ValidateProfileType(profileType); err != nil {
+			return err
+		}
+	}
+	for stage := range c.Stages {
+		if !keyPattern.MatchString(string(stage)) {
+			return fmt.Errorf("invalid stage key: %q", stage)
+		}
+	}
+	for state := range c.Followups {
+		switch state {
+		case constants.FollowupStateScheduled, constants.FollowupStateDue, constants.FollowupStateOverdue:
+		default:
+			return fmt.Errorf("invalid follow-up state: %q", state)
+		}
+	}
+	if c.Response.Points != 0 && c.Response.DecayDays <= 0 {
+		return errors.New("the response weight needs a positive decay")
+	}
+	for _, keyword := range c.Keywords {
+		if strings.TrimSpace(keyword.Keyword) == "" {
+			return errors.New("a keyword weight needs a keyword")
+		}
+	}
+	return nil
+}
+
+// Score returns the lead's score at the given time and the signals it is made of
+func (c *ScoringConfig) Score(lead *Lead, now time.Time) (int, []ScoreSignal) {
+	var signals []ScoreSignal
+	addSignal := func(signal constants.ScoreSignal, detail string, points int) {
+		if points != 0 {
+			signals = append(signals, ScoreSignal{Signal: signal, Detail: detail, Points: points})
+		}
+	}
+
+	addSignal(constants.ScoreSignalProfileType, string(lead.ProfileType), c.ProfileTypes[lead.ProfileType])
+	addSignal(constants.ScoreSignalStage, string(lead.ConnectionStatus), c.Stages[lead.ConnectionStatus])
+	if state := lead.FollowupState(now); state != constants.FollowupStateNone {
+		addSignal(constants.ScoreSignalFollowup, string(state), c.Followups[state])
+	}
+	if respondedAt := lead.LastResponseAt(); respondedAt != nil && c.Response.DecayDays > 0 {
+		days := int(StartOfDay(now).Sub(StartOfDay(*respondedAt)).Hours() / 24)
+		if days < c.Response.DecayDays {
+			detail := fmt.Sprintf("%d days ago", days)
+			switch days {
+			case 0:
+				detail = "today"
+			case 1:
+				detail = "yesterday"
+			}
+			addSignal(constants.ScoreSignalResponse, detail, c.Response.Points*(c.Response.DecayDays-days)/c.Response.DecayDays)
+		}
+	}
+	matched := c.matchKeywords(lead.Notes.Text())
+	for i, keyword := range c.Keywords {
+		if matched[i] {
+			addSignal(constants.ScoreSignalKeyword, keyword.Keyword, keyword.Points)
+		}
+	}
+
+	total := 0
+	for _, signal := range signals {
+		total += signal.Points
+	}
+	return total, signals
+}
+
+// matchKeywords tells which keywords the notes contain, by their index. The
+// longest keywords are matched first and their matches are blanked out, so
+// notes saying "not interested" don't also match the keyword "interested".
+func (c *ScoringConfig) matchKeywords(notes string) map[int]bool {
+	order := make([]int, len(c.Keywords))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return len(c.Keywords[order[i]].Keyword) > len(c.Keywords[order[j]].Keyword)
+	})
+
+	notes = strings.ToLower(notes)
+	matched := make(map[int]bool)
+	for _, i := range order {
+		keyword := strings.ToLower(c.Keywords[i].Keyword)
+		if strings.Contains(notes, keyword) {
+			matched[i] = true
+			notes = strings.ReplaceAll(notes, keyword, "\n")
+		}
+	}
+	return matched
+}
+
+// Temperature tells whether the score makes a lead hot or cold
+func (c *ScoringConfig) Temperature(score int) constants.LeadTemperature {
+	if score >= c.HotThreshold {
+		return constants.LeadTemperatureHot
+	}
+	return constants.LeadTemperatureCold
+}
+
+// LastResponseAt returns when the lead last moved into the responded stage,
+// nil if it never did
+func (l *Lead) LastResponseAt() *time.Time {
+	for i := len(l.StatusHistory) - 1; i >= 0; i-- {
+		change := l.StatusHistory[i]
+		if change.Field == constants.LeadFieldConnectionStatus && change.NewValue == string(constants.ConnectionStatusResponded) {
+			return &change.ChangedAt
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"leadgentracker/internals/model/constants"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Sequence is an ordered list of outreach steps a lead goes through
+type Sequence struct {
+	ID   primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name string             `json:"name" bson:"name"`
+	// OutreachType restricts the sequence to leads of one outreach type, empty allows any
+	OutreachType constants.OutreachType `json:"outreachType,omitempty" bson:"outreachType,omitempty"`
+	// Default sequences are attached to new leads of their outreach type that don't name a sequence
+	Default bool           `json:"default" bson:"default"`
+	Steps   []SequenceStep `json:"steps" bson:"steps"`
+}
+
+type SequenceStep struct {
+	Name string `json:"name" bson:"name"`
+	// DelayDays is how long after the previous step, or after the lead was
+	// attached for the first step, this step falls due
+	DelayDays int `json:"delayDays" bson:"delayDays"`
+}
+
+// SequenceProgress tracks a lead through its sequence. It keeps a copy of
+// the steps, so editing or deleting the sequence doesn't affect attached leads.
+type SequenceProgress struct {
+	SequenceID primitive.ObjectID `json:"sequenceId" bson:"sequenceId"`
+	Name       string             `json:"name" bson:"name"`
+	Steps      []SequenceStep     `json:"steps" bson:"steps"`
+	// NextStep is the index of the step to do next, len(Steps) once all are done
+	NextStep      int        `json:"nextStep" bson:"nextStep"`
+	NextStepDueAt *time.Time `json:"nextStepDueAt,omitempty" bson:"nextStepDueAt,omitempty"`
+	StartedAt     time.Time  `json:"startedAt" bson:"startedAt"`
+	CompletedAt   *time.Time `json:"completedAt,omitempty" bson:"completedAt,omitempty"`
+}
+
+// Validate checks that the sequence has a name, a valid outreach type and at least one named step
+func (s *Sequence) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return errors.New("a sequence needs a name")
+	}
+	if s.OutreachType != "" {
+		if err := constants.ValidateOutReachType(s.OutreachType); err != nil {
+			return err
+		}
+	}
+	if len(s.Steps) == 0 {
+		return errors.New("a sequence needs at least one step")
+	}
+	for i, step := range s.Steps {
+		if strings.TrimSpace(step.Name) == "" {
+			return fmt.Errorf("step %d has no name", i+1)
+		}
+		if step.DelayDays < 0 {
+			return fmt.Errorf("step %s has a negative delay", step.Name)
+		}
+	}
+	return nil
+}
+
+// Start returns the progress of a lead attached to the sequence at the given time
+func (s *Sequence) Start(now time.Time) *SequenceProgress {
+	progress := &SequenceProgress{
+		SequenceID: s.ID,
+		Name:       s.Name,
+		Steps:      append([]SequenceStep(nil), s.Steps...),
+		StartedAt:  now,
+	}
+	progress.scheduleNextStep(now)
+	return progress
+}
+
+// Current returns the step to do next, nil once the sequence is completed
+func (p *SequenceProgress) Current() *SequenceStep {
+	if p.NextStep >= len(p.Steps) {
+		return nil
+	}
+	return &p.Steps[p.NextStep]
+}
+
+// Advance returns the progress after the current step was done at the given time
+func (p *SequenceProgress) Advance(now time.Time) *SequenceProgress {
+	advanced := *p
+	advanced.NextStep++
+	advanced.scheduleNextStep(now)
+	return &advanced
+}
+
+func (p *SequenceProgress) scheduleNextStep(now time.Time) {
+	step := p.Current()
+	if step == nil {
+		p.NextStepDueAt = nil
+		p.CompletedAt = &now
+		return
+	}
+	dueAt := now.AddDate(0, 0, step.DelayDays)
+	p.NextStepDueAt = &dueAt
+}
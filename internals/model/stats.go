@@ -1,6 +1,32 @@
 package model
 
 type Stats struct {
-	Connections int `json:"connections"`
-	InMails     int `json:"InMails"`
+	Connections int `json:"connections" bson:"connections"`
+	InMails     int `json:"InMails" bson:"inMails"`
+}
+
+// DailyStats are the stats of a single day, dated as YYYY-MM-DD
+type DailyStats struct {
+	Date        string `json:"date" bson:"date"`
+	Connections int    `json:"connections" bson:"connections"`
+	InMails     int    `json:"inMails" bson:"inMails"`
+}
+
+// StatsSnapshot holds every stats counter, the total and the daily ones
+type StatsSnapshot struct {
+	Total Stats        `json:"totalStats"`
+	Daily []DailyStats `json:"dailyStats"`
+}
+
+// StatsChange is a counter whose stored value differs from the one recomputed from the leads
+type StatsChange struct {
+	Date    string `json:"date,omitempty"` // empty for the total stats
+	Field   string `json:"field"`
+	Current int    `json:"current"`
+	Rebuilt int    `json:"rebuilt"`
+}
+
+type StatsRebuildResult struct {
+	DryRun  bool          `json:"dryRun"`
+	Changes []StatsChange `json:"changes"`
 }
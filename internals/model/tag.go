@@ -0,0 +1,38 @@
+package model
+
+import "strings"
+
+// maxTagLength keeps tags short enough to show as badges
+const maxTagLength = 40
+
+// TagCount is how many leads outside the trash carry a tag
+type TagCount struct {
+	Tag   string `json:"tag" bson:"_id"`
+	Count int    `json:"count" bson:"count"`
+}
+
+// NormalizeTags splits the raw values on commas, trims and collapses the
+// whitespace of every tag and drops empty and repeated ones. Tags are
+// lowercased, so tags that only differ in case are the same tag when they
+// are stored, filtered and counted.
+func NormalizeTags(raw []string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, value := range raw {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.Join(strings.Fields(strings.ToLower(tag)), " ")
+			if tag == "" {
+				continue
+			}
+			if runes := []rune(tag); len(runes) > maxTagLength {
+				tag = strings.TrimSpace(string(runes[:maxTagLength]))
+			}
+			if seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
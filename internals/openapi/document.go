@@ -0,0 +1,589 @@
+// Package openapi describes the JSON API with an OpenAPI 3 document and
+// validates request bodies against it, so the served contract and the checks
+// applied to requests can't drift apart.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/model/dto"
+)
+
+const (
+	// Version is the version of the API the document describes
+	Version = "1.0.0"
+	// BasePath is the path every route of the API is served under
+	BasePath = "/api/v1"
+
+	bodyPath        = "body"
+	schemaRefPrefix = "#/components/schemas/"
+	contentTypeJSON = "application/json"
+)
+
+// objectIDPattern matches the hex IDs of leads, campaigns and the like
+const objectIDPattern = `^[0-9a-fA-F]{24}$`
+
+// stagePattern matches pipeline stage keys, see model.Pipeline.Validate
+const stagePattern = `^[a-zA-Z][a-zA-Z0-9]*$`
+
+// ErrInvalidSpec is returned when validating against a schema the document
+// doesn't define, which is a mistake in the document rather than in the request
+var ErrInvalidSpec = errors.New("invalid API spec")
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+
+	operations map[string]*Operation
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem holds the operations of a path by lower case HTTP method
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary"`
+	Description string              `json:"description,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// ValidateRequestBody checks the JSON body of a request to the operation
+// against its schema and returns the fields that don't match. A body that
+// isn't JSON fails with an error, a schema referring to one the document
+// doesn't define with ErrInvalidSpec.
+func (d *Document) ValidateRequestBody(operationID string, body []byte) ([]FieldError, error) {
+	operation, ok := d.operations[operationID]
+	if !ok || operation.RequestBody == nil {
+		return nil, fmt.Errorf("operation %s takes no request body", operationID)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("request body is empty")
+		}
+		return nil, err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("request body holds more than one JSON value")
+	}
+
+	return d.validate(operation.RequestBody.Content[contentTypeJSON].Schema, value, bodyPath)
+}
+
+// resolve returns the schema the reference points to, failing with ErrInvalidSpec for an unknown one
+func (d *Document) resolve(ref string) (*Schema, error) {
+	schema, ok := d.Components.Schemas[strings.TrimPrefix(ref, schemaRefPrefix)]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown schema %s", ErrInvalidSpec, ref)
+	}
+	return schema, nil
+}
+
+// NewDocument describes the lead and stats endpoints. The values of enums are
+// taken from the lists in constants.
+func NewDocument() *Document {
+	d := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "Lead Tracker API",
+			Description: "Leads and outreach stats of the lead tracker. Failed requests answer with an Error body.",
+			Version:     Version,
+		},
+		Paths: map[string]PathItem{
+			BasePath + "/leads": {
+				"get":  listLeadsOperation(),
+				"post": createLeadOperation(),
+			},
+			BasePath + "/leads/bulk": {
+				"post": bulkUpdateLeadsOperation(),
+			},
+			BasePath + "/leads/{id}": {
+				"get":    getLeadOperation(),
+				"patch":  updateLeadOperation(),
+				"delete": deleteLeadOperation(),
+			},
+			BasePath + "/stats": {
+				"get": {
+					OperationID: "getStats",
+					Summary:     "Get the total outreach counters and the ones of today",
+					Responses: map[string]Response{
+						"200": jsonResponse("The outreach counters", ref("StatsResponse")),
+					},
+				},
+			},
+			BasePath + "/openapi.json": {
+				"get": {
+					OperationID: "getSpec",
+					Summary:     "Get this document",
+					Responses: map[string]Response{
+						"200": {Description: "The OpenAPI document of the API"},
+					},
+				},
+			},
+		},
+		Components: Components{Schemas: schemas()},
+	}
+
+	d.operations = make(map[string]*Operation)
+	for _, item := range d.Paths {
+		for _, operation := range item {
+			d.operations[operation.OperationID] = operation
+		}
+	}
+	return d
+}
+
+func listLeadsOperation() *Operation {
+	return &Operation{
+		OperationID: "listLeads",
+		Summary:     "List the leads matching the filters of the lead list",
+		Description: "Custom fields are filtered by cf.<key> parameters, number fields by cf.<key>.min and " +
+			"cf.<key>.max and date fields by cf.<key>.from and cf.<key>.to. Trashed leads are not listed.",
+		Parameters: []Parameter{
+			queryParameter("search", "Words the name, URL or notes of the leads contain", &Schema{Type: "string"}),
+			queryParameter("outreachType", "", enumSchema(constants.OutreachTypes)),
+			queryParameter("connectionStatus", "Pipeline stage, applied when it is part of the pipeline of the filtered outreach type", stageSchema()),
+			queryParameter("leadTemperature", "", enumSchema(constants.LeadTemperatures)),
+			queryParameter("dateAdded", "Day the leads were added", &Schema{Type: "string", Format: "date"}),
+			queryParameter("followup", "State of the follow-up, the leads are then ordered by due date",
+				enumSchema([]constants.FollowupState{constants.FollowupStateDue, constants.FollowupStateOverdue})),
+			queryParameter("tag", "Tags the leads carry, repeated for several tags", &Schema{Type: "array", Items: &Schema{Type: "string"}}),
+			queryParameter("tagMatch", "Whether leads carry any or all of the tags", enumSchema(constants.TagMatches)),
+			queryParameter("company", "ID of the company the leads work at", objectIDSchema()),
+			queryParameter("campaign", "ID of the campaign the leads are assigned to", objectIDSchema()),
+			queryParameter("page", "Page to list, starting at 1", &Schema{Type: "integer"}),
+			queryParameter("perPage", fmt.Sprintf("Leads per page, %d unless set, at most %d", dto.LeadsPerPage, dto.MaxLeadsPerPage), &Schema{Type: "integer"}),
+		},
+		Responses: map[string]Response{
+			"200": jsonResponse("A page of the leads", ref("LeadList")),
+			"400": errorResponse("A filter is invalid"),
+		},
+	}
+}
+
+func createLeadOperation() *Operation {
+	return &Operation{
+		OperationID: "createLead",
+		Summary:     "Add a lead",
+		Description: "A lead with the same profile URL as an existing one is handled as onDuplicate decides: " +
+			"reject fails with 409, merge updates the existing lead and create adds a lead referencing it.",
+		RequestBody: jsonRequestBody(ref("CreateLeadRequest")),
+		Responses: map[string]Response{
+			"201": jsonResponse("The lead was added", ref("CreateLeadResponse")),
+			"200": jsonResponse("The lead was merged into the existing one", ref("CreateLeadResponse")),
+			"400": errorResponse("The body, sequence or campaign is invalid"),
+			"409": errorResponse("A lead with the profile URL exists, see the duplicate of the error"),
+		},
+	}
+}
+
+func getLeadOperation() *Operation {
+	return &Operation{
+		OperationID: "getLead",
+		Summary:     "Get a lead, also when it is in the trash",
+		Parameters:  []Parameter{leadIDParameter()},
+		Responses: map[string]Response{
+			"200": jsonResponse("The lead", ref("Lead")),
+			"400": errorResponse("The ID is invalid"),
+			"404": errorResponse("There is no such lead"),
+		},
+	}
+}
+
+func updateLeadOperation() *Operation {
+	return &Operation{
+		OperationID: "updateLead",
+		Summary:     "Update the pipeline details of a lead",
+		Description: "Fields left out keep their current value, null clears the nullable ones. " +
+			"The lead is rescored and the stage change recorded in its status history.",
+		Parameters:  []Parameter{leadIDParameter()},
+		RequestBody: jsonRequestBody(ref("UpdateLeadRequest")),
+		Responses: map[string]Response{
+			"200": jsonResponse("The updated lead", ref("Lead")),
+			"400": errorResponse("The body, a custom field value, the company or the campaign is invalid"),
+			"404": errorResponse("There is no such lead or it is in the trash"),
+			"409": errorResponse("The lead was changed by another request while it was updated"),
+			"422": errorResponse("The pipeline doesn't allow the stage change"),
+		},
+	}
+}
+
+func deleteLeadOperation() *Operation {
+	return &Operation{
+		OperationID: "deleteLead",
+		Summary:     "Move a lead to the trash",
+		Parameters:  []Parameter{leadIDParameter()},
+		Responses: map[string]Response{
+			"204": {Description: "The lead was moved to the trash"},
+			"404": errorResponse("There is no such lead outside the trash"),
+		},
+	}
+}
+
+func bulkUpdateLeadsOperation() *Operation {
+	return &Operation{
+		OperationID: "bulkUpdateLeads",
+		Summary:     "Apply an action to many leads at once",
+		Description: fmt.Sprintf("Up to %d leads are changed in one round trip. ", dto.MaxLeadsPerPage) +
+			"A lead the action can't change is reported in the items of the result instead of failing the request.",
+		RequestBody: jsonRequestBody(ref("BulkLeadRequest")),
+		Responses: map[string]Response{
+			"200": jsonResponse("What the action did to each lead", ref("BulkResult")),
+			"400": errorResponse("The body is invalid or lacks the value of the action"),
+		},
+	}
+}
+
+func schemas() map[string]*Schema {
+	timestamp := &Schema{Type: "string", Format: "date-time"}
+
+	return map[string]*Schema{
+		"Lead": {
+			Type:     "object",
+			Required: []string{"id", "connectionStatus", "leadTemperature", "profileType", "outreachType", "date", "url", "name", "followupSent", "score"},
+			Properties: map[string]*Schema{
+				"id":               readOnly(objectIDSchema()),
+				"connectionStatus": stageSchema(),
+				"leadTemperature":  enumSchema(constants.LeadTemperatures),
+				"profileType":      enumSchema(constants.ProfileTypes),
+				"outreachType":     enumSchema(constants.OutreachTypes),
+				"date":             timestamp,
+				"url":              {Type: "string"},
+				"name":             {Type: "string"},
+				"followupSent":     {Type: "boolean"},
+				"followupDueAt":    {Type: "string", Format: "date-time", Description: "Midnight of the day the follow-up is due"},
+				"notes":            {Type: "array", Items: ref("Note")},
+				"pictureUrl":       {Type: "string"},
+				"statusHistory":    {Type: "array", Items: ref("StatusChange"), Nullable: true},
+				"deletedAt":        {Type: "string", Format: "date-time", Description: "When the lead was moved to the trash"},
+				"tags":             {Type: "array", Items: &Schema{Type: "string"}},
+				"customFields":     {Type: "object", AdditionalProperties: ref("CustomFieldValue"), Description: "Custom field values by field key"},
+				"score":            {Type: "integer"},
+				"scoreBreakdown":   {Type: "array", Items: ref("ScoreSignal")},
+				"temperatureOverride": withDescription(enumSchema(constants.LeadTemperatures),
+					"Temperature set by hand, which replaces the one of the score. Left out when the score decides."),
+				"sequence":        ref("SequenceProgress"),
+				"companyId":       objectIDSchema(),
+				"campaignId":      objectIDSchema(),
+				"messageTemplate": ref("MessageTemplateUsage"),
+				"messageTemplateHistory": withDescription(&Schema{Type: "array", Items: ref("MessageTemplateUsage")},
+					"Every message sent to the lead with a template, the oldest first"),
+				"normalizedUrl": {Type: "string", Description: "The unique profile key, empty on leads referencing a duplicate"},
+				"duplicateOf":   withDescription(objectIDSchema(), "The lead holding the profile key of this duplicate"),
+				"version":       readOnly(&Schema{Type: "integer", Description: "Counts the changes of the lead other than of its score"}),
+			},
+		},
+		"Note": {
+			Type:     "object",
+			Required: []string{"id", "text", "createdAt"},
+			Properties: map[string]*Schema{
+				"id":        objectIDSchema(),
+				"type":      enumSchema(constants.NoteTypes),
+				"text":      {Type: "string"},
+				"author":    {Type: "string"},
+				"createdAt": timestamp,
+				"updatedAt": timestamp,
+			},
+		},
+		"StatusChange": {
+			Type:     "object",
+			Required: []string{"field", "oldValue", "newValue", "changedAt"},
+			Properties: map[string]*Schema{
+				"field":     {Type: "string"},
+				"oldValue":  {Type: "string"},
+				"newValue":  {Type: "string"},
+				"changedAt": timestamp,
+			},
+		},
+		"CustomFieldValue": {
+			Type:        "object",
+			Description: "Holds text for text and select fields, number or date for the others",
+			Properties: map[string]*Schema{
+				"text":   {Type: "string"},
+				"number": {Type: "number"},
+				"date":   timestamp,
+			},
+		},
+		"ScoreSignal": {
+			Type:     "object",
+			Required: []string{"signal", "detail", "points"},
+			Properties: map[string]*Schema{
+				"signal": enumSchema(constants.ScoreSignals),
+				"detail": {Type: "string"},
+				"points": {Type: "integer"},
+			},
+		},
+		"SequenceProgress": {
+			Type:     "object",
+			Required: []string{"sequenceId", "name", "steps", "nextStep", "startedAt"},
+			Properties: map[string]*Schema{
+				"sequenceId": objectIDSchema(),
+				"name":       {Type: "string"},
+				"steps": {Type: "array", Items: &Schema{
+					Type:       "object",
+					Properties: map[string]*Schema{"name": {Type: "string"}, "delayDays": {Type: "integer"}},
+				}},
+				"nextStep":      {Type: "integer", Description: "Index of the step to do next, the number of steps once all are done"},
+				"nextStepDueAt": timestamp,
+				"startedAt":     timestamp,
+				"completedAt":   timestamp,
+			},
+		},
+		"MessageTemplateUsage": {
+			Type:     "object",
+			Required: []string{"templateId", "name", "usedAt"},
+			Properties: map[string]*Schema{
+				"templateId": objectIDSchema(),
+				"name":       {Type: "string"},
+				"usedAt":     timestamp,
+			},
+		},
+		"LeadList": {
+			Type:     "object",
+			Required: []string{"leads", "page", "perPage", "totalPages"},
+			Properties: map[string]*Schema{
+				"leads":      {Type: "array", Items: ref("Lead")},
+				"page":       {Type: "integer"},
+				"perPage":    {Type: "integer"},
+				"totalPages": {Type: "integer"},
+			},
+		},
+		"CreateLeadRequest": {
+			Type:     "object",
+			Closed:   true,
+			Required: []string{"profileType", "outreachType", "url", "name"},
+			Properties: map[string]*Schema{
+				"profileType":  enumSchema(constants.ProfileTypes),
+				"outreachType": enumSchema(constants.OutreachTypes),
+				"url":          {Type: "string", MinLength: 1, Description: "LinkedIn profile URL"},
+				"name":         {Type: "string", MinLength: 1},
+				"pictureUrl":   {Type: "string"},
+				"onDuplicate":  withDescription(enumSchema(constants.DuplicateResolutions), "How to handle an existing lead with the profile URL, reject unless set"),
+				"sequenceId":   withDescription(nullable(objectIDSchema()), "Sequence to attach the lead to, the default one of its outreach type unless set"),
+				"campaignId":   withDescription(nullable(objectIDSchema()), "Campaign to assign the lead to"),
+			},
+		},
+		"CreateLeadResponse": {
+			Type:     "object",
+			Required: []string{"lead"},
+			Properties: map[string]*Schema{
+				"lead":       ref("Lead"),
+				"resolution": withDescription(enumSchema(constants.DuplicateResolutions), "How a duplicate was handled, left out when there was none"),
+				"duplicate":  ref("DuplicateMatch"),
+			},
+		},
+		"UpdateLeadRequest": {
+			Type:   "object",
+			Closed: true,
+			Properties: map[string]*Schema{
+				"connectionStatus": stageSchema(),
+				"followupSent":     {Type: "boolean"},
+				"followupDueAt":    {Type: "string", Format: "date", Nullable: true, Description: "Day the follow-up is due, null to schedule none"},
+				"tags":             {Type: "array", Items: &Schema{Type: "string"}, Description: "Replaces the tags of the lead"},
+				"customFields": {
+					Type:                 "object",
+					AdditionalProperties: &Schema{Type: "string"},
+					Description:          "Values by field key, entered as on the lead form. An empty value clears the field, fields left out keep their value.",
+				},
+				"companyId":  withDescription(nullable(objectIDSchema()), "Company the lead works at, null to unlink it"),
+				"campaignId": withDescription(nullable(objectIDSchema()), "Campaign of the lead, null to take it out of its campaign"),
+			},
+		},
+		"BulkLeadRequest": {
+			Type:     "object",
+			Closed:   true,
+			Required: []string{"ids", "action"},
+			Properties: map[string]*Schema{
+				"ids":    {Type: "array", Items: objectIDSchema(), Description: fmt.Sprintf("Leads to apply the action to, at most %d", dto.MaxLeadsPerPage)},
+				"action": enumSchema(constants.BulkActions),
+				"leadTemperature": withDescription(enumSchema(append([]constants.LeadTemperature{""}, constants.LeadTemperatures...)),
+					"Temperature set by setTemperature, empty lets the score decide again"),
+				"connectionStatus": withDescription(stageSchema(), "Stage set by setStatus, the pipeline of each lead must allow the move"),
+				"tag":              {Type: "string", Description: "Tag added by addTag"},
+			},
+		},
+		"BulkResult": {
+			Type:     "object",
+			Required: []string{"action", "updated", "unchanged", "failed", "items"},
+			Properties: map[string]*Schema{
+				"action":    enumSchema(constants.BulkActions),
+				"updated":   {Type: "integer"},
+				"unchanged": {Type: "integer"},
+				"failed":    {Type: "integer"},
+				"items":     {Type: "array", Items: ref("BulkItemResult"), Description: "The outcome for each lead, in the order of the request"},
+			},
+		},
+		"BulkItemResult": {
+			Type:     "object",
+			Required: []string{"id", "outcome"},
+			Properties: map[string]*Schema{
+				"id":      objectIDSchema(),
+				"name":    {Type: "string", Description: "Left out when there is no such lead"},
+				"outcome": enumSchema(constants.BulkOutcomes),
+				"reason":  {Type: "string", Description: "Why the lead was left unchanged or failed"},
+			},
+		},
+		"StatsResponse": {
+			Type:     "object",
+			Required: []string{"total", "today"},
+			Properties: map[string]*Schema{
+				"total": ref("Stats"),
+				"today": ref("Stats"),
+			},
+		},
+		"Stats": {
+			Type:     "object",
+			Required: []string{"connections", "InMails"},
+			Properties: map[string]*Schema{
+				"connections": {Type: "integer"},
+				"InMails":     {Type: "integer"},
+			},
+		},
+		"DuplicateMatch": {
+			Type:     "object",
+			Required: []string{"id", "name", "url", "trashed"},
+			Properties: map[string]*Schema{
+				"id":      objectIDSchema(),
+				"name":    {Type: "string"},
+				"url":     {Type: "string"},
+				"trashed": {Type: "boolean"},
+			},
+		},
+		"Error": {
+			Type:     "object",
+			Required: []string{"error"},
+			Properties: map[string]*Schema{
+				"error": {
+					Type:     "object",
+					Required: []string{"code", "message"},
+					Properties: map[string]*Schema{
+						"code":      {Type: "string", Description: "Machine readable reason, e.g. invalid_request, validation_failed or not_found"},
+						"message":   {Type: "string"},
+						"fields":    {Type: "array", Items: ref("FieldError"), Description: "The fields of the body that don't match the spec"},
+						"duplicate": ref("DuplicateMatch"),
+					},
+				},
+			},
+		},
+		"FieldError": {
+			Type:     "object",
+			Required: []string{"field", "message"},
+			Properties: map[string]*Schema{
+				"field":   {Type: "string", Description: "Path to the value, e.g. tags[1], or body for the body itself"},
+				"message": {Type: "string"},
+			},
+		},
+	}
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: schemaRefPrefix + name}
+}
+
+func enumSchema[T ~string](values []T) *Schema {
+	enum := make([]string, len(values))
+	for i, value := range values {
+		enum[i] = string(value)
+	}
+	return &Schema{Type: "string", Enum: enum}
+}
+
+func patternSchema(pattern string) *Schema {
+	return &Schema{Type: "string", Pattern: pattern, pattern: regexp.MustCompile(pattern)}
+}
+
+func objectIDSchema() *Schema {
+	return patternSchema(objectIDPattern)
+}
+
+// stageSchema matches any stage key, pipelines can be customized beyond the default stages
+func stageSchema() *Schema {
+	schema := patternSchema(stagePattern)
+	stages := make([]string, len(constants.DefaultStages))
+	for i, stage := range constants.DefaultStages {
+		stages[i] = string(stage)
+	}
+	schema.Description = "Stage key of the lead's pipeline, the default pipelines use " + strings.Join(stages, ", ")
+	return schema
+}
+
+func nullable(schema *Schema) *Schema {
+	schema.Nullable = true
+	return schema
+}
+
+func readOnly(schema *Schema) *Schema {
+	schema.ReadOnly = true
+	return schema
+}
+
+func withDescription(schema *Schema, description string) *Schema {
+	schema.Description = description
+	return schema
+}
+
+func queryParameter(name string, description string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func leadIDParameter() Parameter {
+	return Parameter{Name: "id", In: "path", Required: true, Schema: objectIDSchema()}
+}
+
+func jsonRequestBody(schema *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]MediaType{contentTypeJSON: {Schema: schema}}}
+}
+
+func jsonResponse(description string, schema *Schema) Response {
+	return Response{Description: description, Content: map[string]MediaType{contentTypeJSON: {Schema: schema}}}
+}
+
+func errorResponse(description string) Response {
+	return jsonResponse(description, ref("Error"))
+}
@@ -0,0 +1,224 @@
+package openapi
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"leadgentracker/internals/model"
+)
+
+// TestSchemasMatchModels keeps the hand-written schemas of the responses in
+// line with the JSON fields of the types the API encodes
+func TestSchemasMatchModels(t *testing.T) {
+	tests := []struct {
+		schema string
+		value  any
+	}{
+		{schema: "Lead", value: model.Lead{}},
+		{schema: "Note", value: model.Note{}},
+		{schema: "StatusChange", value: model.StatusChange{}},
+		{schema: "CustomFieldValue", value: model.CustomFieldValue{}},
+		{schema: "ScoreSignal", value: model.ScoreSignal{}},
+		{schema: "SequenceProgress", value: model.SequenceProgress{}},
+		{schema: "MessageTemplateUsage", value: model.MessageTemplateUsage{}},
+		{schema: "Stats", value: model.Stats{}},
+	}
+
+	d := NewDocument()
+	for _, tt := range tests {
+		t.Run(tt.schema, func(t *testing.T) {
+			schema, ok := d.Components.Schemas[tt.schema]
+			if !ok {
+				t.Fatalf("schema %s is not defined", tt.schema)
+			}
+
+			fields := jsonFields(reflect.TypeOf(tt.value))
+			for _, field := range fields {
+				if _, ok := schema.Properties[field]; !ok {
+					t.Errorf("field %s of %T is missing in the schema", field, tt.value)
+				}
+			}
+			for property := range schema.Properties {
+				if !slices.Contains(fields, property) {
+					t.Errorf("property %s is not a field of %T", property, tt.value)
+				}
+			}
+			for _, required := range schema.Required {
+				if _, ok := schema.Properties[required]; !ok {
+					t.Errorf("required property %s is not defined", required)
+				}
+			}
+		})
+	}
+}
+
+// TestSchemaRefsResolve checks every reference of the document points to a defined schema
+func TestSchemaRefsResolve(t *testing.T) {
+	d := NewDocument()
+
+	var check func(schema *Schema, path string)
+	check = func(schema *Schema, path string) {
+		if schema == nil {
+			return
+		}
+		if schema.Ref != "" {
+			if _, err := d.resolve(schema.Ref); err != nil {
+				t.Errorf("%s: %s", path, err)
+			}
+		}
+		for name, property := range schema.Properties {
+			check(property, path+"."+name)
+		}
+		check(schema.Items, path+"[]")
+		check(schema.AdditionalProperties, path+".*")
+	}
+
+	for name, schema := range d.Components.Schemas {
+		check(schema, name)
+	}
+	for path, item := range d.Paths {
+		for method, operation := range item {
+			if operation.RequestBody != nil {
+				check(operation.RequestBody.Content[contentTypeJSON].Schema, method+" "+path+" body")
+			}
+			for status, response := range operation.Responses {
+				for _, mediaType := range response.Content {
+					check(mediaType.Schema, method+" "+path+" "+status)
+				}
+			}
+		}
+	}
+}
+
+func TestValidateRequestBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		operationID string
+		body        string
+		wantFields  []string
+		wantErr     bool
+	}{
+		{
+			name:        "valid lead",
+			operationID: "createLead",
+			body:        `{"profileType":"public","outreachType":"connection","url":"https://www.linkedin.com/in/jane","name":"Jane"}`,
+		},
+		{
+			name:        "missing required fields",
+			operationID: "createLead",
+			body:        `{"profileType":"public","outreachType":"connection"}`,
+			wantFields:  []string{"url", "name"},
+		},
+		{
+			name:        "unknown field",
+			operationID: "createLead",
+			body:        `{"profileType":"public","outreachType":"connection","url":"u","name":"Jane","color":"red"}`,
+			wantFields:  []string{"color"},
+		},
+		{
+			name:        "value outside the enum",
+			operationID: "createLead",
+			body:        `{"profileType":"secret","outreachType":"connection","url":"u","name":"Jane"}`,
+			wantFields:  []string{"profileType"},
+		},
+		{
+			name:        "wrong types",
+			operationID: "updateLead",
+			body:        `{"followupSent":"yes","tags":["a",1]}`,
+			wantFields:  []string{"followupSent", "tags[1]"},
+		},
+		{
+			name:        "invalid object ID",
+			operationID: "updateLead",
+			body:        `{"companyId":"acme"}`,
+			wantFields:  []string{"companyId"},
+		},
+		{
+			name:        "null clears a nullable field",
+			operationID: "updateLead",
+			body:        `{"companyId":null,"followupDueAt":null}`,
+		},
+		{
+			name:        "invalid date",
+			operationID: "updateLead",
+			body:        `{"followupDueAt":"tomorrow"}`,
+			wantFields:  []string{"followupDueAt"},
+		},
+		{
+			name:        "body is not an object",
+			operationID: "updateLead",
+			body:        `[]`,
+			wantFields:  []string{"body"},
+		},
+		{
+			name:        "empty body",
+			operationID: "updateLead",
+			body:        ``,
+			wantErr:     true,
+		},
+		{
+			name:        "more than one value",
+			operationID: "updateLead",
+			body:        `{} {}`,
+			wantErr:     true,
+		},
+		{
+			name:        "operation without body",
+			operationID: "getLead",
+			body:        `{}`,
+			wantErr:     true,
+		},
+	}
+
+	d := NewDocument()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fieldErrs, err := d.ValidateRequestBody(tt.operationID, []byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateRequestBody() error = %v, want error %t", err, tt.wantErr)
+			}
+
+			var fields []string
+			for _, fieldErr := range fieldErrs {
+				fields = append(fields, fieldErr.Field)
+			}
+			slices.Sort(fields)
+			want := slices.Clone(tt.wantFields)
+			slices.Sort(want)
+			if !slices.Equal(fields, want) {
+				t.Errorf("ValidateRequestBody() fields = %v, want %v (%v)", fields, want, fieldErrs)
+			}
+		})
+	}
+}
+
+func TestValidateUnknownRef(t *testing.T) {
+	d := NewDocument()
+	_, err := d.validate(ref("Missing"), map[string]any{}, bodyPath)
+	if !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("validate() error = %v, want ErrInvalidSpec", err)
+	}
+}
+
+// jsonFields returns the names the fields of the struct type are encoded with
+func jsonFields(structType reflect.Type) []string {
+	var fields []string
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
@@ -0,0 +1,190 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of the OpenAPI 3.0 schema object the API spec uses
+type Schema struct {
+	Ref         string   `json:"$ref,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Format      string   `json:"format,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Pattern     string   `json:"pattern,omitempty"`
+	MinLength   int      `json:"minLength,omitempty"`
+	Nullable    bool     `json:"nullable,omitempty"`
+	ReadOnly    bool     `json:"readOnly,omitempty"`
+
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	// AdditionalProperties is the schema of the object's other properties,
+	// nil allows any and Closed rejects them
+	AdditionalProperties *Schema `json:"additionalProperties,omitempty"`
+	Closed               bool    `json:"-"`
+	Items                *Schema `json:"items,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// MarshalJSON writes a closed object as additionalProperties false
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type schema Schema
+	if !s.Closed {
+		return json.Marshal((*schema)(s))
+	}
+	return json.Marshal(struct {
+		*schema
+		AdditionalProperties bool `json:"additionalProperties"`
+	}{(*schema)(s), false})
+}
+
+// FieldError is a value of a request body that doesn't match the spec. Field
+// is the path to the value, e.g. "tags[1]", or "body" for the body itself.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+// validate checks the value, as decoded by a json.Decoder using numbers,
+// against the schema and returns every mismatch
+func (d *Document) validate(schema *Schema, value any, path string) ([]FieldError, error) {
+	if schema.Ref != "" {
+		resolved, err := d.resolve(schema.Ref)
+		if err != nil {
+			return nil, err
+		}
+		return d.validate(resolved, value, path)
+	}
+	if value == nil {
+		if schema.Nullable {
+			return nil, nil
+		}
+		return []FieldError{{Field: path, Message: "must not be null"}}, nil
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return []FieldError{{Field: path, Message: "must be an object"}}, nil
+		}
+		return d.validateObject(schema, object, path)
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return []FieldError{{Field: path, Message: "must be an array"}}, nil
+		}
+		var errs []FieldError
+		for i, item := range array {
+			itemErrs, err := d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			errs = append(errs, itemErrs...)
+		}
+		return errs, nil
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return []FieldError{{Field: path, Message: "must be a string"}}, nil
+		}
+		if err := validateString(schema, str); err != "" {
+			return []FieldError{{Field: path, Message: err}}, nil
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []FieldError{{Field: path, Message: "must be a boolean"}}, nil
+		}
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			return []FieldError{{Field: path, Message: "must be a number"}}, nil
+		}
+		if _, err := number.Int64(); err != nil && schema.Type == "integer" {
+			return []FieldError{{Field: path, Message: "must be an integer"}}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (d *Document) validateObject(schema *Schema, object map[string]any, path string) ([]FieldError, error) {
+	var errs []FieldError
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			errs = append(errs, FieldError{Field: join(path, name), Message: "is required"})
+		}
+	}
+
+	// Sorted, so the errors are reported in a stable order
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		propertySchema, ok := schema.Properties[name]
+		switch {
+		case ok:
+		case schema.Closed:
+			errs = append(errs, FieldError{Field: join(path, name), Message: "is not a known field"})
+			continue
+		case schema.AdditionalProperties != nil:
+			propertySchema = schema.AdditionalProperties
+		default:
+			continue
+		}
+
+		propertyErrs, err := d.validate(propertySchema, object[name], join(path, name))
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, propertyErrs...)
+	}
+	return errs, nil
+}
+
+// validateString returns why the string doesn't match the schema, empty when it does
+func validateString(schema *Schema, value string) string {
+	if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, value) {
+		return fmt.Sprintf("must be one of %s", strings.Join(quoted(schema.Enum), ", "))
+	}
+	if len(value) < schema.MinLength {
+		return "must not be empty"
+	}
+	if schema.pattern != nil && !schema.pattern.MatchString(value) {
+		return fmt.Sprintf("must match %s", schema.Pattern)
+	}
+	if schema.Format == "date" {
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return "must be a date formatted as YYYY-MM-DD"
+		}
+	}
+	return ""
+}
+
+func quoted(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+	return quoted
+}
+
+// join appends the property name to the path of its object
+func join(path string, name string) string {
+	if path == bodyPath {
+		return name
+	}
+	return path + "." + name
+}
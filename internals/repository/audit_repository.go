@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/dto"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	MongoFieldAuditTimestamp  = "timestamp"
+	MongoFieldAuditActor      = "actor"
+	MongoFieldAuditAction     = "action"
+	MongoFieldAuditEntityType = "entityType"
+	MongoFieldAuditEntityID   = "entityId"
+)
+
+type MongoAuditRepository struct {
+	db  *mongo.Client
+	col *mongo.Collection
+}
+
+func NewAuditRepository(client *mongo.Client) *MongoAuditRepository {
+	return &MongoAuditRepository{
+		db:  client,
+		col: client.Database(os.Getenv("MONGO_DB")).Collection(collectionAudit),
+	}
+}
+
+func (r *MongoAuditRepository) Create(ctx context.Context, entry *model.AuditEntry) error {
+	_, err := r.col.InsertOne(ctx, entry)
+	if err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListPaged returns the matching entries newest first
+func (r *MongoAuditRepository) ListPaged(ctx context.Context, filter *dto.AuditFilter) ([]model.AuditEntry, int, error) {
+	query := bson.D{}
+	if filter.Actor != "" {
+		query = append(query, bson.E{Key: MongoFieldAuditActor, Value: filter.Actor})
+	}
+	if filter.Action != "" {
+		query = append(query, bson.E{Key: MongoFieldAuditAction, Value: filter.Action})
+	}
+	if filter.EntityType != "" {
+		query = append(query, bson.E{Key: MongoFieldAuditEntityType, Value: filter.EntityType})
+	}
+	if filter.EntityID != "" {
+		query = append(query, bson.E{Key: MongoFieldAuditEntityID, Value: filter.EntityID})
+	}
+
+	totalCount, err := r.col.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(filter.EntriesPerPage)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	opts := options.Find().
+		SetSkip(int64((filter.Page - 1) * filter.EntriesPerPage)).
+		SetLimit(int64(filter.EntriesPerPage)).
+		SetSort(bson.D{{Key: MongoFieldAuditTimestamp, Value: -1}})
+
+	cursor, err := r.col.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	entries := []model.AuditEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode audit entries: %w", err)
+	}
+
+	return entries, totalPages, nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"leadgentracker/internals/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoCampaignRepository struct {
+	db  *mongo.Client
+	col *mongo.Collection
+}
+
+func NewCampaignRepository(client *mongo.Client) *MongoCampaignRepository {
+	return &MongoCampaignRepository{
+		db:  client,
+		col: client.Database(os.Getenv("MONGO_DB")).Collection(collectionCampaigns),
+	}
+}
+
+func (r *MongoCampaignRepository) Create(ctx context.Context, campaign *model.Campaign) error {
+	if _, err := r.col.InsertOne(ctx, campaign); err != nil {
+		return fmt.Errorf("failed to create campaign: %w", err)
+	}
+	return nil
+}
+
+func (r *MongoCampaignRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Campaign, error) {
+	var campaign model.Campaign
+	if err := r.col.FindOne(ctx, bson.D{{Key: MongoFieldID, Value: id}}).Decode(&campaign); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", ErrCampaignNotFound, id.Hex())
+		}
+		return nil, fmt.Errorf("failed to find campaign: %w", err)
+	}
+	return &campaign, nil
+}
+
+// List returns the campaigns, the newest first
+func (r *MongoCampaignRepository) List(ctx context.Context) ([]model.Campaign, error) {
+	cursor, err := r.col.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: MongoFieldID, Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list campaigns: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var campaigns []model.Campaign
+	if err := cursor.All(ctx, &campaigns); err != nil {
+		return nil, fmt.Errorf("failed to decode campaigns: %w", err)
+	}
+	return campaigns, nil
+}
+
+func (r *MongoCampaignRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := r.col.DeleteOne(ctx, bson.D{{Key: MongoFieldID, Value: id}}); err != nil {
+		return fmt.Errorf("failed to delete campaign: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"leadgentracker/internals/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoCompanyRepository struct {
+	db  *mongo.Client
+	col *mongo.Collection
+}
+
+func NewCompanyRepository(client *mongo.Client) *MongoCompanyRepository {
+	return &MongoCompanyRepository{
+		db:  client,
+		col: client.Database(os.Getenv("MONGO_DB")).Collection(collectionCompanies),
+	}
+}
+
+func (r *MongoCompanyRepository) Create(ctx context.Context, company *model.Company) error {
+	if _, err := r.col.InsertOne(ctx, company); err != nil {
+		return fmt.Errorf("failed to create company: %w", err)
+	}
+	return nil
+}
+
+func (r *MongoCompanyRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Company, error) {
+	var company model.Company
+	if err := r.col.FindOne(ctx, bson.D{{Key: MongoFieldID, Value: id}}).Decode(&company); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", ErrCompanyNotFound, id.Hex())
+		}
+		return nil, fmt.Errorf("failed to find company: %w", err)
+	}
+	return &company, nil
+}
+
+// List returns the companies ordered by name
+func (r *MongoCompanyRepository) List(ctx context.Context) ([]model.Company, error) {
+	cursor, err := r.col.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: MongoFieldName, Value: 1}, {Key: MongoFieldID, Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list companies: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var companies []model.Company
+	if err := cursor.All(ctx, &companies); err != nil {
+		return nil, fmt.Errorf("failed to decode companies: %w", err)
+	}
+	return companies, nil
+}
+
+func (r *MongoCompanyRepository) Update(ctx context.Context, company *model.Company) error {
+	result, err := r.col.ReplaceOne(ctx, bson.D{{Key: MongoFieldID, Value: company.ID}}, company)
+	if err != nil {
+		return fmt.Errorf("failed to update company: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrCompanyNotFound, company.ID.Hex())
+	}
+	return nil
+}
+
+func (r *MongoCompanyRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := r.col.DeleteOne(ctx, bson.D{{Key: MongoFieldID, Value: id}}); err != nil {
+		return fmt.Errorf("failed to delete company: %w", err)
+	}
+	return nil
+}
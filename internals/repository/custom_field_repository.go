@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"leadgentracker/internals/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoCustomFieldRepository struct {
+	db  *mongo.Client
+	col *mongo.Collection
+}
+
+func NewCustomFieldRepository(client *mongo.Client) *MongoCustomFieldRepository {
+	return &MongoCustomFieldRepository{
+		db:  client,
+		col: client.Database(os.Getenv("MONGO_DB")).Collection(collectionFields),
+	}
+}
+
+func (r *MongoCustomFieldRepository) Create(ctx context.Context, field *model.CustomField) error {
+	if _, err := r.col.InsertOne(ctx, field); err != nil {
+		return fmt.Errorf("failed to create custom field: %w", err)
+	}
+	return nil
+}
+
+func (r *MongoCustomFieldRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.CustomField, error) {
+	var field model.CustomField
+	if err := r.col.FindOne(ctx, bson.D{{Key: MongoFieldID, Value: id}}).Decode(&field); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", ErrCustomFieldNotFound, id.Hex())
+		}
+		return nil, fmt.Errorf("failed to find custom field: %w", err)
+	}
+	return &field, nil
+}
+
+// List returns the custom fields in the order they were created
+func (r *MongoCustomFieldRepository) List(ctx context.Context) ([]model.CustomField, error) {
+	cursor, err := r.col.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: MongoFieldID, Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custom fields: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var fields []model.CustomField
+	if err := cursor.All(ctx, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode custom fields: %w", err)
+	}
+	return fields, nil
+}
+
+func (r *MongoCustomFieldRepository) Update(ctx context.Context, field *model.CustomField) error {
+	result, err := r.col.ReplaceOne(ctx, bson.D{{Key: MongoFieldID, Value: field.ID}}, field)
+	if err != nil {
+		return fmt.Errorf("failed to update custom field: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrCustomFieldNotFound, field.ID.Hex())
+	}
+	return nil
+}
+
+func (r *MongoCustomFieldRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := r.col.DeleteOne(ctx, bson.D{{Key: MongoFieldID, Value: id}}); err != nil {
+		return fmt.Errorf("failed to delete custom field: %w", err)
+	}
+	return nil
+}
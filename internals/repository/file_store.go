@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	snapshotFileName = "snapshot.jsonl"
+	journalFileName  = "journal.jsonl"
+
+	// compactionThreshold is the number of journal entries after which the
+	// journal is folded into a new snapshot
+	compactionThreshold = 500
+)
+
+// journalEntry is a single line of the snapshot or journal file. An entry
+// without a document marks the deletion of the document with the given key.
+type journalEntry struct {
+	Collection string          `json:"collection"`
+	Key        string          `json:"key"`
+	Doc        json.RawMessage `json:"doc,omitempty"`
+}
+
+type fileJournal struct {
+	dir     string
+	file    *os.File
+	pending int
+}
+
+// OpenFileStore loads the store persisted in dir and keeps persisting every
+// change to it. Data is kept as a snapshot plus an append-only journal of
+// the changes made since, which is compacted into a new snapshot
+// periodically and when the store is closed.
+func OpenFileStore(dir string) (*MemoryStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	store := NewMemoryStore()
+	if err := store.load(filepath.Join(dir, snapshotFileName)); err != nil {
+		return nil, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+	if err := store.load(filepath.Join(dir, journalFileName)); err != nil {
+		return nil, fmt.Errorf("failed to replay journal: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, journalFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	store.journal = &fileJournal{dir: dir, file: file}
+
+	// Start from a fresh snapshot so the journal only holds this run's changes
+	if err := store.compact(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// load replays every entry of the given file, ignoring a missing file
+func (s *MemoryStore) load(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// A last line without a newline is a write interrupted by a crash
+			if len(bytes.TrimSpace(line)) > 0 {
+				log.Printf("[WARNING] ignoring incomplete entry at %s:%d", path, lineNumber)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var entry journalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("invalid entry at %s:%d: %w", path, lineNumber, err)
+		}
+		if err := s.apply(entry); err != nil {
+			return fmt.Errorf("invalid entry at %s:%d: %w", path, lineNumber, err)
+		}
+	}
+}
+
+// appendToJournal durably writes an entry, compacting first once enough
+// entries piled up. The entry is applied in memory only after it was written,
+// so the compaction must come before it to keep it in the snapshot or the
+// journal. It must be called with mu held.
+func (s *MemoryStore) appendToJournal(entry journalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+
+	if s.journal.pending >= compactionThreshold {
+		// The journal still holds every entry, a failed compaction is retried on the next write
+		if err := s.compact(); err != nil {
+			log.Printf("[WARNING] failed to compact storage: %s", err)
+		}
+	}
+
+	if _, err := s.journal.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := s.journal.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	s.journal.pending++
+	return nil
+}
+
+// compact writes the current state as a new snapshot and truncates the journal.
+// It must be called with mu held.
+func (s *MemoryStore) compact() error {
+	entries, err := s.documents()
+	if err != nil {
+		return fmt.Errorf("failed to compact storage: %w", err)
+	}
+
+	snapshotPath := filepath.Join(s.journal.dir, snapshotFileName)
+	tmpPath := snapshotPath + ".tmp"
+	if err := writeEntries(tmpPath, entries); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	// Renaming is atomic, so a crash leaves either the old or the new snapshot
+	if err := os.Rename(tmpPath, snapshotPath); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	// The rename must be durable before the journal is truncated, or a crash
+	// could leave the old snapshot with an empty journal
+	if err := syncDir(s.journal.dir); err != nil {
+		return fmt.Errorf("failed to sync storage directory: %w", err)
+	}
+
+	if err := s.journal.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate journal: %w", err)
+	}
+	s.journal.pending = 0
+	return nil
+}
+
+func writeEntries(path string, entries []journalEntry) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// syncDir flushes the entries of the directory, such as a renamed file, to disk
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
+
+func (j *fileJournal) close() error {
+	if err := j.file.Close(); err != nil {
+		return fmt.Errorf("failed to close journal: %w", err)
+	}
+	return nil
+}
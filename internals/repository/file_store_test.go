@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFileStoreReopen(t *testing.T) {
+	tests := []struct {
+		name string
+		// leads is the number of leads stored before the restart
+		leads int
+		// crash stops the store without the compaction of Close
+		crash bool
+		// tail is appended to the journal before the restart
+		tail string
+	}{
+		{name: "replays the journal after a crash", leads: 3, crash: true},
+		{name: "loads the snapshot after closing", leads: 3},
+		{name: "ignores a truncated last journal line", leads: 3, crash: true, tail: `{"collection":"leads","key":"`},
+		{name: "replays the journal written after a compaction", leads: compactionThreshold + 2, crash: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store := openTestFileStore(t, dir)
+			ids := createTestLeads(t, store, tt.leads)
+
+			if tt.crash {
+				crashFileStore(t, store)
+			} else if err := store.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if tt.tail != "" {
+				appendToFile(t, filepath.Join(dir, journalFileName), tt.tail)
+			}
+
+			reopened := openTestFileStore(t, dir)
+			defer reopened.Close()
+			if len(reopened.leads) != len(ids) {
+				t.Errorf("reopened store has %d leads, want %d", len(reopened.leads), len(ids))
+			}
+			for _, id := range ids {
+				if _, exists := reopened.leads[id]; !exists {
+					t.Errorf("reopened store lost lead %s", id.Hex())
+				}
+			}
+		})
+	}
+}
+
+// TestFileStoreCompaction checks that compacting moves the journal into the
+// snapshot, including deletions, and that the next run starts from it
+func TestFileStoreCompaction(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestFileStore(t, dir)
+	ids := createTestLeads(t, store, compactionThreshold+1)
+
+	// The full journal was compacted into the snapshot before the last lead was written
+	if journal := readFile(t, filepath.Join(dir, journalFileName)); bytes.Count(journal, []byte("\n")) != 1 {
+		t.Errorf("journal holds %d entries after compaction, want 1", bytes.Count(journal, []byte("\n")))
+	}
+
+	repo := NewMemoryLeadRepository(store)
+	if err := repo.Delete(context.Background(), ids[0]); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	crashFileStore(t, store)
+
+	reopened := openTestFileStore(t, dir)
+	defer reopened.Close()
+	if _, exists := reopened.leads[ids[0]]; exists {
+		t.Error("reopened store still has the deleted lead")
+	}
+	if len(reopened.leads) != len(ids)-1 {
+		t.Errorf("reopened store has %d leads, want %d", len(reopened.leads), len(ids)-1)
+	}
+	if _, err := os.Stat(filepath.Join(dir, snapshotFileName+".tmp")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("temporary snapshot was left behind: %v", err)
+	}
+}
+
+func openTestFileStore(t *testing.T, dir string) *MemoryStore {
+	t.Helper()
+
+	store, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatalf("OpenFileStore() error = %v", err)
+	}
+	return store
+}
+
+// crashFileStore stops the store like a crash would, without compacting it
+func crashFileStore(t *testing.T, store *MemoryStore) {
+	t.Helper()
+
+	if err := store.journal.close(); err != nil {
+		t.Fatalf("failed to close journal: %v", err)
+	}
+}
+
+func createTestLeads(t *testing.T, store *MemoryStore, count int) []primitive.ObjectID {
+	t.Helper()
+
+	repo := NewMemoryLeadRepository(store)
+	ids := make([]primitive.ObjectID, count)
+	for i := range ids {
+		lead := &model.Lead{
+			ID:           primitive.NewObjectID(),
+			Name:         "Lead",
+			OutreachType: constants.OutreachTypeConnection,
+			Date:         time.Now(),
+		}
+		if err := repo.Create(context.Background(), lead); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		ids[i] = lead.ID
+	}
+	return ids
+}
+
+func appendToFile(t *testing.T, path string, text string) {
+	t.Helper()
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(text); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return content
+}
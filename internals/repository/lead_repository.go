@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"math"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
 	"leadgentracker/internals/model/dto"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -20,17 +24,51 @@ import (
 const (
 	MongoFieldID               = "_id"
 	MongoFieldName             = "name"
-	MongoFieldOutreachType     = "outreachtype"
-	MongoFieldLeadTemp         = "leadtemperature"
-	MongoFieldConnectionStatus = "connectionstatus"
-	MongoFieldFollowupSent     = "followupsent"
+	MongoFieldOutreachType     = "outreachType"
+	MongoFieldLeadTemp         = "leadTemperature"
+	MongoFieldTempOverride     = "temperatureOverride"
+	MongoFieldConnectionStatus = "connectionStatus"
+	MongoFieldFollowupSent     = "followupSent"
 	MongoFieldDate             = "date"
-	MongoFieldProfileType      = "profiletype"
+	MongoFieldProfileType      = "profileType"
 	MongoFieldURL              = "url"
-	MongoFieldPictureURL       = "pictureurl"
+	MongoFieldPictureURL       = "pictureUrl"
 	MongoFieldNotes            = "notes"
+	MongoFieldNoteID           = "notes.id"
+	MongoFieldMessageTemplate  = "messageTemplate"
+	MongoFieldTemplateHistory  = "messageTemplateHistory"
+	MongoFieldTemplateID       = "messageTemplate.templateId"
+	MongoFieldStatusHistory    = "statusHistory"
+	MongoFieldDeletedAt        = "deletedAt"
+	MongoFieldNormalizedURL    = "normalizedUrl"
+	MongoFieldFollowupDueAt    = "followupDueAt"
+	MongoFieldSequence         = "sequence"
+	MongoFieldScore            = "score"
+	MongoFieldScoreBreakdown   = "scoreBreakdown"
+	MongoFieldTags             = "tags"
+	MongoFieldCustomFields     = "customFields"
+	MongoFieldCompanyID        = "companyId"
+	MongoFieldCampaignID       = "campaignId"
+	MongoFieldSequenceNextStep = "sequence.nextStep"
+	MongoFieldVersion          = "version"
+	// MongoFieldBatchID marks the leads a batch write changed with the ID of
+	// the batch, which tells the leads its filters matched from the others.
+	// The mark is removed again once they were told apart, see batchResult.
+	MongoFieldBatchID = "batchId"
 )
 
+// Weights of the searched lead fields in the relevance of a search result,
+// the same as in the leads_text index rebuilt by migration 11. Searching
+// another field requires a new migration rebuilding that index.
+const (
+	searchWeightName  = 10
+	searchWeightURL   = 5
+	searchWeightNotes = 1
+)
+
+// incrementVersion is part of every lead update except the ones of its score, see model.Lead
+var incrementVersion = bson.E{Key: "$inc", Value: bson.D{{Key: MongoFieldVersion, Value: 1}}}
+
 type MongoLeadRepository struct {
 	db  *mongo.Client
 	col *mongo.Collection
@@ -39,37 +77,170 @@ type MongoLeadRepository struct {
 func NewLeadRepository(client *mongo.Client) *MongoLeadRepository {
 	return &MongoLeadRepository{
 		db:  client,
-		col: client.Database(os.Getenv("MONGO_DB")).Collection("leads"),
+		col: client.Database(os.Getenv("MONGO_DB")).Collection(collectionLeads),
 	}
 }
 
 func (r *MongoLeadRepository) Create(ctx context.Context, lead *model.Lead) error {
 	_, err := r.col.InsertOne(ctx, lead)
 	if err != nil {
+		// The unique index on the normalized profile URL is the only one a new lead can violate
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("%w: %s", ErrDuplicateProfileURL, lead.NormalizedURL)
+		}
 		return fmt.Errorf("failed to create lead: %w", err)
 	}
 	return nil
 }
 
+// CreateBatch inserts the leads unordered, so a lead whose profile URL is
+// taken doesn't keep the ones after it from being stored
+func (r *MongoLeadRepository) CreateBatch(ctx context.Context, leads []*model.Lead) ([]primitive.ObjectID, error) {
+	docs := make([]any, len(leads))
+	for i, lead := range leads {
+		docs[i] = lead
+	}
+	_, err := r.col.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err == nil {
+		return leadIDs(leads, nil), nil
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) {
+		return nil, fmt.Errorf("failed to create leads: %w", err)
+	}
+	failed := make(map[int]bool, len(bulkErr.WriteErrors))
+	var writeErr error
+	for _, writeError := range bulkErr.WriteErrors {
+		failed[writeError.Index] = true
+		// The unique index on the normalized profile URL is the only one a new lead can violate
+		if !mongo.IsDuplicateKeyError(writeError) && writeErr == nil {
+			writeErr = writeError
+		}
+	}
+	if bulkErr.WriteConcernError != nil && writeErr == nil {
+		writeErr = bulkErr.WriteConcernError
+	}
+	if writeErr != nil {
+		return leadIDs(leads, failed), fmt.Errorf("failed to create leads: %w", writeErr)
+	}
+	return leadIDs(leads, failed), nil
+}
+
+// leadIDs returns the IDs of the leads but those at the skipped indexes
+func leadIDs(leads []*model.Lead, skipped map[int]bool) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(leads))
+	for i, lead := range leads {
+		if !skipped[i] {
+			ids = append(ids, lead.ID)
+		}
+	}
+	return ids
+}
+
+func (r *MongoLeadRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Lead, error) {
+	var lead model.Lead
+	err := r.col.FindOne(ctx, bson.D{{Key: MongoFieldID, Value: id}}).Decode(&lead)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", ErrLeadNotFound, id.Hex())
+		}
+		return nil, fmt.Errorf("failed to find lead: %w", err)
+	}
+	return &lead, nil
+}
+
+func (r *MongoLeadRepository) FindByNormalizedURL(ctx context.Context, normalizedURL string) (*model.Lead, error) {
+	var lead model.Lead
+	err := r.col.FindOne(ctx, bson.D{{Key: MongoFieldNormalizedURL, Value: normalizedURL}}).Decode(&lead)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", ErrLeadNotFound, normalizedURL)
+		}
+		return nil, fmt.Errorf("failed to find lead: %w", err)
+	}
+	return &lead, nil
+}
+
+// FindByNormalizedURLs returns the leads holding the normalized profile URLs in a single query
+func (r *MongoLeadRepository) FindByNormalizedURLs(ctx context.Context, normalizedURLs []string) ([]model.Lead, error) {
+	cursor, err := r.col.Find(ctx, bson.D{{Key: MongoFieldNormalizedURL, Value: bson.D{{Key: "$in", Value: normalizedURLs}}}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find leads: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var leads []model.Lead
+	if err := cursor.All(ctx, &leads); err != nil {
+		return nil, fmt.Errorf("failed to decode leads: %w", err)
+	}
+	return leads, nil
+}
+
 func (r *MongoLeadRepository) Update(ctx context.Context, updateProperties *dto.UpdateLeadProperties) (*model.Lead, error) {
 	// Filter for finding the lead by ID
-	filter := bson.D{{Key: "_id", Value: updateProperties.ID}}
+	// The update was validated against the lead at its version, and a lead in
+	// the trash is not changed until it is restored
+	filter := bson.D{
+		{Key: MongoFieldID, Value: updateProperties.ID},
+		versionFilter(updateProperties.Version),
+		{Key: MongoFieldDeletedAt, Value: bson.D{{Key: "$exists", Value: false}}},
+	}
 
 	// Construct the update document directly, since validation is handled beforehand
 	update := bson.D{
 		{Key: MongoFieldConnectionStatus, Value: updateProperties.ConnectionStatus},
-		{Key: MongoFieldLeadTemp, Value: updateProperties.LeadTemperature},
 		{Key: MongoFieldFollowupSent, Value: updateProperties.FollowupSent},
-		{Key: MongoFieldNotes, Value: updateProperties.Notes},
 	}
 
+	// A follow-up without due date is unset, the field only exists while one is scheduled
+	var unset bson.D
+	if updateProperties.FollowupDueAt != nil {
+		update = append(update, bson.E{Key: MongoFieldFollowupDueAt, Value: updateProperties.FollowupDueAt})
+	} else {
+		unset = bson.D{{Key: MongoFieldFollowupDueAt, Value: ""}}
+	}
+	// Same for the tags and custom fields, which only exist on leads that have some
+	if len(updateProperties.Tags) > 0 {
+		update = append(update, bson.E{Key: MongoFieldTags, Value: updateProperties.Tags})
+	} else {
+		unset = append(unset, bson.E{Key: MongoFieldTags, Value: ""})
+	}
+	if len(updateProperties.CustomFields) > 0 {
+		update = append(update, bson.E{Key: MongoFieldCustomFields, Value: updateProperties.CustomFields})
+	} else {
+		unset = append(unset, bson.E{Key: MongoFieldCustomFields, Value: ""})
+	}
+	if updateProperties.CompanyID != nil {
+		update = append(update, bson.E{Key: MongoFieldCompanyID, Value: updateProperties.CompanyID})
+	} else {
+		unset = append(unset, bson.E{Key: MongoFieldCompanyID, Value: ""})
+	}
+	if updateProperties.CampaignID != nil {
+		update = append(update, bson.E{Key: MongoFieldCampaignID, Value: updateProperties.CampaignID})
+	} else {
+		unset = append(unset, bson.E{Key: MongoFieldCampaignID, Value: ""})
+	}
+
+	updateDoc := bson.D{{Key: "$set", Value: update}}
+	if unset != nil {
+		updateDoc = append(updateDoc, bson.E{Key: "$unset", Value: unset})
+	}
+	if len(updateProperties.StatusChanges) > 0 {
+		updateDoc = append(updateDoc, bson.E{Key: "$push", Value: bson.D{{
+			Key:   MongoFieldStatusHistory,
+			Value: bson.D{{Key: "$each", Value: updateProperties.StatusChanges}},
+		}}})
+	}
+	updateDoc = append(updateDoc, incrementVersion)
+
 	// Use FindOneAndUpdate to perform the update and retrieve the updated document
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 	var updatedLead model.Lead
-	err := r.col.FindOneAndUpdate(ctx, filter, bson.D{{Key: "$set", Value: update}}, opts).Decode(&updatedLead)
+	err := r.col.FindOneAndUpdate(ctx, filter, updateDoc, opts).Decode(&updatedLead)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, fmt.Errorf("lead with ID %s not found", updateProperties.ID.Hex())
+			return nil, fmt.Errorf("%w: %s", ErrLeadNotFound, updateProperties.ID.Hex())
 		}
 		return nil, fmt.Errorf("failed to update lead: %w", err)
 	}
@@ -77,20 +248,436 @@ func (r *MongoLeadRepository) Update(ctx context.Context, updateProperties *dto.
 	return &updatedLead, nil
 }
 
+// UpdateProfile overwrites the profile details of a lead, empty properties keep their current value
+func (r *MongoLeadRepository) UpdateProfile(ctx context.Context, profileProperties *dto.LeadProfileProperties) (*model.Lead, error) {
+	update := bson.D{}
+	if profileProperties.ProfileType != "" {
+		update = append(update, bson.E{Key: MongoFieldProfileType, Value: profileProperties.ProfileType})
+	}
+	if profileProperties.Name != "" {
+		update = append(update, bson.E{Key: MongoFieldName, Value: profileProperties.Name})
+	}
+	if profileProperties.PictureUrl != "" {
+		update = append(update, bson.E{Key: MongoFieldPictureURL, Value: profileProperties.PictureUrl})
+	}
+	if profileProperties.CampaignID != nil {
+		update = append(update, bson.E{Key: MongoFieldCampaignID, Value: profileProperties.CampaignID})
+	}
+	if len(update) == 0 {
+		return r.FindByID(ctx, profileProperties.ID)
+	}
+
+	filter := bson.D{{Key: MongoFieldID, Value: profileProperties.ID}}
+	return r.findOneAndUpdate(ctx, profileProperties.ID, filter, bson.D{{Key: "$set", Value: update}})
+}
+
+// UpdateScore stores the score of the lead version it was rated from, it
+// fails with ErrLeadNotFound when the lead is missing or was changed since
+func (r *MongoLeadRepository) UpdateScore(ctx context.Context, scoreProperties *dto.LeadScoreProperties) (*model.Lead, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updatedLead model.Lead
+	err := r.col.FindOneAndUpdate(ctx, scoreFilter(scoreProperties), scoreUpdate(scoreProperties), opts).Decode(&updatedLead)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", ErrLeadNotFound, scoreProperties.ID.Hex())
+		}
+		return nil, fmt.Errorf("failed to update lead: %w", err)
+	}
+	return &updatedLead, nil
+}
+
+// scoreFilter matches the lead at the version its score was rated from. Scores don't change the
+// version, so a temperature change also needs the temperature it was rated
+// from, which keeps a concurrent rescore from recording the change twice.
+func scoreFilter(scoreProperties *dto.LeadScoreProperties) bson.D {
+	filter := bson.D{{Key: MongoFieldID, Value: scoreProperties.ID}, versionFilter(scoreProperties.Version)}
+	if change := scoreProperties.TemperatureChange; change != nil {
+		filter = append(filter, bson.E{Key: MongoFieldLeadTemp, Value: change.OldValue})
+	}
+	return filter
+}
+
+// versionFilter matches the lead at the version, a lead that was never
+// changed has no version yet
+func versionFilter(version int) bson.E {
+	if version == 0 {
+		return bson.E{Key: MongoFieldVersion, Value: bson.D{{Key: "$exists", Value: false}}}
+	}
+	return bson.E{Key: MongoFieldVersion, Value: version}
+}
+
+// scoreUpdate sets the score and records a temperature change in the status
+// history, which leaves the version of the lead as is
+func scoreUpdate(scoreProperties *dto.LeadScoreProperties) bson.D {
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: MongoFieldScore, Value: scoreProperties.Score},
+		{Key: MongoFieldScoreBreakdown, Value: scoreProperties.Breakdown},
+		{Key: MongoFieldLeadTemp, Value: scoreProperties.LeadTemperature},
+	}}}
+	if change := scoreProperties.TemperatureChange; change != nil {
+		update = append(update, bson.E{Key: "$push", Value: bson.D{{Key: MongoFieldStatusHistory, Value: change}}})
+	}
+	return update
+}
+
+// UnsetCustomField removes the values of the custom field from every lead
+func (r *MongoLeadRepository) UnsetCustomField(ctx context.Context, key string) error {
+	field := MongoFieldCustomFields + "." + key
+	filter := bson.D{{Key: field, Value: bson.D{{Key: "$exists", Value: true}}}}
+	update := bson.D{{Key: "$unset", Value: bson.D{{Key: field, Value: ""}}}, incrementVersion}
+	if _, err := r.col.UpdateMany(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to unset custom field %s: %w", key, err)
+	}
+	return nil
+}
+
+// UnlinkCompany removes the link to the company from every lead, including trashed ones
+func (r *MongoLeadRepository) UnlinkCompany(ctx context.Context, companyID primitive.ObjectID) error {
+	filter := bson.D{{Key: MongoFieldCompanyID, Value: companyID}}
+	update := bson.D{{Key: "$unset", Value: bson.D{{Key: MongoFieldCompanyID, Value: ""}}}, incrementVersion}
+	if _, err := r.col.UpdateMany(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to unlink company %s: %w", companyID.Hex(), err)
+	}
+	return nil
+}
+
+func (r *MongoLeadRepository) AddNote(ctx context.Context, leadID primitive.ObjectID, note model.Note) (*model.Lead, error) {
+	filter := bson.D{{Key: MongoFieldID, Value: leadID}}
+	update := bson.D{{Key: "$push", Value: bson.D{{Key: MongoFieldNotes, Value: note}}}}
+	return r.findOneAndUpdate(ctx, leadID, filter, update)
+}
+
+func (r *MongoLeadRepository) UpdateNote(ctx context.Context, leadID primitive.ObjectID, note model.Note) (*model.Lead, error) {
+	filter := bson.D{{Key: MongoFieldID, Value: leadID}, {Key: MongoFieldNoteID, Value: note.ID}}
+	set := bson.D{
+		{Key: MongoFieldNotes + ".$.text", Value: note.Text},
+		{Key: MongoFieldNotes + ".$.updatedAt", Value: note.UpdatedAt},
+	}
+	var unset bson.D
+	if note.Type != constants.NoteTypeNone {
+		set = append(set, bson.E{Key: MongoFieldNotes + ".$.type", Value: note.Type})
+	} else {
+		unset = append(unset, bson.E{Key: MongoFieldNotes + ".$.type", Value: ""})
+	}
+
+	update := bson.D{{Key: "$set", Value: set}}
+	if len(unset) > 0 {
+		update = append(update, bson.E{Key: "$unset", Value: unset})
+	}
+	return r.findNoteAndUpdate(ctx, leadID, note.ID, filter, update)
+}
+
+func (r *MongoLeadRepository) DeleteNote(ctx context.Context, leadID primitive.ObjectID, noteID primitive.ObjectID) (*model.Lead, error) {
+	filter := bson.D{{Key: MongoFieldID, Value: leadID}, {Key: MongoFieldNoteID, Value: noteID}}
+	update := bson.D{{Key: "$pull", Value: bson.D{{Key: MongoFieldNotes, Value: bson.D{{Key: "id", Value: noteID}}}}}}
+	return r.findNoteAndUpdate(ctx, leadID, noteID, filter, update)
+}
+
+func (r *MongoLeadRepository) AddMessageTemplateUsage(ctx context.Context, leadID primitive.ObjectID, usage *model.MessageTemplateUsage) (*model.Lead, error) {
+	filter := bson.D{{Key: MongoFieldID, Value: leadID}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: MongoFieldMessageTemplate, Value: usage}}},
+		{Key: "$push", Value: bson.D{{Key: MongoFieldTemplateHistory, Value: usage}}},
+	}
+	return r.findOneAndUpdate(ctx, leadID, filter, update)
+}
+
+// findNoteAndUpdate applies the update to a note of the lead, failing with
+// ErrNoteNotFound when the lead has no such note
+func (r *MongoLeadRepository) findNoteAndUpdate(ctx context.Context, leadID primitive.ObjectID, noteID primitive.ObjectID, filter bson.D, update bson.D) (*model.Lead, error) {
+	lead, err := r.findOneAndUpdate(ctx, leadID, filter, update)
+	if errors.Is(err, ErrLeadNotFound) {
+		// The filter also misses a lead without the note
+		if _, findErr := r.FindByID(ctx, leadID); findErr != nil {
+			return nil, findErr
+		}
+		return nil, fmt.Errorf("%w: %s", ErrNoteNotFound, noteID.Hex())
+	}
+	return lead, err
+}
+
+// FindByIDs returns the leads with the IDs that exist, including trashed ones, in no particular order
+func (r *MongoLeadRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]model.Lead, error) {
+	cursor, err := r.col.Find(ctx, bson.D{{Key: MongoFieldID, Value: bson.D{{Key: "$in", Value: ids}}}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find leads: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var leads []model.Lead
+	if err := cursor.All(ctx, &leads); err != nil {
+		return nil, fmt.Errorf("failed to decode leads: %w", err)
+	}
+	return leads, nil
+}
+
+// UpdateBatch applies the changes to their leads in a single bulk write
+func (r *MongoLeadRepository) UpdateBatch(ctx context.Context, updates []dto.LeadBatchUpdate) ([]primitive.ObjectID, error) {
+	batchID := primitive.NewObjectID()
+	models := make([]mongo.WriteModel, 0, len(updates))
+	ids := make([]primitive.ObjectID, 0, len(updates))
+	for _, batchUpdate := range updates {
+		filter := bson.D{
+			{Key: MongoFieldID, Value: batchUpdate.ID},
+			{Key: MongoFieldDeletedAt, Value: bson.D{{Key: "$exists", Value: false}}},
+		}
+		set := bson.D{}
+		if batchUpdate.ExpectedStatus != "" {
+			filter = append(filter, bson.E{Key: MongoFieldConnectionStatus, Value: batchUpdate.ExpectedStatus})
+		}
+		if batchUpdate.ConnectionStatus != "" {
+			set = append(set, bson.E{Key: MongoFieldConnectionStatus, Value: batchUpdate.ConnectionStatus})
+		}
+		if batchUpdate.FollowupSent != nil {
+			filter = append(filter, bson.E{Key: MongoFieldFollowupSent, Value: bson.D{{Key: "$ne", Value: *batchUpdate.FollowupSent}}})
+			set = append(set, bson.E{Key: MongoFieldFollowupSent, Value: *batchUpdate.FollowupSent})
+		}
+		if batchUpdate.FollowupDueAt != nil {
+			set = append(set, bson.E{Key: MongoFieldFollowupDueAt, Value: batchUpdate.FollowupDueAt})
+		}
+		if batchUpdate.LeadTemperature != "" {
+			filter = append(filter, bson.E{Key: MongoFieldLeadTemp, Value: batchUpdate.ExpectedTemperature})
+			set = append(set, bson.E{Key: MongoFieldLeadTemp, Value: batchUpdate.LeadTemperature})
+		}
+
+		update := bson.D{}
+		// The override only exists while a temperature is set by hand
+		if override := batchUpdate.TemperatureOverride; override != nil && *override != "" {
+			set = append(set, bson.E{Key: MongoFieldTempOverride, Value: *override})
+		} else if override != nil {
+			update = append(update, bson.E{Key: "$unset", Value: bson.D{{Key: MongoFieldTempOverride, Value: ""}}})
+		}
+		if batchUpdate.AddTag != "" {
+			update = append(update, bson.E{Key: "$addToSet", Value: bson.D{{Key: MongoFieldTags, Value: batchUpdate.AddTag}}})
+		}
+		if len(batchUpdate.StatusChanges) > 0 {
+			update = append(update, bson.E{Key: "$push", Value: bson.D{{
+				Key:   MongoFieldStatusHistory,
+				Value: bson.D{{Key: "$each", Value: batchUpdate.StatusChanges}},
+			}}})
+		}
+		if len(set) == 0 && len(update) == 0 {
+			continue
+		}
+		set = append(set, bson.E{Key: MongoFieldBatchID, Value: batchID})
+		update = append(update, bson.E{Key: "$set", Value: set}, incrementVersion)
+
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update))
+		ids = append(ids, batchUpdate.ID)
+	}
+	if len(models) == 0 {
+		return nil, nil
+	}
+
+	result, err := r.col.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update leads: %w", err)
+	}
+	return r.batchResult(ctx, ids, batchID, result.MatchedCount)
+}
+
+// UpdateScores stores the scores of the leads in a single bulk write
+func (r *MongoLeadRepository) UpdateScores(ctx context.Context, scores []*dto.LeadScoreProperties) error {
+	if len(scores) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(scores))
+	for _, scoreProperties := range scores {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(scoreFilter(scoreProperties)).
+			SetUpdate(scoreUpdate(scoreProperties)))
+	}
+
+	if _, err := r.col.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("failed to update scores: %w", err)
+	}
+	return nil
+}
+
+// SoftDeleteBatch moves the active leads among the IDs to the trash
+func (r *MongoLeadRepository) SoftDeleteBatch(ctx context.Context, ids []primitive.ObjectID, deletedAt time.Time) ([]primitive.ObjectID, error) {
+	batchID := primitive.NewObjectID()
+	filter := bson.D{
+		{Key: MongoFieldID, Value: bson.D{{Key: "$in", Value: ids}}},
+		{Key: MongoFieldDeletedAt, Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: MongoFieldDeletedAt, Value: deletedAt},
+			{Key: MongoFieldBatchID, Value: batchID},
+		}},
+		incrementVersion,
+	}
+	result, err := r.col.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete leads: %w", err)
+	}
+	return r.batchResult(ctx, ids, batchID, result.MatchedCount)
+}
+
+// batchResult returns the IDs among ids of the leads the batch write changed
+// and removes its mark from them. Only when its filters left some leads
+// unchanged are the changed ones read back.
+func (r *MongoLeadRepository) batchResult(ctx context.Context, ids []primitive.ObjectID, batchID primitive.ObjectID, matched int64) ([]primitive.ObjectID, error) {
+	changed := ids
+	if matched != int64(len(ids)) {
+		var err error
+		if changed, err = r.findBatch(ctx, ids, batchID); err != nil {
+			return nil, err
+		}
+	}
+	if len(changed) == 0 {
+		return changed, nil
+	}
+
+	// The leads were changed already, a mark left behind is only logged
+	filter := bson.D{
+		{Key: MongoFieldID, Value: bson.D{{Key: "$in", Value: changed}}},
+		{Key: MongoFieldBatchID, Value: batchID},
+	}
+	update := bson.D{{Key: "$unset", Value: bson.D{{Key: MongoFieldBatchID, Value: ""}}}}
+	if _, err := r.col.UpdateMany(ctx, filter, update); err != nil {
+		log.Printf("[WARNING] failed to remove the mark of batch %s: %s", batchID.Hex(), err)
+	}
+	return changed, nil
+}
+
+// findBatch returns the IDs among ids of the leads the batch write changed
+func (r *MongoLeadRepository) findBatch(ctx context.Context, ids []primitive.ObjectID, batchID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	filter := bson.D{
+		{Key: MongoFieldID, Value: bson.D{{Key: "$in", Value: ids}}},
+		{Key: MongoFieldBatchID, Value: batchID},
+	}
+	cursor, err := r.col.Find(ctx, filter, options.Find().SetProjection(bson.D{{Key: MongoFieldID, Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find changed leads: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var changed []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &changed); err != nil {
+		return nil, fmt.Errorf("failed to decode changed leads: %w", err)
+	}
+
+	changedIDs := make([]primitive.ObjectID, len(changed))
+	for i, lead := range changed {
+		changedIDs[i] = lead.ID
+	}
+	return changedIDs, nil
+}
+
+// UnassignCampaign removes the campaign from every lead, including trashed ones
+func (r *MongoLeadRepository) UnassignCampaign(ctx context.Context, campaignID primitive.ObjectID) error {
+	filter := bson.D{{Key: MongoFieldCampaignID, Value: campaignID}}
+	update := bson.D{{Key: "$unset", Value: bson.D{{Key: MongoFieldCampaignID, Value: ""}}}, incrementVersion}
+	if _, err := r.col.UpdateMany(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to unassign campaign %s: %w", campaignID.Hex(), err)
+	}
+	return nil
+}
+
 func (r *MongoLeadRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
 	filter := bson.D{{Key: MongoFieldID, Value: id}}
-	_, err := r.col.DeleteOne(ctx, filter)
+	result, err := r.col.DeleteOne(ctx, filter)
 	if err != nil {
 		return fmt.Errorf("failed to delete lead: %w", err)
 	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrLeadNotFound, id.Hex())
+	}
 	return nil
 }
 
+// DeleteTrashed removes a lead in the trash permanently
+func (r *MongoLeadRepository) DeleteTrashed(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.D{{Key: MongoFieldID, Value: id}, {Key: MongoFieldDeletedAt, Value: bson.D{{Key: "$exists", Value: true}}}}
+	result, err := r.col.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete lead: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrLeadNotFound, id.Hex())
+	}
+	return nil
+}
+
+// SoftDelete moves an active lead to the trash
+func (r *MongoLeadRepository) SoftDelete(ctx context.Context, id primitive.ObjectID, deletedAt time.Time) (*model.Lead, error) {
+	filter := bson.D{{Key: MongoFieldID, Value: id}, {Key: MongoFieldDeletedAt, Value: bson.D{{Key: "$exists", Value: false}}}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: MongoFieldDeletedAt, Value: deletedAt}}}}
+	return r.findOneAndUpdate(ctx, id, filter, update)
+}
+
+// Restore moves a lead out of the trash
+func (r *MongoLeadRepository) Restore(ctx context.Context, id primitive.ObjectID) (*model.Lead, error) {
+	filter := bson.D{{Key: MongoFieldID, Value: id}, {Key: MongoFieldDeletedAt, Value: bson.D{{Key: "$exists", Value: true}}}}
+	update := bson.D{{Key: "$unset", Value: bson.D{{Key: MongoFieldDeletedAt, Value: ""}}}}
+	return r.findOneAndUpdate(ctx, id, filter, update)
+}
+
+// AdvanceSequence replaces the sequence progress of a lead whose sequence is still at fromStep
+func (r *MongoLeadRepository) AdvanceSequence(ctx context.Context, id primitive.ObjectID, fromStep int, progress *model.SequenceProgress, change model.StatusChange) (*model.Lead, error) {
+	filter := bson.D{{Key: MongoFieldID, Value: id}, {Key: MongoFieldSequenceNextStep, Value: fromStep}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: MongoFieldSequence, Value: progress}}},
+		{Key: "$push", Value: bson.D{{Key: MongoFieldStatusHistory, Value: change}}},
+	}
+	return r.findOneAndUpdate(ctx, id, filter, update)
+}
+
+// findOneAndUpdate applies the update to the lead matching filter, counting it as a new version of the lead
+func (r *MongoLeadRepository) findOneAndUpdate(ctx context.Context, id primitive.ObjectID, filter bson.D, update bson.D) (*model.Lead, error) {
+	update = append(update, incrementVersion)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updatedLead model.Lead
+	err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updatedLead)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", ErrLeadNotFound, id.Hex())
+		}
+		return nil, fmt.Errorf("failed to update lead: %w", err)
+	}
+	return &updatedLead, nil
+}
+
+// ListTrashedBefore returns the leads moved to the trash before the given time
+func (r *MongoLeadRepository) ListTrashedBefore(ctx context.Context, before time.Time) ([]model.Lead, error) {
+	cursor, err := r.col.Find(ctx, bson.D{{Key: MongoFieldDeletedAt, Value: bson.D{{Key: "$lt", Value: before}}}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trashed leads: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var leads []model.Lead
+	if err := cursor.All(ctx, &leads); err != nil {
+		return nil, fmt.Errorf("failed to decode trashed leads: %w", err)
+	}
+	return leads, nil
+}
+
+// ListActive returns every lead that is not in the trash
+func (r *MongoLeadRepository) ListActive(ctx context.Context) ([]model.Lead, error) {
+	cursor, err := r.col.Find(ctx, bson.D{{Key: MongoFieldDeletedAt, Value: bson.D{{Key: "$exists", Value: false}}}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list leads: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var leads []model.Lead
+	if err := cursor.All(ctx, &leads); err != nil {
+		return nil, fmt.Errorf("failed to decode leads: %w", err)
+	}
+	return leads, nil
+}
+
 func (r *MongoLeadRepository) ListPaged(ctx context.Context, filter *dto.LeadFilter) ([]model.Lead, int, error) {
 	var leads []model.Lead
 
 	// Build query filters
-	query := r.buildFilters(filter)
+	query := r.buildFilters(filter, false)
 
 	// Count total matching documents (with filters applied)
 	totalCount, err := r.col.CountDocuments(ctx, query)
@@ -98,6 +685,18 @@ func (r *MongoLeadRepository) ListPaged(ctx context.Context, filter *dto.LeadFil
 		return nil, 0, fmt.Errorf("failed to count leads: %w", err)
 	}
 
+	// The text index only finds whole words and their stems, so a search
+	// finding nothing is run again for words starting with the terms, which
+	// finds "Jane" for "Jan" like the memory repository does
+	partialSearch := false
+	if totalCount == 0 && len(filter.SearchTerms()) > 0 {
+		partialSearch = true
+		query = r.buildFilters(filter, true)
+		if totalCount, err = r.col.CountDocuments(ctx, query); err != nil {
+			return nil, 0, fmt.Errorf("failed to count leads: %w", err)
+		}
+	}
+
 	// Calculate pagination values
 	totalPages := int(math.Ceil(float64(totalCount) / float64(filter.LeadsPerPage)))
 	if totalPages == 0 {
@@ -106,11 +705,25 @@ func (r *MongoLeadRepository) ListPaged(ctx context.Context, filter *dto.LeadFil
 
 	skip := (filter.Page - 1) * filter.LeadsPerPage
 
+	// The trash lists the most recently deleted leads first, follow-ups the
+	// earliest due ones, search results are ranked by relevance and the
+	// highest scored leads come first otherwise, the newest among equal ones
+	sort := bson.D{{Key: MongoFieldScore, Value: -1}, {Key: MongoFieldDate, Value: -1}}
+	if filter.Trashed {
+		sort = bson.D{{Key: MongoFieldDeletedAt, Value: -1}}
+	} else if filter.Followup != constants.FollowupStateNone {
+		// Follow-ups are listed in the order they fell due
+		sort = bson.D{{Key: MongoFieldFollowupDueAt, Value: 1}}
+	} else if len(filter.SearchTerms()) > 0 && !partialSearch {
+		// Only a text search has a relevance, partial matches are sorted by score
+		sort = append(bson.D{{Key: "relevance", Value: bson.D{{Key: "$meta", Value: "textScore"}}}}, sort...)
+	}
+
 	// Set up find options with pagination and sorting
 	opts := options.Find().
 		SetSkip(int64(skip)).
 		SetLimit(int64(filter.LeadsPerPage)).
-		SetSort(bson.D{{Key: MongoFieldDate, Value: -1}})
+		SetSort(sort)
 
 	// Execute query with filters and options
 	cursor, err := r.col.Find(ctx, query, opts)
@@ -126,18 +739,306 @@ func (r *MongoLeadRepository) ListPaged(ctx context.Context, filter *dto.LeadFil
 	return leads, totalPages, nil
 }
 
+func (r *MongoLeadRepository) ListStatusesInUse(ctx context.Context, outreachType constants.OutreachType) ([]constants.ConnectionStatus, error) {
+	values, err := r.col.Distinct(ctx, MongoFieldConnectionStatus, bson.D{{Key: MongoFieldOutreachType, Value: outreachType}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lead statuses: %w", err)
+	}
+
+	statuses := make([]constants.ConnectionStatus, 0, len(values))
+	for _, value := range values {
+		if status, ok := value.(string); ok {
+			statuses = append(statuses, constants.ConnectionStatus(status))
+		}
+	}
+	return statuses, nil
+}
+
+// ListCustomFieldTextsInUse returns the distinct text values of the custom field, including the ones of trashed leads
+func (r *MongoLeadRepository) ListCustomFieldTextsInUse(ctx context.Context, key string) ([]string, error) {
+	values, err := r.col.Distinct(ctx, MongoFieldCustomFields+"."+key+".text", bson.D{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list values of custom field %s: %w", key, err)
+	}
+
+	texts := make([]string, 0, len(values))
+	for _, value := range values {
+		if text, ok := value.(string); ok {
+			texts = append(texts, text)
+		}
+	}
+	return texts, nil
+}
+
+// AggregateStats recomputes the stats counters by grouping all leads by the day they were added and their outreach type
+func (r *MongoLeadRepository) AggregateStats(ctx context.Context) (*model.StatsSnapshot, error) {
+	pipeline := mongo.Pipeline{
+		// Leads in the trash are not counted
+		{{Key: "$match", Value: bson.D{{Key: MongoFieldDeletedAt, Value: bson.D{{Key: "$exists", Value: false}}}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "day", Value: bson.D{{Key: "$dateToString", Value: bson.D{
+					{Key: "format", Value: "%Y-%m-%d"},
+					{Key: "date", Value: "$" + MongoFieldDate},
+					{Key: "timezone", Value: statsTimezone()},
+				}}}},
+				{Key: "outreachType", Value: "$" + MongoFieldOutreachType},
+			}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}
+
+	cursor, err := r.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate lead stats: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		ID struct {
+			Day          string                 `bson:"day"`
+			OutreachType constants.OutreachType `bson:"outreachType"`
+		} `bson:"_id"`
+		Count int `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, fmt.Errorf("failed to decode lead stats: %w", err)
+	}
+
+	accumulator := newStatsAccumulator()
+	for _, group := range groups {
+		accumulator.add(group.ID.Day, group.ID.OutreachType, group.Count)
+	}
+	return accumulator.snapshot(), nil
+}
+
+// customFieldFilter matches the custom field value the way the filter describes, see dto.CustomFieldFilter
+func customFieldFilter(fieldFilter dto.CustomFieldFilter) bson.D {
+	field := MongoFieldCustomFields + "." + fieldFilter.Field.Key
+	switch fieldFilter.Field.Type {
+	case constants.CustomFieldTypeNumber:
+		var bounds bson.D
+		if fieldFilter.Min != nil {
+			bounds = append(bounds, bson.E{Key: "$gte", Value: *fieldFilter.Min})
+		}
+		if fieldFilter.Max != nil {
+			bounds = append(bounds, bson.E{Key: "$lte", Value: *fieldFilter.Max})
+		}
+		return bson.D{{Key: field + ".number", Value: bounds}}
+	case constants.CustomFieldTypeDate:
+		var bounds bson.D
+		if fieldFilter.From != nil {
+			bounds = append(bounds, bson.E{Key: "$gte", Value: *fieldFilter.From})
+		}
+		if fieldFilter.To != nil {
+			bounds = append(bounds, bson.E{Key: "$lt", Value: fieldFilter.To.AddDate(0, 0, 1)})
+		}
+		return bson.D{{Key: field + ".date", Value: bounds}}
+	case constants.CustomFieldTypeSelect:
+		return bson.D{{Key: field + ".text", Value: fieldFilter.Text}}
+	default:
+		return bson.D{{Key: field + ".text", Value: primitive.Regex{Pattern: regexp.QuoteMeta(fieldFilter.Text), Options: "i"}}}
+	}
+}
+
+// CountTags returns how many leads outside the trash carry each tag, the most used tags first
+func (r *MongoLeadRepository) CountTags(ctx context.Context) ([]model.TagCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: MongoFieldDeletedAt, Value: bson.D{{Key: "$exists", Value: false}}},
+			{Key: MongoFieldTags, Value: bson.D{{Key: "$exists", Value: true}}},
+		}}},
+		{{Key: "$unwind", Value: "$" + MongoFieldTags}},
+		{{Key: "$group", Value: bson.D{
+			{Key: MongoFieldID, Value: "$" + MongoFieldTags},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: MongoFieldID, Value: 1}}}},
+	}
+
+	cursor, err := r.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tags: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var counts []model.TagCount
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, fmt.Errorf("failed to decode tag counts: %w", err)
+	}
+	return counts, nil
+}
+
+// CountByCompany returns how many leads outside the trash are linked to each company
+// CountByMessageTemplate returns how many leads outside the trash were last sent a message with each template
+func (r *MongoLeadRepository) CountByMessageTemplate(ctx context.Context) (map[primitive.ObjectID]int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: MongoFieldDeletedAt, Value: bson.D{{Key: "$exists", Value: false}}},
+			{Key: MongoFieldTemplateID, Value: bson.D{{Key: "$exists", Value: true}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: MongoFieldID, Value: "$" + MongoFieldTemplateID},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}
+
+	cursor, err := r.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count template leads: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		TemplateID primitive.ObjectID `bson:"_id"`
+		Count      int                `bson:"count"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode template lead counts: %w", err)
+	}
+
+	counts := make(map[primitive.ObjectID]int, len(results))
+	for _, result := range results {
+		counts[result.TemplateID] = result.Count
+	}
+	return counts, nil
+}
+
+// CountByCampaignStage groups the leads of campaigns by campaign, outreach
+// type, stage and whether their history has a move to the accepted and the
+// responded stage
+func (r *MongoLeadRepository) CountByCampaignStage(ctx context.Context) ([]model.CampaignStageCount, error) {
+	movedTo := func(stage constants.ConnectionStatus) bson.D {
+		return bson.D{{Key: "$anyElementTrue", Value: bson.A{bson.D{{Key: "$map", Value: bson.D{
+			{Key: "input", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$" + MongoFieldStatusHistory, bson.A{}}}}},
+			{Key: "in", Value: bson.D{{Key: "$and", Value: bson.A{
+				bson.D{{Key: "$eq", Value: bson.A{"$$this.field", constants.LeadFieldConnectionStatus}}},
+				bson.D{{Key: "$eq", Value: bson.A{"$$this.newValue", stage}}},
+			}}}},
+		}}}}}}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: MongoFieldDeletedAt, Value: bson.D{{Key: "$exists", Value: false}}},
+			{Key: MongoFieldCampaignID, Value: bson.D{{Key: "$exists", Value: true}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: MongoFieldID, Value: bson.D{
+				{Key: "campaignId", Value: "$" + MongoFieldCampaignID},
+				{Key: "outreachType", Value: "$" + MongoFieldOutreachType},
+				{Key: "connectionStatus", Value: "$" + MongoFieldConnectionStatus},
+				{Key: "movedToAccepted", Value: movedTo(constants.ConnectionStatusAccepted)},
+				{Key: "movedToResponded", Value: movedTo(constants.ConnectionStatusResponded)},
+			}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}
+
+	cursor, err := r.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count campaign leads: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Group struct {
+			CampaignID       primitive.ObjectID         `bson:"campaignId"`
+			OutreachType     constants.OutreachType     `bson:"outreachType"`
+			ConnectionStatus constants.ConnectionStatus `bson:"connectionStatus"`
+			MovedToAccepted  bool                       `bson:"movedToAccepted"`
+			MovedToResponded bool                       `bson:"movedToResponded"`
+		} `bson:"_id"`
+		Count int `bson:"count"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode campaign lead counts: %w", err)
+	}
+
+	counts := make([]model.CampaignStageCount, len(results))
+	for i, result := range results {
+		counts[i] = model.CampaignStageCount{
+			CampaignID:       result.Group.CampaignID,
+			OutreachType:     result.Group.OutreachType,
+			ConnectionStatus: result.Group.ConnectionStatus,
+			MovedToAccepted:  result.Group.MovedToAccepted,
+			MovedToResponded: result.Group.MovedToResponded,
+			Count:            result.Count,
+		}
+	}
+	return counts, nil
+}
+
+func (r *MongoLeadRepository) CountByCompany(ctx context.Context) (map[primitive.ObjectID]int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: MongoFieldDeletedAt, Value: bson.D{{Key: "$exists", Value: false}}},
+			{Key: MongoFieldCompanyID, Value: bson.D{{Key: "$exists", Value: true}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: MongoFieldID, Value: "$" + MongoFieldCompanyID},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}
+
+	cursor, err := r.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count company leads: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		CompanyID primitive.ObjectID `bson:"_id"`
+		Count     int                `bson:"count"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode company lead counts: %w", err)
+	}
+
+	counts := make(map[primitive.ObjectID]int, len(results))
+	for _, result := range results {
+		counts[result.CompanyID] = result.Count
+	}
+	return counts, nil
+}
+
+// partialSearchFilter matches the leads with a word starting with one of the
+// terms in a searched field. The terms are letters and digits only, they are
+// quoted all the same so the pattern never depends on that.
+func partialSearchFilter(terms []string) bson.D {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	word := primitive.Regex{
+		Pattern: `(^|[^\p{L}\p{N}])(` + strings.Join(quoted, "|") + ")",
+		Options: "i",
+	}
+
+	fields := bson.A{}
+	for _, field := range []string{MongoFieldName, MongoFieldURL, MongoFieldNotes + ".text"} {
+		fields = append(fields, bson.D{{Key: field, Value: word}})
+	}
+	return bson.D{{Key: "$or", Value: fields}}
+}
+
 // buildFilters constructs the MongoDB query filter based on the provided LeadFilter
-func (r *MongoLeadRepository) buildFilters(filter *dto.LeadFilter) bson.D {
-	filters := bson.A{}
+func (r *MongoLeadRepository) buildFilters(filter *dto.LeadFilter, partialSearch bool) bson.D {
+	// Active and trashed leads are always listed separately
+	filters := bson.A{bson.D{{
+		Key:   MongoFieldDeletedAt,
+		Value: bson.D{{Key: "$exists", Value: filter.Trashed}},
+	}}}
 
-	// Add name search filter if provided
-	if filter.SearchQuery != "" {
+	// Add full-text search filter if provided, or the partial word search
+	// ListPaged falls back to. Only the plain words of the query are passed
+	// on, so quotes and dashes can't turn into phrase or negation operators
+	// of $text.
+	if terms := filter.SearchTerms(); len(terms) > 0 && partialSearch {
+		filters = append(filters, partialSearchFilter(terms))
+	} else if len(terms) > 0 {
 		filters = append(filters, bson.D{{
-			Key: MongoFieldName,
-			Value: primitive.Regex{
-				Pattern: filter.SearchQuery,
-				Options: "i",
-			},
+			Key:   "$text",
+			Value: bson.D{{Key: "$search", Value: strings.Join(terms, " ")}},
 		}})
 	}
 
@@ -150,6 +1051,54 @@ func (r *MongoLeadRepository) buildFilters(filter *dto.LeadFilter) bson.D {
 		filters = append(filters, outreachFilter)
 	}
 
+	// Add pipeline stage filter if provided
+	if filter.ConnectionStatus != "" {
+		filters = append(filters, bson.D{{
+			Key:   MongoFieldConnectionStatus,
+			Value: filter.ConnectionStatus,
+		}})
+	}
+
+	// Add follow-up filter if provided, sent follow-ups are never due
+	if filter.Followup != constants.FollowupStateNone {
+		dueFrom, dueUntil := followupDueRange(filter.Followup, time.Now())
+		dueAt := bson.D{{Key: "$lt", Value: dueUntil}}
+		if !dueFrom.IsZero() {
+			dueAt = append(dueAt, bson.E{Key: "$gte", Value: dueFrom})
+		}
+		filters = append(filters, bson.D{
+			{Key: MongoFieldFollowupSent, Value: false},
+			{Key: MongoFieldFollowupDueAt, Value: dueAt},
+		})
+	}
+
+	// Add tag filter if provided
+	if len(filter.Tags) > 0 {
+		operator := "$in"
+		if filter.TagMatch == constants.TagMatchAll {
+			operator = "$all"
+		}
+		filters = append(filters, bson.D{{
+			Key:   MongoFieldTags,
+			Value: bson.D{{Key: operator, Value: filter.Tags}},
+		}})
+	}
+
+	// Add custom field filters if provided
+	for _, fieldFilter := range filter.CustomFields {
+		filters = append(filters, customFieldFilter(fieldFilter))
+	}
+
+	// Add company filter if provided
+	if filter.CompanyID != nil {
+		filters = append(filters, bson.D{{Key: MongoFieldCompanyID, Value: filter.CompanyID}})
+	}
+
+	// Add campaign filter if provided
+	if filter.CampaignID != nil {
+		filters = append(filters, bson.D{{Key: MongoFieldCampaignID, Value: filter.CampaignID}})
+	}
+
 	// Add lead temperature filter if provided
 	if filter.LeadTemperature != "" {
 		tempFilter := bson.D{{
@@ -179,12 +1128,18 @@ func (r *MongoLeadRepository) buildFilters(filter *dto.LeadFilter) bson.D {
 		}})
 	}
 
-	finalFilter := bson.D{}
-	if len(filters) > 0 {
-		finalFilter = bson.D{{
-			Key:   "$and",
-			Value: filters,
-		}}
+	return bson.D{{
+		Key:   "$and",
+		Value: filters,
+	}}
+}
+
+// followupDueRange returns the range of due dates of a follow-up state as of
+// now. Overdue follow-ups have no lower bound, so from is zero for them.
+func followupDueRange(state constants.FollowupState, now time.Time) (from time.Time, until time.Time) {
+	today := model.StartOfDay(now)
+	if state == constants.FollowupStateOverdue {
+		return time.Time{}, today
 	}
-	return finalFilter
+	return today, today.AddDate(0, 0, 1)
 }
@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"leadgentracker/internals/migration"
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/model/dto"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TestLeadRepositoryListPaged runs the same listings against every lead
+// repository, so the memory repository keeps behaving like the Mongo one.
+// The Mongo repository is tested when MONGO_TEST_URI points to a server, in
+// a database of its own that is dropped afterwards.
+func TestLeadRepositoryListPaged(t *testing.T) {
+	industry := model.CustomField{Key: "industry", Label: "Industry", Type: constants.CustomFieldTypeText}
+	employees := model.CustomField{Key: "employees", Label: "Employees", Type: constants.CustomFieldTypeNumber}
+	hundred := 100.0
+
+	tests := []struct {
+		name      string
+		filter    dto.LeadFilter
+		wantNames []string
+		wantPages int
+	}{
+		{
+			name:      "highest score first, newest among equal ones",
+			filter:    dto.LeadFilter{},
+			wantNames: []string{"Alice Jones", "Jane Doe", "John Smith"},
+		},
+		{
+			name:      "second page",
+			filter:    dto.LeadFilter{Page: 2, LeadsPerPage: 2},
+			wantNames: []string{"John Smith"},
+			wantPages: 2,
+		},
+		{
+			name:      "search by name",
+			filter:    dto.LeadFilter{SearchQuery: "jane"},
+			wantNames: []string{"Jane Doe"},
+		},
+		{
+			name:      "search by word start",
+			filter:    dto.LeadFilter{SearchQuery: "Jan"},
+			wantNames: []string{"Jane Doe"},
+		},
+		{
+			name:      "search by notes",
+			filter:    dto.LeadFilter{SearchQuery: "pricing"},
+			wantNames: []string{"Alice Jones"},
+		},
+		{
+			name:      "search leaves out trashed leads",
+			filter:    dto.LeadFilter{SearchQuery: "trash"},
+			wantNames: []string{},
+		},
+		{
+			name:      "any of the tags",
+			filter:    dto.LeadFilter{Tags: []string{"saas", "founder"}, TagMatch: constants.TagMatchAny},
+			wantNames: []string{"Alice Jones", "Jane Doe", "John Smith"},
+		},
+		{
+			name:      "all of the tags",
+			filter:    dto.LeadFilter{Tags: []string{"saas", "founder"}, TagMatch: constants.TagMatchAll},
+			wantNames: []string{"Jane Doe"},
+		},
+		{
+			name:      "text custom field contains the text in any case",
+			filter:    dto.LeadFilter{CustomFields: []dto.CustomFieldFilter{{Field: industry, Text: "SOFT"}}},
+			wantNames: []string{"Jane Doe"},
+		},
+		{
+			name:      "number custom field from the minimum",
+			filter:    dto.LeadFilter{CustomFields: []dto.CustomFieldFilter{{Field: employees, Min: &hundred}}},
+			wantNames: []string{"John Smith"},
+		},
+		{
+			name:      "number custom field up to the maximum",
+			filter:    dto.LeadFilter{CustomFields: []dto.CustomFieldFilter{{Field: employees, Max: &hundred}}},
+			wantNames: []string{"Jane Doe"},
+		},
+		{
+			name:      "trash, the most recently deleted first",
+			filter:    dto.LeadFilter{Trashed: true},
+			wantNames: []string{"Bob Trash", "Carol Trash"},
+		},
+		{
+			name:      "trash with a tag",
+			filter:    dto.LeadFilter{Trashed: true, Tags: []string{"founder"}, TagMatch: constants.TagMatchAny},
+			wantNames: []string{"Bob Trash"},
+		},
+	}
+
+	for name, newRepository := range leadRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepository(t)
+			seedLeads(t, repo)
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					filter := tt.filter
+					if filter.Page == 0 {
+						filter.Page, filter.LeadsPerPage = 1, dto.MaxLeadsPerPage
+					}
+
+					leads, pages, err := repo.ListPaged(context.Background(), &filter)
+					if err != nil {
+						t.Fatalf("ListPaged() error = %v", err)
+					}
+
+					names := make([]string, 0, len(leads))
+					for _, lead := range leads {
+						names = append(names, lead.Name)
+					}
+					if !slices.Equal(names, tt.wantNames) {
+						t.Errorf("ListPaged() names = %v, want %v", names, tt.wantNames)
+					}
+
+					wantPages := tt.wantPages
+					if wantPages == 0 {
+						wantPages = 1
+					}
+					if pages != wantPages {
+						t.Errorf("ListPaged() pages = %d, want %d", pages, wantPages)
+					}
+				})
+			}
+		})
+	}
+}
+
+// TestLeadRepositorySoftDelete checks that every lead repository moves a lead
+// to the trash once and restores it to the active leads
+func TestLeadRepositorySoftDelete(t *testing.T) {
+	for name, newRepository := range leadRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepository(t)
+			seedLeads(t, repo)
+
+			lead := findLeadByName(t, repo, "Jane Doe")
+			deletedAt := time.Now().Truncate(time.Millisecond)
+
+			trashed, err := repo.SoftDelete(ctx, lead.ID, deletedAt)
+			if err != nil {
+				t.Fatalf("SoftDelete() error = %v", err)
+			}
+			if trashed.DeletedAt == nil || !trashed.DeletedAt.Equal(deletedAt) {
+				t.Errorf("SoftDelete() deletedAt = %v, want %v", trashed.DeletedAt, deletedAt)
+			}
+			if _, err := repo.SoftDelete(ctx, lead.ID, deletedAt); err == nil {
+				t.Error("SoftDelete() of a trashed lead succeeded, want ErrLeadNotFound")
+			}
+
+			active, err := repo.ListActive(ctx)
+			if err != nil {
+				t.Fatalf("ListActive() error = %v", err)
+			}
+			if slices.ContainsFunc(active, func(l model.Lead) bool { return l.ID == lead.ID }) {
+				t.Error("ListActive() lists the trashed lead")
+			}
+
+			restored, err := repo.Restore(ctx, lead.ID)
+			if err != nil {
+				t.Fatalf("Restore() error = %v", err)
+			}
+			if restored.DeletedAt != nil {
+				t.Errorf("Restore() deletedAt = %v, want nil", restored.DeletedAt)
+			}
+
+			if err := repo.Delete(ctx, lead.ID); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if err := repo.Delete(ctx, lead.ID); err == nil {
+				t.Error("Delete() of a removed lead succeeded, want ErrLeadNotFound")
+			}
+		})
+	}
+}
+
+// leadRepositories returns a constructor of an empty repository by backend
+func leadRepositories(t *testing.T) map[string]func(t *testing.T) LeadRepository {
+	return map[string]func(t *testing.T) LeadRepository{
+		"memory": func(t *testing.T) LeadRepository {
+			return NewMemoryLeadRepository(NewMemoryStore())
+		},
+		"mongo": newTestMongoLeadRepository,
+	}
+}
+
+// newTestMongoLeadRepository migrates a new database on the server of
+// MONGO_TEST_URI and skips the test when there is none
+func newTestMongoLeadRepository(t *testing.T) LeadRepository {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Skipf("failed to connect to MongoDB: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Skipf("failed to reach MongoDB: %v", err)
+	}
+
+	dbName := "leadgentracker_test_" + primitive.NewObjectID().Hex()
+	t.Setenv("MONGO_DB", dbName)
+	db := client.Database(dbName)
+	t.Cleanup(func() {
+		ctx := context.Background()
+		if err := db.Drop(ctx); err != nil {
+			t.Logf("failed to drop test database %s: %v", dbName, err)
+		}
+		_ = client.Disconnect(ctx)
+	})
+
+	if _, err := migration.NewRunner(db, migration.Migrations).Up(ctx); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return NewLeadRepository(client)
+}
+
+// seedLeads stores three active leads and two trashed ones
+func seedLeads(t *testing.T, repo LeadRepository) {
+	t.Helper()
+
+	today := model.StartOfDay(time.Now()).UTC()
+	daysAgo := func(days int) *time.Time {
+		date := today.AddDate(0, 0, -days)
+		return &date
+	}
+	number := func(value float64) model.CustomFieldValue {
+		return model.CustomFieldValue{Number: &value}
+	}
+	note := func(text string) model.Notes {
+		return model.Notes{{ID: primitive.NewObjectID(), Text: text, CreatedAt: today}}
+	}
+
+	leads := []model.Lead{
+		{
+			Name:  "Jane Doe",
+			Date:  *daysAgo(1),
+			Score: 10,
+			Tags:  []string{"founder", "saas"},
+			Notes: note("Met at the conference"),
+			CustomFields: map[string]model.CustomFieldValue{
+				"industry":  {Text: "Software"},
+				"employees": number(50),
+			},
+		},
+		{
+			Name:  "John Smith",
+			Date:  *daysAgo(2),
+			Score: 10,
+			Tags:  []string{"founder"},
+			CustomFields: map[string]model.CustomFieldValue{
+				"industry":  {Text: "Retail"},
+				"employees": number(500),
+			},
+		},
+		{
+			Name:  "Alice Jones",
+			Date:  *daysAgo(3),
+			Score: 30,
+			Tags:  []string{"saas"},
+			Notes: note("Asked about pricing"),
+		},
+		{
+			Name:      "Bob Trash",
+			Date:      *daysAgo(4),
+			Score:     50,
+			Tags:      []string{"founder"},
+			DeletedAt: daysAgo(0),
+		},
+		{
+			Name:      "Carol Trash",
+			Date:      *daysAgo(5),
+			DeletedAt: daysAgo(1),
+		},
+	}
+
+	for _, lead := range leads {
+		lead.ID = primitive.NewObjectID()
+		lead.OutreachType = constants.OutreachTypeConnection
+		lead.URL = "https://www.linkedin.com/in/" + lead.ID.Hex()
+		lead.NormalizedURL = model.NormalizeProfileURL(lead.URL)
+		if err := repo.Create(context.Background(), &lead); err != nil {
+			t.Fatalf("failed to seed lead %s: %v", lead.Name, err)
+		}
+	}
+}
+
+func findLeadByName(t *testing.T, repo LeadRepository, name string) model.Lead {
+	t.Helper()
+
+	leads, err := repo.ListActive(context.Background())
+	if err != nil {
+		t.Fatalf("ListActive() error = %v", err)
+	}
+	for _, lead := range leads {
+		if lead.Name == name {
+			return lead
+		}
+	}
+	t.Fatalf("no lead named %s", name)
+	return model.Lead{}
+}
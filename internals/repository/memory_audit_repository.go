@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/dto"
+)
+
+type MemoryAuditRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryAuditRepository(store *MemoryStore) *MemoryAuditRepository {
+	return &MemoryAuditRepository{store: store}
+}
+
+func (r *MemoryAuditRepository) Create(ctx context.Context, entry *model.AuditEntry) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if err := r.store.persist(collectionAudit, entry.ID.Hex(), entry); err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+	r.store.audit[entry.ID] = *entry
+	return nil
+}
+
+// ListPaged returns the matching entries newest first
+func (r *MemoryAuditRepository) ListPaged(ctx context.Context, filter *dto.AuditFilter) ([]model.AuditEntry, int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	entries := []model.AuditEntry{}
+	for _, entry := range r.store.audit {
+		if (filter.Actor == "" || entry.Actor == filter.Actor) &&
+			(filter.Action == "" || entry.Action == filter.Action) &&
+			(filter.EntityType == "" || entry.EntityType == filter.EntityType) &&
+			(filter.EntityID == "" || entry.EntityID == filter.EntityID) {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Timestamp.After(entries[j].Timestamp)
+	})
+
+	totalPages := int(math.Ceil(float64(len(entries)) / float64(filter.EntriesPerPage)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return paginate(entries, filter.Page, filter.EntriesPerPage), totalPages, nil
+}
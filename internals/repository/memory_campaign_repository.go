@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"leadgentracker/internals/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryCampaignRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryCampaignRepository(store *MemoryStore) *MemoryCampaignRepository {
+	return &MemoryCampaignRepository{store: store}
+}
+
+func (r *MemoryCampaignRepository) Create(ctx context.Context, campaign *model.Campaign) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.campaigns[campaign.ID]; exists {
+		return fmt.Errorf("failed to create campaign: duplicate ID %s", campaign.ID.Hex())
+	}
+	if err := r.store.persist(collectionCampaigns, campaign.ID.Hex(), campaign); err != nil {
+		return fmt.Errorf("failed to create campaign: %w", err)
+	}
+	r.store.campaigns[campaign.ID] = *campaign
+	return nil
+}
+
+func (r *MemoryCampaignRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Campaign, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	campaign, exists := r.store.campaigns[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrCampaignNotFound, id.Hex())
+	}
+	return &campaign, nil
+}
+
+// List returns the campaigns, the newest first
+func (r *MemoryCampaignRepository) List(ctx context.Context) ([]model.Campaign, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	campaigns := make([]model.Campaign, 0, len(r.store.campaigns))
+	for _, campaign := range r.store.campaigns {
+		campaigns = append(campaigns, campaign)
+	}
+	// Object IDs start with their creation time, same as sorting by _id in Mongo
+	sort.Slice(campaigns, func(i, j int) bool {
+		return campaigns[i].ID.Hex() > campaigns[j].ID.Hex()
+	})
+	return campaigns, nil
+}
+
+func (r *MemoryCampaignRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.campaigns[id]; !exists {
+		return nil
+	}
+	if err := r.store.persistDelete(collectionCampaigns, id.Hex()); err != nil {
+		return fmt.Errorf("failed to delete campaign: %w", err)
+	}
+	delete(r.store.campaigns, id)
+	return nil
+}
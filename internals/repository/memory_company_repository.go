@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"leadgentracker/internals/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryCompanyRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryCompanyRepository(store *MemoryStore) *MemoryCompanyRepository {
+	return &MemoryCompanyRepository{store: store}
+}
+
+func (r *MemoryCompanyRepository) Create(ctx context.Context, company *model.Company) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.companies[company.ID]; exists {
+		return fmt.Errorf("failed to create company: duplicate ID %s", company.ID.Hex())
+	}
+	return r.put(company)
+}
+
+func (r *MemoryCompanyRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Company, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	company, exists := r.store.companies[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrCompanyNotFound, id.Hex())
+	}
+	return &company, nil
+}
+
+// List returns the companies ordered by name
+func (r *MemoryCompanyRepository) List(ctx context.Context) ([]model.Company, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	companies := make([]model.Company, 0, len(r.store.companies))
+	for _, company := range r.store.companies {
+		companies = append(companies, company)
+	}
+	sort.Slice(companies, func(i, j int) bool {
+		if companies[i].Name != companies[j].Name {
+			return companies[i].Name < companies[j].Name
+		}
+		return companies[i].ID.Hex() < companies[j].ID.Hex()
+	})
+	return companies, nil
+}
+
+func (r *MemoryCompanyRepository) Update(ctx context.Context, company *model.Company) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.companies[company.ID]; !exists {
+		return fmt.Errorf("%w: %s", ErrCompanyNotFound, company.ID.Hex())
+	}
+	return r.put(company)
+}
+
+func (r *MemoryCompanyRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.companies[id]; !exists {
+		return nil
+	}
+	if err := r.store.persistDelete(collectionCompanies, id.Hex()); err != nil {
+		return fmt.Errorf("failed to delete company: %w", err)
+	}
+	delete(r.store.companies, id)
+	return nil
+}
+
+func (r *MemoryCompanyRepository) put(company *model.Company) error {
+	if err := r.store.persist(collectionCompanies, company.ID.Hex(), company); err != nil {
+		return fmt.Errorf("failed to save company: %w", err)
+	}
+	r.store.companies[company.ID] = *company
+	return nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"leadgentracker/internals/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryCustomFieldRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryCustomFieldRepository(store *MemoryStore) *MemoryCustomFieldRepository {
+	return &MemoryCustomFieldRepository{store: store}
+}
+
+func (r *MemoryCustomFieldRepository) Create(ctx context.Context, field *model.CustomField) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.fields[field.ID]; exists {
+		return fmt.Errorf("failed to create custom field: duplicate ID %s", field.ID.Hex())
+	}
+	return r.put(field)
+}
+
+func (r *MemoryCustomFieldRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.CustomField, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	field, exists := r.store.fields[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrCustomFieldNotFound, id.Hex())
+	}
+	return cloneCustomField(field), nil
+}
+
+// List returns the custom fields in the order they were created
+func (r *MemoryCustomFieldRepository) List(ctx context.Context) ([]model.CustomField, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	fields := make([]model.CustomField, 0, len(r.store.fields))
+	for _, field := range r.store.fields {
+		fields = append(fields, *cloneCustomField(field))
+	}
+	// Object IDs start with their creation time, same as sorting by _id in Mongo
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].ID.Hex() < fields[j].ID.Hex()
+	})
+	return fields, nil
+}
+
+func (r *MemoryCustomFieldRepository) Update(ctx context.Context, field *model.CustomField) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.fields[field.ID]; !exists {
+		return fmt.Errorf("%w: %s", ErrCustomFieldNotFound, field.ID.Hex())
+	}
+	return r.put(field)
+}
+
+func (r *MemoryCustomFieldRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.fields[id]; !exists {
+		return nil
+	}
+	if err := r.store.persistDelete(collectionFields, id.Hex()); err != nil {
+		return fmt.Errorf("failed to delete custom field: %w", err)
+	}
+	delete(r.store.fields, id)
+	return nil
+}
+
+func (r *MemoryCustomFieldRepository) put(field *model.CustomField) error {
+	stored := cloneCustomField(*field)
+	if err := r.store.persist(collectionFields, field.ID.Hex(), stored); err != nil {
+		return fmt.Errorf("failed to save custom field: %w", err)
+	}
+	r.store.fields[field.ID] = *stored
+	return nil
+}
+
+func cloneCustomField(field model.CustomField) *model.CustomField {
+	field.Options = slices.Clone(field.Options)
+	return &field
+}
@@ -0,0 +1,860 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/model/dto"
+	"leadgentracker/internals/search"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryLeadRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryLeadRepository(store *MemoryStore) *MemoryLeadRepository {
+	return &MemoryLeadRepository{store: store}
+}
+
+func (r *MemoryLeadRepository) Create(ctx context.Context, lead *model.Lead) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.leads[lead.ID]; exists {
+		return fmt.Errorf("failed to create lead: duplicate ID %s", lead.ID.Hex())
+	}
+	if lead.NormalizedURL != "" && r.findByNormalizedURL(lead.NormalizedURL) != nil {
+		return fmt.Errorf("%w: %s", ErrDuplicateProfileURL, lead.NormalizedURL)
+	}
+	if err := r.store.persist(collectionLeads, lead.ID.Hex(), lead); err != nil {
+		return fmt.Errorf("failed to create lead: %w", err)
+	}
+	r.store.leads[lead.ID] = *lead
+	return nil
+}
+
+// CreateBatch stores the leads, leaving out those whose profile URL is taken
+func (r *MemoryLeadRepository) CreateBatch(ctx context.Context, leads []*model.Lead) ([]primitive.ObjectID, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	ids := make([]primitive.ObjectID, 0, len(leads))
+	for _, lead := range leads {
+		if _, exists := r.store.leads[lead.ID]; exists {
+			return ids, fmt.Errorf("failed to create leads: duplicate ID %s", lead.ID.Hex())
+		}
+		if lead.NormalizedURL != "" && r.findByNormalizedURL(lead.NormalizedURL) != nil {
+			continue
+		}
+		if err := r.store.persist(collectionLeads, lead.ID.Hex(), lead); err != nil {
+			return ids, fmt.Errorf("failed to create leads: %w", err)
+		}
+		r.store.leads[lead.ID] = *lead
+		ids = append(ids, lead.ID)
+	}
+	return ids, nil
+}
+
+func (r *MemoryLeadRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Lead, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	lead, exists := r.store.leads[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrLeadNotFound, id.Hex())
+	}
+	return &lead, nil
+}
+
+func (r *MemoryLeadRepository) FindByNormalizedURL(ctx context.Context, normalizedURL string) (*model.Lead, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	lead := r.findByNormalizedURL(normalizedURL)
+	if lead == nil {
+		return nil, fmt.Errorf("%w: %s", ErrLeadNotFound, normalizedURL)
+	}
+	return lead, nil
+}
+
+// FindByNormalizedURLs returns the leads holding the normalized profile URLs, including trashed ones
+func (r *MemoryLeadRepository) FindByNormalizedURLs(ctx context.Context, normalizedURLs []string) ([]model.Lead, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var leads []model.Lead
+	for _, normalizedURL := range normalizedURLs {
+		if lead := r.findByNormalizedURL(normalizedURL); lead != nil {
+			leads = append(leads, *lead)
+		}
+	}
+	return leads, nil
+}
+
+// findByNormalizedURL must be called with the store lock held. Leads stored
+// before profile URLs were normalized are matched by their URL, the oldest
+// one stands in for its duplicates the same way migration 4 picks it.
+func (r *MemoryLeadRepository) findByNormalizedURL(normalizedURL string) *model.Lead {
+	var found *model.Lead
+	for _, lead := range r.store.leads {
+		key := lead.NormalizedURL
+		if key == "" && lead.DuplicateOf == nil {
+			key = model.NormalizeProfileURL(lead.URL)
+		}
+		if key == normalizedURL && (found == nil || lead.Date.Before(found.Date)) {
+			found = &lead
+		}
+	}
+	return found
+}
+
+func (r *MemoryLeadRepository) Update(ctx context.Context, updateProperties *dto.UpdateLeadProperties) (*model.Lead, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// Same as the Mongo repository, the lead must be active and at the version the update was made from
+	lead, exists := r.store.leads[updateProperties.ID]
+	if !exists || lead.IsTrashed() || lead.Version != updateProperties.Version {
+		return nil, fmt.Errorf("%w: %s", ErrLeadNotFound, updateProperties.ID.Hex())
+	}
+
+	lead.ConnectionStatus = updateProperties.ConnectionStatus
+	lead.FollowupSent = updateProperties.FollowupSent
+	lead.FollowupDueAt = updateProperties.FollowupDueAt
+	lead.Tags = slices.Clone(updateProperties.Tags)
+	lead.CustomFields = maps.Clone(updateProperties.CustomFields)
+	lead.CompanyID = updateProperties.CompanyID
+	lead.CampaignID = updateProperties.CampaignID
+	// Copy the history, earlier returned leads must not see the new entries
+	lead.StatusHistory = slices.Concat(lead.StatusHistory, updateProperties.StatusChanges)
+	lead.Version++
+	if err := r.store.persist(collectionLeads, lead.ID.Hex(), lead); err != nil {
+		return nil, fmt.Errorf("failed to update lead: %w", err)
+	}
+	r.store.leads[lead.ID] = lead
+
+	return &lead, nil
+}
+
+// FindByIDs returns the leads with the IDs that exist, including trashed ones, in no particular order
+func (r *MemoryLeadRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]model.Lead, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var leads []model.Lead
+	for _, id := range ids {
+		if lead, exists := r.store.leads[id]; exists {
+			leads = append(leads, lead)
+		}
+	}
+	return leads, nil
+}
+
+// UpdateBatch applies the changes to their leads, missing leads are skipped like by the Mongo repository
+func (r *MemoryLeadRepository) UpdateBatch(ctx context.Context, updates []dto.LeadBatchUpdate) ([]primitive.ObjectID, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var changed []primitive.ObjectID
+	for _, batchUpdate := range updates {
+		lead, exists := r.store.leads[batchUpdate.ID]
+		if !exists || lead.IsTrashed() {
+			continue
+		}
+		if batchUpdate.ExpectedStatus != "" && lead.ConnectionStatus != batchUpdate.ExpectedStatus {
+			continue
+		}
+		if batchUpdate.FollowupSent != nil && lead.FollowupSent == *batchUpdate.FollowupSent {
+			continue
+		}
+		if batchUpdate.LeadTemperature != "" && lead.LeadTemperature != batchUpdate.ExpectedTemperature {
+			continue
+		}
+
+		if batchUpdate.ConnectionStatus != "" {
+			lead.ConnectionStatus = batchUpdate.ConnectionStatus
+		}
+		if batchUpdate.FollowupSent != nil {
+			lead.FollowupSent = *batchUpdate.FollowupSent
+		}
+		if batchUpdate.FollowupDueAt != nil {
+			lead.FollowupDueAt = batchUpdate.FollowupDueAt
+		}
+		if batchUpdate.TemperatureOverride != nil {
+			lead.TemperatureOverride = *batchUpdate.TemperatureOverride
+		}
+		if batchUpdate.LeadTemperature != "" {
+			lead.LeadTemperature = batchUpdate.LeadTemperature
+		}
+		if batchUpdate.AddTag != "" && !slices.Contains(lead.Tags, batchUpdate.AddTag) {
+			// Copy the tags, earlier returned leads must not see the new one
+			lead.Tags = slices.Concat(lead.Tags, []string{batchUpdate.AddTag})
+		}
+		lead.StatusHistory = slices.Concat(lead.StatusHistory, batchUpdate.StatusChanges)
+		lead.Version++
+		if err := r.store.persist(collectionLeads, lead.ID.Hex(), lead); err != nil {
+			return changed, fmt.Errorf("failed to update lead: %w", err)
+		}
+		r.store.leads[lead.ID] = lead
+		changed = append(changed, lead.ID)
+	}
+	return changed, nil
+}
+
+// UpdateScores stores the scores of the leads, missing and changed leads are skipped like by the Mongo repository
+func (r *MemoryLeadRepository) UpdateScores(ctx context.Context, scores []*dto.LeadScoreProperties) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, scoreProperties := range scores {
+		lead, exists := r.store.leads[scoreProperties.ID]
+		if !exists || !ratedFrom(&lead, scoreProperties) {
+			continue
+		}
+
+		setScore(&lead, scoreProperties)
+		if err := r.store.persist(collectionLeads, lead.ID.Hex(), lead); err != nil {
+			return fmt.Errorf("failed to update lead: %w", err)
+		}
+		r.store.leads[lead.ID] = lead
+	}
+	return nil
+}
+
+// UnsetCustomField removes the values of the custom field from every lead
+func (r *MemoryLeadRepository) UnsetCustomField(ctx context.Context, key string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, lead := range r.store.leads {
+		if _, exists := lead.CustomFields[key]; !exists {
+			continue
+		}
+		// Copy the values, earlier returned leads must not see the change
+		lead.CustomFields = maps.Clone(lead.CustomFields)
+		delete(lead.CustomFields, key)
+		lead.Version++
+		if err := r.store.persist(collectionLeads, id.Hex(), lead); err != nil {
+			return fmt.Errorf("failed to unset custom field %s: %w", key, err)
+		}
+		r.store.leads[id] = lead
+	}
+	return nil
+}
+
+// UnlinkCompany removes the link to the company from every lead, including trashed ones
+func (r *MemoryLeadRepository) UnlinkCompany(ctx context.Context, companyID primitive.ObjectID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, lead := range r.store.leads {
+		if lead.CompanyID == nil || *lead.CompanyID != companyID {
+			continue
+		}
+		lead.CompanyID = nil
+		lead.Version++
+		if err := r.store.persist(collectionLeads, id.Hex(), lead); err != nil {
+			return fmt.Errorf("failed to unlink company %s: %w", companyID.Hex(), err)
+		}
+		r.store.leads[id] = lead
+	}
+	return nil
+}
+
+// UnassignCampaign removes the campaign from every lead, including trashed ones
+func (r *MemoryLeadRepository) UnassignCampaign(ctx context.Context, campaignID primitive.ObjectID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, lead := range r.store.leads {
+		if lead.CampaignID == nil || *lead.CampaignID != campaignID {
+			continue
+		}
+		lead.CampaignID = nil
+		lead.Version++
+		if err := r.store.persist(collectionLeads, id.Hex(), lead); err != nil {
+			return fmt.Errorf("failed to unassign campaign %s: %w", campaignID.Hex(), err)
+		}
+		r.store.leads[id] = lead
+	}
+	return nil
+}
+
+// UpdateScore stores the score of the lead version it was rated from
+func (r *MemoryLeadRepository) UpdateScore(ctx context.Context, scoreProperties *dto.LeadScoreProperties) (*model.Lead, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	lead, exists := r.store.leads[scoreProperties.ID]
+	if !exists || !ratedFrom(&lead, scoreProperties) {
+		return nil, fmt.Errorf("%w: %s", ErrLeadNotFound, scoreProperties.ID.Hex())
+	}
+
+	setScore(&lead, scoreProperties)
+	if err := r.store.persist(collectionLeads, lead.ID.Hex(), lead); err != nil {
+		return nil, fmt.Errorf("failed to update lead: %w", err)
+	}
+	r.store.leads[lead.ID] = lead
+
+	return &lead, nil
+}
+
+// ratedFrom tells whether the score was rated from the lead as it is stored, like the Mongo scoreFilter
+func ratedFrom(lead *model.Lead, scoreProperties *dto.LeadScoreProperties) bool {
+	if lead.Version != scoreProperties.Version {
+		return false
+	}
+	change := scoreProperties.TemperatureChange
+	return change == nil || string(lead.LeadTemperature) == change.OldValue
+}
+
+// setScore sets the score of the lead and records a temperature change in its status history
+func setScore(lead *model.Lead, scoreProperties *dto.LeadScoreProperties) {
+	lead.Score = scoreProperties.Score
+	lead.ScoreBreakdown = scoreProperties.Breakdown
+	lead.LeadTemperature = scoreProperties.LeadTemperature
+	if change := scoreProperties.TemperatureChange; change != nil {
+		// Copy the history, earlier returned leads must not see the change
+		lead.StatusHistory = slices.Concat(lead.StatusHistory, []model.StatusChange{*change})
+	}
+}
+
+func (r *MemoryLeadRepository) AddNote(ctx context.Context, leadID primitive.ObjectID, note model.Note) (*model.Lead, error) {
+	return r.updateNotes(leadID, func(notes model.Notes) (model.Notes, error) {
+		return append(notes, note), nil
+	})
+}
+
+func (r *MemoryLeadRepository) UpdateNote(ctx context.Context, leadID primitive.ObjectID, note model.Note) (*model.Lead, error) {
+	return r.updateNotes(leadID, func(notes model.Notes) (model.Notes, error) {
+		current := notes.Find(note.ID)
+		if current == nil {
+			return nil, fmt.Errorf("%w: %s", ErrNoteNotFound, note.ID.Hex())
+		}
+		current.Type = note.Type
+		current.Text = note.Text
+		current.UpdatedAt = note.UpdatedAt
+		return notes, nil
+	})
+}
+
+func (r *MemoryLeadRepository) DeleteNote(ctx context.Context, leadID primitive.ObjectID, noteID primitive.ObjectID) (*model.Lead, error) {
+	return r.updateNotes(leadID, func(notes model.Notes) (model.Notes, error) {
+		if notes.Find(noteID) == nil {
+			return nil, fmt.Errorf("%w: %s", ErrNoteNotFound, noteID.Hex())
+		}
+		return slices.DeleteFunc(notes, func(note model.Note) bool { return note.ID == noteID }), nil
+	})
+}
+
+func (r *MemoryLeadRepository) AddMessageTemplateUsage(ctx context.Context, leadID primitive.ObjectID, usage *model.MessageTemplateUsage) (*model.Lead, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	lead, exists := r.store.leads[leadID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrLeadNotFound, leadID.Hex())
+	}
+
+	lead.MessageTemplate = usage
+	// Copy the history, earlier returned leads must not see the new entry
+	lead.MessageTemplateHistory = slices.Concat(lead.MessageTemplateHistory, []model.MessageTemplateUsage{*usage})
+	lead.Version++
+	if err := r.store.persist(collectionLeads, lead.ID.Hex(), lead); err != nil {
+		return nil, fmt.Errorf("failed to update lead: %w", err)
+	}
+	r.store.leads[lead.ID] = lead
+
+	return &lead, nil
+}
+
+// updateNotes replaces the notes of the lead with the ones change makes of a copy of them
+func (r *MemoryLeadRepository) updateNotes(leadID primitive.ObjectID, change func(model.Notes) (model.Notes, error)) (*model.Lead, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	lead, exists := r.store.leads[leadID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrLeadNotFound, leadID.Hex())
+	}
+
+	notes, err := change(slices.Clone(lead.Notes))
+	if err != nil {
+		return nil, err
+	}
+	lead.Notes = notes
+	lead.Version++
+	if err := r.store.persist(collectionLeads, lead.ID.Hex(), lead); err != nil {
+		return nil, fmt.Errorf("failed to update lead: %w", err)
+	}
+	r.store.leads[lead.ID] = lead
+
+	return &lead, nil
+}
+
+// UpdateProfile overwrites the profile details of a lead, empty properties keep their current value
+func (r *MemoryLeadRepository) UpdateProfile(ctx context.Context, profileProperties *dto.LeadProfileProperties) (*model.Lead, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	lead, exists := r.store.leads[profileProperties.ID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrLeadNotFound, profileProperties.ID.Hex())
+	}
+
+	if profileProperties.ProfileType != "" {
+		lead.ProfileType = profileProperties.ProfileType
+	}
+	if profileProperties.Name != "" {
+		lead.Name = profileProperties.Name
+	}
+	if profileProperties.PictureUrl != "" {
+		lead.PictureUrl = profileProperties.PictureUrl
+	}
+	if profileProperties.CampaignID != nil {
+		lead.CampaignID = profileProperties.CampaignID
+	}
+	lead.Version++
+	if err := r.store.persist(collectionLeads, lead.ID.Hex(), lead); err != nil {
+		return nil, fmt.Errorf("failed to update lead: %w", err)
+	}
+	r.store.leads[lead.ID] = lead
+
+	return &lead, nil
+}
+
+func (r *MemoryLeadRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.leads[id]; !exists {
+		return fmt.Errorf("%w: %s", ErrLeadNotFound, id.Hex())
+	}
+	if err := r.store.persistDelete(collectionLeads, id.Hex()); err != nil {
+		return fmt.Errorf("failed to delete lead: %w", err)
+	}
+	delete(r.store.leads, id)
+	return nil
+}
+
+// DeleteTrashed removes a lead in the trash permanently
+func (r *MemoryLeadRepository) DeleteTrashed(ctx context.Context, id primitive.ObjectID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	lead, exists := r.store.leads[id]
+	if !exists || !lead.IsTrashed() {
+		return fmt.Errorf("%w: %s", ErrLeadNotFound, id.Hex())
+	}
+	if err := r.store.persistDelete(collectionLeads, id.Hex()); err != nil {
+		return fmt.Errorf("failed to delete lead: %w", err)
+	}
+	delete(r.store.leads, id)
+	return nil
+}
+
+// SoftDelete moves an active lead to the trash
+func (r *MemoryLeadRepository) SoftDelete(ctx context.Context, id primitive.ObjectID, deletedAt time.Time) (*model.Lead, error) {
+	return r.updateTrashState(id, false, &deletedAt)
+}
+
+// SoftDeleteBatch moves the active leads among the IDs to the trash
+func (r *MemoryLeadRepository) SoftDeleteBatch(ctx context.Context, ids []primitive.ObjectID, deletedAt time.Time) ([]primitive.ObjectID, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var trashed []primitive.ObjectID
+	for _, id := range ids {
+		lead, exists := r.store.leads[id]
+		if !exists || lead.IsTrashed() {
+			continue
+		}
+
+		lead.DeletedAt = &deletedAt
+		lead.Version++
+		if err := r.store.persist(collectionLeads, lead.ID.Hex(), lead); err != nil {
+			return trashed, fmt.Errorf("failed to delete lead: %w", err)
+		}
+		r.store.leads[lead.ID] = lead
+		trashed = append(trashed, lead.ID)
+	}
+	return trashed, nil
+}
+
+// Restore moves a lead out of the trash
+func (r *MemoryLeadRepository) Restore(ctx context.Context, id primitive.ObjectID) (*model.Lead, error) {
+	return r.updateTrashState(id, true, nil)
+}
+
+// AdvanceSequence replaces the sequence progress of a lead whose sequence is still at fromStep
+func (r *MemoryLeadRepository) AdvanceSequence(ctx context.Context, id primitive.ObjectID, fromStep int, progress *model.SequenceProgress, change model.StatusChange) (*model.Lead, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	lead, exists := r.store.leads[id]
+	if !exists || lead.Sequence == nil || lead.Sequence.NextStep != fromStep {
+		return nil, fmt.Errorf("%w: %s", ErrLeadNotFound, id.Hex())
+	}
+
+	lead.Sequence = progress
+	lead.StatusHistory = slices.Concat(lead.StatusHistory, []model.StatusChange{change})
+	lead.Version++
+	if err := r.store.persist(collectionLeads, lead.ID.Hex(), lead); err != nil {
+		return nil, fmt.Errorf("failed to update lead: %w", err)
+	}
+	r.store.leads[lead.ID] = lead
+
+	return &lead, nil
+}
+
+func (r *MemoryLeadRepository) updateTrashState(id primitive.ObjectID, trashed bool, deletedAt *time.Time) (*model.Lead, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	lead, exists := r.store.leads[id]
+	if !exists || lead.IsTrashed() != trashed {
+		return nil, fmt.Errorf("%w: %s", ErrLeadNotFound, id.Hex())
+	}
+
+	lead.DeletedAt = deletedAt
+	lead.Version++
+	if err := r.store.persist(collectionLeads, lead.ID.Hex(), lead); err != nil {
+		return nil, fmt.Errorf("failed to update lead: %w", err)
+	}
+	r.store.leads[lead.ID] = lead
+
+	return &lead, nil
+}
+
+// ListTrashedBefore returns the leads moved to the trash before the given time
+func (r *MemoryLeadRepository) ListTrashedBefore(ctx context.Context, before time.Time) ([]model.Lead, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var leads []model.Lead
+	for _, lead := range r.store.leads {
+		if lead.IsTrashed() && lead.DeletedAt.Before(before) {
+			leads = append(leads, lead)
+		}
+	}
+	return leads, nil
+}
+
+// ListActive returns every lead that is not in the trash
+func (r *MemoryLeadRepository) ListActive(ctx context.Context) ([]model.Lead, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var leads []model.Lead
+	for _, lead := range r.store.leads {
+		if !lead.IsTrashed() {
+			leads = append(leads, lead)
+		}
+	}
+	return leads, nil
+}
+
+func (r *MemoryLeadRepository) ListPaged(ctx context.Context, filter *dto.LeadFilter) ([]model.Lead, int, error) {
+	match, err := newMemoryLeadMatcher(filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list leads: %w", err)
+	}
+
+	r.store.mu.RLock()
+	leads := make([]model.Lead, 0, len(r.store.leads))
+	for _, lead := range r.store.leads {
+		if match(&lead) {
+			leads = append(leads, lead)
+		}
+	}
+	r.store.mu.RUnlock()
+
+	// Calculate pagination values the same way as the Mongo repository
+	totalPages := int(math.Ceil(float64(len(leads)) / float64(filter.LeadsPerPage)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	var relevance map[primitive.ObjectID]int
+	if terms := filter.SearchTerms(); len(terms) > 0 {
+		relevance = make(map[primitive.ObjectID]int, len(leads))
+		for _, lead := range leads {
+			relevance[lead.ID] = searchScore(&lead, terms)
+		}
+	}
+
+	sort.SliceStable(leads, func(i, j int) bool {
+		// The trash lists the most recently deleted leads first
+		if filter.Trashed {
+			return leads[i].DeletedAt.After(*leads[j].DeletedAt)
+		}
+		// Follow-ups are listed in the order they fell due
+		if filter.Followup != constants.FollowupStateNone {
+			return leads[i].FollowupDueAt.Before(*leads[j].FollowupDueAt)
+		}
+		// Search results are ranked by relevance first
+		if relevance[leads[i].ID] != relevance[leads[j].ID] {
+			return relevance[leads[i].ID] > relevance[leads[j].ID]
+		}
+		// The highest scored leads come first, the newest among equal ones
+		if leads[i].Score != leads[j].Score {
+			return leads[i].Score > leads[j].Score
+		}
+		return leads[i].Date.After(leads[j].Date)
+	})
+
+	return paginate(leads, filter.Page, filter.LeadsPerPage), totalPages, nil
+}
+
+func (r *MemoryLeadRepository) ListStatusesInUse(ctx context.Context, outreachType constants.OutreachType) ([]constants.ConnectionStatus, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var statuses []constants.ConnectionStatus
+	for _, lead := range r.store.leads {
+		if lead.OutreachType == outreachType && !slices.Contains(statuses, lead.ConnectionStatus) {
+			statuses = append(statuses, lead.ConnectionStatus)
+		}
+	}
+	return statuses, nil
+}
+
+func (r *MemoryLeadRepository) ListCustomFieldTextsInUse(ctx context.Context, key string) ([]string, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var texts []string
+	for _, lead := range r.store.leads {
+		if value, exists := lead.CustomFields[key]; exists && value.Text != "" && !slices.Contains(texts, value.Text) {
+			texts = append(texts, value.Text)
+		}
+	}
+	return texts, nil
+}
+
+func (r *MemoryLeadRepository) AggregateStats(ctx context.Context) (*model.StatsSnapshot, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	accumulator := newStatsAccumulator()
+	for _, lead := range r.store.leads {
+		// Leads in the trash are not counted
+		if lead.IsTrashed() {
+			continue
+		}
+		accumulator.add(lead.Date.Format("2006-01-02"), lead.OutreachType, 1)
+	}
+	return accumulator.snapshot(), nil
+}
+
+// CountTags returns how many leads outside the trash carry each tag, the most used tags first
+func (r *MemoryLeadRepository) CountTags(ctx context.Context) ([]model.TagCount, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, lead := range r.store.leads {
+		if lead.IsTrashed() {
+			continue
+		}
+		for _, tag := range lead.Tags {
+			counts[tag]++
+		}
+	}
+
+	tagCounts := make([]model.TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tagCounts = append(tagCounts, model.TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(tagCounts, func(i, j int) bool {
+		if tagCounts[i].Count != tagCounts[j].Count {
+			return tagCounts[i].Count > tagCounts[j].Count
+		}
+		return tagCounts[i].Tag < tagCounts[j].Tag
+	})
+	return tagCounts, nil
+}
+
+// CountByMessageTemplate returns how many leads outside the trash were last sent a message with each template
+func (r *MemoryLeadRepository) CountByMessageTemplate(ctx context.Context) (map[primitive.ObjectID]int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	counts := make(map[primitive.ObjectID]int)
+	for _, lead := range r.store.leads {
+		if !lead.IsTrashed() && lead.MessageTemplate != nil {
+			counts[lead.MessageTemplate.TemplateID]++
+		}
+	}
+	return counts, nil
+}
+
+// CountByCampaignStage groups the leads of campaigns like the Mongo repository
+func (r *MemoryLeadRepository) CountByCampaignStage(ctx context.Context) ([]model.CampaignStageCount, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	indexes := make(map[model.CampaignStageCount]int)
+	var counts []model.CampaignStageCount
+	for _, lead := range r.store.leads {
+		if lead.IsTrashed() || lead.CampaignID == nil {
+			continue
+		}
+		group := model.CampaignStageCount{
+			CampaignID:       *lead.CampaignID,
+			OutreachType:     lead.OutreachType,
+			ConnectionStatus: lead.ConnectionStatus,
+			MovedToAccepted:  lead.MovedTo(constants.ConnectionStatusAccepted),
+			MovedToResponded: lead.MovedTo(constants.ConnectionStatusResponded),
+		}
+		index, exists := indexes[group]
+		if !exists {
+			index = len(counts)
+			indexes[group] = index
+			counts = append(counts, group)
+		}
+		counts[index].Count++
+	}
+	return counts, nil
+}
+
+// CountByCompany returns how many leads outside the trash are linked to each company
+func (r *MemoryLeadRepository) CountByCompany(ctx context.Context) (map[primitive.ObjectID]int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	counts := make(map[primitive.ObjectID]int)
+	for _, lead := range r.store.leads {
+		if !lead.IsTrashed() && lead.CompanyID != nil {
+			counts[*lead.CompanyID]++
+		}
+	}
+	return counts, nil
+}
+
+// newMemoryLeadMatcher mirrors buildFilters of the Mongo repository as a predicate
+func newMemoryLeadMatcher(filter *dto.LeadFilter) (func(lead *model.Lead) bool, error) {
+	terms := filter.SearchTerms()
+
+	var startOfDay, endOfDay time.Time
+	if !filter.DateAdded.IsZero() {
+		startOfDay = time.Date(
+			filter.DateAdded.Year(),
+			filter.DateAdded.Month(),
+			filter.DateAdded.Day(),
+			0, 0, 0, 0,
+			filter.DateAdded.Location(),
+		)
+		endOfDay = startOfDay.Add(24 * time.Hour)
+	}
+
+	now := time.Now()
+
+	return func(lead *model.Lead) bool {
+		// Active and trashed leads are always listed separately
+		if lead.IsTrashed() != filter.Trashed {
+			return false
+		}
+		if len(terms) > 0 && searchScore(lead, terms) == 0 {
+			return false
+		}
+		if filter.OutreachType != "" && lead.OutreachType != filter.OutreachType {
+			return false
+		}
+		if filter.ConnectionStatus != "" && lead.ConnectionStatus != filter.ConnectionStatus {
+			return false
+		}
+		if filter.Followup != constants.FollowupStateNone && lead.FollowupState(now) != filter.Followup {
+			return false
+		}
+		if len(filter.Tags) > 0 && !matchTags(lead, filter.Tags, filter.TagMatch) {
+			return false
+		}
+		for _, fieldFilter := range filter.CustomFields {
+			if !matchCustomField(lead, fieldFilter) {
+				return false
+			}
+		}
+		if filter.CompanyID != nil && (lead.CompanyID == nil || *lead.CompanyID != *filter.CompanyID) {
+			return false
+		}
+		if filter.CampaignID != nil && (lead.CampaignID == nil || *lead.CampaignID != *filter.CampaignID) {
+			return false
+		}
+		if filter.LeadTemperature != "" && lead.LeadTemperature != filter.LeadTemperature {
+			return false
+		}
+		if !startOfDay.IsZero() && (lead.Date.Before(startOfDay) || !lead.Date.Before(endOfDay)) {
+			return false
+		}
+		return true
+	}, nil
+}
+
+// matchTags tells whether the lead carries any or, with TagMatchAll, all of the tags
+func matchTags(lead *model.Lead, tags []string, tagMatch constants.TagMatch) bool {
+	for _, tag := range tags {
+		hasTag := slices.Contains(lead.Tags, tag)
+		if hasTag && tagMatch != constants.TagMatchAll {
+			return true
+		}
+		if !hasTag && tagMatch == constants.TagMatchAll {
+			return false
+		}
+	}
+	return tagMatch == constants.TagMatchAll
+}
+
+// matchCustomField tells whether the lead's custom field value matches the filter, see dto.CustomFieldFilter
+func matchCustomField(lead *model.Lead, fieldFilter dto.CustomFieldFilter) bool {
+	value, exists := lead.CustomFields[fieldFilter.Field.Key]
+	if !exists {
+		return false
+	}
+
+	switch fieldFilter.Field.Type {
+	case constants.CustomFieldTypeNumber:
+		return value.Number != nil &&
+			(fieldFilter.Min == nil || *value.Number >= *fieldFilter.Min) &&
+			(fieldFilter.Max == nil || *value.Number <= *fieldFilter.Max)
+	case constants.CustomFieldTypeDate:
+		return value.Date != nil &&
+			(fieldFilter.From == nil || !value.Date.Before(*fieldFilter.From)) &&
+			(fieldFilter.To == nil || value.Date.Before(fieldFilter.To.AddDate(0, 0, 1)))
+	case constants.CustomFieldTypeSelect:
+		return value.Text == fieldFilter.Text
+	default:
+		return strings.Contains(strings.ToLower(value.Text), strings.ToLower(fieldFilter.Text))
+	}
+}
+
+// searchScore approximates the text score of the Mongo text index, weighing
+// the matched words of every searched field the same way
+func searchScore(lead *model.Lead, terms []string) int {
+	return searchWeightName*search.Count(lead.Name, terms) +
+		searchWeightURL*search.Count(lead.URL, terms) +
+		searchWeightNotes*search.Count(lead.Notes.Text(), terms)
+}
+
+// paginate returns the slice of items for the given 1-based page
+func paginate[T any](items []T, page int, perPage int) []T {
+	skip := (page - 1) * perPage
+	if skip < 0 || skip >= len(items) {
+		return []T{}
+	}
+	end := skip + perPage
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[skip:end]
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"leadgentracker/internals/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryMessageTemplateRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryMessageTemplateRepository(store *MemoryStore) *MemoryMessageTemplateRepository {
+	return &MemoryMessageTemplateRepository{store: store}
+}
+
+func (r *MemoryMessageTemplateRepository) Create(ctx context.Context, messageTemplate *model.MessageTemplate) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.templates[messageTemplate.ID]; exists {
+		return fmt.Errorf("failed to create template: duplicate ID %s", messageTemplate.ID.Hex())
+	}
+	return r.put(messageTemplate)
+}
+
+func (r *MemoryMessageTemplateRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.MessageTemplate, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	messageTemplate, exists := r.store.templates[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrMessageTemplateNotFound, id.Hex())
+	}
+	return &messageTemplate, nil
+}
+
+// List returns the templates ordered by name
+func (r *MemoryMessageTemplateRepository) List(ctx context.Context) ([]model.MessageTemplate, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	templates := make([]model.MessageTemplate, 0, len(r.store.templates))
+	for _, messageTemplate := range r.store.templates {
+		templates = append(templates, messageTemplate)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Name != templates[j].Name {
+			return templates[i].Name < templates[j].Name
+		}
+		return templates[i].ID.Hex() < templates[j].ID.Hex()
+	})
+	return templates, nil
+}
+
+func (r *MemoryMessageTemplateRepository) Update(ctx context.Context, messageTemplate *model.MessageTemplate) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.templates[messageTemplate.ID]; !exists {
+		return fmt.Errorf("%w: %s", ErrMessageTemplateNotFound, messageTemplate.ID.Hex())
+	}
+	return r.put(messageTemplate)
+}
+
+func (r *MemoryMessageTemplateRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.templates[id]; !exists {
+		return nil
+	}
+	if err := r.store.persistDelete(collectionTemplates, id.Hex()); err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	delete(r.store.templates, id)
+	return nil
+}
+
+func (r *MemoryMessageTemplateRepository) put(messageTemplate *model.MessageTemplate) error {
+	if err := r.store.persist(collectionTemplates, messageTemplate.ID.Hex(), messageTemplate); err != nil {
+		return fmt.Errorf("failed to save template: %w", err)
+	}
+	r.store.templates[messageTemplate.ID] = *messageTemplate
+	return nil
+}
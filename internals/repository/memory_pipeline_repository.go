@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"leadgentracker/internals/model"
+)
+
+type MemoryPipelineRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryPipelineRepository(store *MemoryStore) *MemoryPipelineRepository {
+	return &MemoryPipelineRepository{store: store}
+}
+
+func (r *MemoryPipelineRepository) List(ctx context.Context) ([]model.Pipeline, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	pipelines := make([]model.Pipeline, 0, len(r.store.pipelines))
+	for _, pipeline := range r.store.pipelines {
+		// Copy the stages, callers must not modify the stored pipeline
+		pipeline.Stages = slices.Clone(pipeline.Stages)
+		pipelines = append(pipelines, pipeline)
+	}
+	return pipelines, nil
+}
+
+// Save creates or replaces the pipeline of its outreach type
+func (r *MemoryPipelineRepository) Save(ctx context.Context, pipeline *model.Pipeline) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored := *pipeline
+	stored.Stages = slices.Clone(pipeline.Stages)
+	if err := r.store.persist(collectionPipelines, string(pipeline.OutreachType), stored); err != nil {
+		return fmt.Errorf("failed to save pipeline: %w", err)
+	}
+	r.store.pipelines[pipeline.OutreachType] = stored
+	return nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"leadgentracker/internals/model"
+)
+
+type MemoryScoringRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryScoringRepository(store *MemoryStore) *MemoryScoringRepository {
+	return &MemoryScoringRepository{store: store}
+}
+
+// Get returns the saved scoring config, nil when none was saved yet
+func (r *MemoryScoringRepository) Get(ctx context.Context) (*model.ScoringConfig, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if r.store.scoring == nil {
+		return nil, nil
+	}
+	// The config holds maps and slices, hand out a copy that can't change the stored one
+	return cloneScoringConfig(r.store.scoring), nil
+}
+
+func (r *MemoryScoringRepository) Save(ctx context.Context, config *model.ScoringConfig) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored := cloneScoringConfig(config)
+	if err := r.store.persist(collectionScoring, scoringConfigKey, stored); err != nil {
+		return fmt.Errorf("failed to save scoring config: %w", err)
+	}
+	r.store.scoring = stored
+	return nil
+}
+
+func cloneScoringConfig(config *model.ScoringConfig) *model.ScoringConfig {
+	clone := *config
+	clone.ProfileTypes = maps.Clone(config.ProfileTypes)
+	clone.Stages = maps.Clone(config.Stages)
+	clone.Followups = maps.Clone(config.Followups)
+	clone.Keywords = slices.Clone(config.Keywords)
+	return &clone
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemorySequenceRepository struct {
+	store *MemoryStore
+}
+
+func NewMemorySequenceRepository(store *MemoryStore) *MemorySequenceRepository {
+	return &MemorySequenceRepository{store: store}
+}
+
+func (r *MemorySequenceRepository) Create(ctx context.Context, sequence *model.Sequence) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.sequences[sequence.ID]; exists {
+		return fmt.Errorf("failed to create sequence: duplicate ID %s", sequence.ID.Hex())
+	}
+	return r.put(sequence)
+}
+
+func (r *MemorySequenceRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Sequence, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	sequence, exists := r.store.sequences[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrSequenceNotFound, id.Hex())
+	}
+	return cloneSequence(sequence), nil
+}
+
+// FindDefault returns the default sequence of the outreach type
+func (r *MemorySequenceRepository) FindDefault(ctx context.Context, outreachType constants.OutreachType) (*model.Sequence, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, sequence := range r.store.sequences {
+		if sequence.Default && sequence.OutreachType == outreachType {
+			return cloneSequence(sequence), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: default %s", ErrSequenceNotFound, outreachType)
+}
+
+func (r *MemorySequenceRepository) List(ctx context.Context) ([]model.Sequence, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	sequences := make([]model.Sequence, 0, len(r.store.sequences))
+	for _, sequence := range r.store.sequences {
+		sequences = append(sequences, *cloneSequence(sequence))
+	}
+	// Sorted by name, same as the Mongo repository
+	sort.Slice(sequences, func(i, j int) bool {
+		return sequences[i].Name < sequences[j].Name
+	})
+	return sequences, nil
+}
+
+func (r *MemorySequenceRepository) Update(ctx context.Context, sequence *model.Sequence) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.sequences[sequence.ID]; !exists {
+		return fmt.Errorf("%w: %s", ErrSequenceNotFound, sequence.ID.Hex())
+	}
+	return r.put(sequence)
+}
+
+func (r *MemorySequenceRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.sequences[id]; !exists {
+		return nil
+	}
+	if err := r.store.persistDelete(collectionSequences, id.Hex()); err != nil {
+		return fmt.Errorf("failed to delete sequence: %w", err)
+	}
+	delete(r.store.sequences, id)
+	return nil
+}
+
+func (r *MemorySequenceRepository) put(sequence *model.Sequence) error {
+	stored := cloneSequence(*sequence)
+	if err := r.store.persist(collectionSequences, sequence.ID.Hex(), stored); err != nil {
+		return fmt.Errorf("failed to save sequence: %w", err)
+	}
+	r.store.sequences[sequence.ID] = *stored
+	return nil
+}
+
+func cloneSequence(sequence model.Sequence) *model.Sequence {
+	sequence.Steps = slices.Clone(sequence.Steps)
+	return &sequence
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+)
+
+type MemoryStatsRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryStatsRepository(store *MemoryStore) *MemoryStatsRepository {
+	return &MemoryStatsRepository{store: store}
+}
+
+// Update adjusts both the total and the given day's stats by delta based on outreachType
+func (r *MemoryStatsRepository) Update(ctx context.Context, outreachType constants.OutreachType, date time.Time, delta int) error {
+	var stats model.Stats
+	switch outreachType {
+	case constants.OutreachTypeConnection:
+		stats.Connections = delta
+	case constants.OutreachTypeInMail:
+		stats.InMails = delta
+	default:
+		return fmt.Errorf("unsupported outreach type: %v", outreachType)
+	}
+	return r.UpdateDay(ctx, date, stats)
+}
+
+// UpdateDay adjusts both the total and the given day's stats by the counts of delta
+func (r *MemoryStatsRepository) UpdateDay(ctx context.Context, date time.Time, delta model.Stats) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	day := date.Format("2006-01-02") // format as YYYY-MM-DD
+	total := r.store.totalStats
+	total.Connections += delta.Connections
+	total.InMails += delta.InMails
+	if err := r.store.persist(collectionStats, totalStatsKey, total); err != nil {
+		return fmt.Errorf("failed to update total stats: %w", err)
+	}
+	r.store.totalStats = total
+
+	// A decrement for a day that was never counted is dropped, same as the Mongo repository
+	daily, dayExists := r.store.dailyStats[day]
+	if !dayExists && delta.Connections <= 0 && delta.InMails <= 0 {
+		return nil
+	}
+	daily.Connections += delta.Connections
+	daily.InMails += delta.InMails
+	if err := r.store.persist(collectionDailyStats, day, daily); err != nil {
+		return fmt.Errorf("failed to update daily stats: %w", err)
+	}
+	r.store.dailyStats[day] = daily
+
+	return nil
+}
+
+func (r *MemoryStatsRepository) GetTotal(ctx context.Context) (*model.Stats, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	total := r.store.totalStats
+	return &total, nil
+}
+
+func (r *MemoryStatsRepository) GetForDate(ctx context.Context, date time.Time) (*model.Stats, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	// A missing entry yields zero-value Stats, same as the Mongo repository
+	daily := r.store.dailyStats[date.Format("2006-01-02")]
+	return &daily, nil
+}
+
+func (r *MemoryStatsRepository) GetSnapshot(ctx context.Context) (*model.StatsSnapshot, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	snapshot := &model.StatsSnapshot{Total: r.store.totalStats, Daily: make([]model.DailyStats, 0, len(r.store.dailyStats))}
+	for date, stats := range r.store.dailyStats {
+		snapshot.Daily = append(snapshot.Daily, model.DailyStats{Date: date, Connections: stats.Connections, InMails: stats.InMails})
+	}
+	sortDailyStats(snapshot.Daily)
+	return snapshot, nil
+}
+
+// Adjust adds the counters of delta to the stored ones
+func (r *MemoryStatsRepository) Adjust(ctx context.Context, delta *model.StatsSnapshot) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	total := r.store.totalStats
+	total.Connections += delta.Total.Connections
+	total.InMails += delta.Total.InMails
+	if err := r.store.persist(collectionStats, totalStatsKey, total); err != nil {
+		return fmt.Errorf("failed to adjust stats: %w", err)
+	}
+	r.store.totalStats = total
+
+	for _, day := range delta.Daily {
+		daily := r.store.dailyStats[day.Date]
+		daily.Connections += day.Connections
+		daily.InMails += day.InMails
+		if err := r.store.persist(collectionDailyStats, day.Date, daily); err != nil {
+			return fmt.Errorf("failed to adjust stats: %w", err)
+		}
+		r.store.dailyStats[day.Date] = daily
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	collectionLeads      = "leads"
+	collectionStats      = "stats"
+	collectionDailyStats = "dailyStats"
+	collectionAudit      = "audit"
+	collectionPipelines  = "pipelines"
+	collectionSequences  = "sequences"
+	collectionScoring    = "scoring"
+	collectionFields     = "customFields"
+	collectionCompanies  = "companies"
+	collectionCampaigns  = "campaigns"
+	collectionTemplates  = "messageTemplates"
+
+	totalStatsKey = "total"
+)
+
+// MemoryStore holds the documents of the in-memory storage backend. Every
+// repository created from the same store shares its data and its lock.
+// A store opened with OpenFileStore additionally persists every change.
+// The repositories behave like their Mongo counterparts: they list documents
+// in the same order, deleting a missing company, campaign, sequence, custom
+// field or template is not an error, and their put helpers store copies and
+// must be called with the lock held.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	leads      map[primitive.ObjectID]model.Lead
+	totalStats model.Stats
+	dailyStats map[string]model.Stats
+	audit      map[primitive.ObjectID]model.AuditEntry
+	pipelines  map[constants.OutreachType]model.Pipeline
+	sequences  map[primitive.ObjectID]model.Sequence
+	scoring    *model.ScoringConfig
+	fields     map[primitive.ObjectID]model.CustomField
+	companies  map[primitive.ObjectID]model.Company
+	campaigns  map[primitive.ObjectID]model.Campaign
+	templates  map[primitive.ObjectID]model.MessageTemplate
+	journal    *fileJournal
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		leads:      make(map[primitive.ObjectID]model.Lead),
+		dailyStats: make(map[string]model.Stats),
+		audit:      make(map[primitive.ObjectID]model.AuditEntry),
+		pipelines:  make(map[constants.OutreachType]model.Pipeline),
+		sequences:  make(map[primitive.ObjectID]model.Sequence),
+		fields:     make(map[primitive.ObjectID]model.CustomField),
+		companies:  make(map[primitive.ObjectID]model.Company),
+		campaigns:  make(map[primitive.ObjectID]model.Campaign),
+		templates:  make(map[primitive.ObjectID]model.MessageTemplate),
+	}
+}
+
+// Close flushes and closes the journal of a file-backed store
+func (s *MemoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.journal == nil {
+		return nil
+	}
+	if err := s.compact(); err != nil {
+		return err
+	}
+	return s.journal.close()
+}
+
+// persist records a new version of a document before it is applied in memory.
+// It must be called with mu held and is a no-op when the store has no journal.
+func (s *MemoryStore) persist(collection string, key string, doc any) error {
+	if s.journal == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s document %s: %w", collection, key, err)
+	}
+	return s.appendToJournal(journalEntry{Collection: collection, Key: key, Doc: raw})
+}
+
+// persistDelete records the removal of a document, see persist
+func (s *MemoryStore) persistDelete(collection string, key string) error {
+	if s.journal == nil {
+		return nil
+	}
+	return s.appendToJournal(journalEntry{Collection: collection, Key: key})
+}
+
+// apply loads a single journal entry into memory
+func (s *MemoryStore) apply(entry journalEntry) error {
+	switch entry.Collection {
+	case collectionLeads:
+		id, err := primitive.ObjectIDFromHex(entry.Key)
+		if err != nil {
+			return fmt.Errorf("invalid lead ID %s: %w", entry.Key, err)
+		}
+		if entry.Doc == nil {
+			delete(s.leads, id)
+			return nil
+		}
+		var lead model.Lead
+		if err := json.Unmarshal(entry.Doc, &lead); err != nil {
+			return fmt.Errorf("failed to decode lead %s: %w", entry.Key, err)
+		}
+		// Notes journaled as a single text carry no time, they date from when the lead was added
+		for i := range lead.Notes {
+			if lead.Notes[i].CreatedAt.IsZero() {
+				lead.Notes[i].CreatedAt = lead.Date
+			}
+		}
+		// Tags journaled before tags were lowercased are lowercased like migration 12 does
+		lead.Tags = model.NormalizeTags(lead.Tags)
+		s.leads[id] = lead
+	case collectionStats:
+		return json.Unmarshal(entry.Doc, &s.totalStats)
+	case collectionDailyStats:
+		var stats model.Stats
+		if err := json.Unmarshal(entry.Doc, &stats); err != nil {
+			return fmt.Errorf("failed to decode daily stats %s: %w", entry.Key, err)
+		}
+		s.dailyStats[entry.Key] = stats
+	case collectionAudit:
+		// Audit entries are append-only, so they are never replaced or deleted
+		var auditEntry model.AuditEntry
+		if err := json.Unmarshal(entry.Doc, &auditEntry); err != nil {
+			return fmt.Errorf("failed to decode audit entry %s: %w", entry.Key, err)
+		}
+		s.audit[auditEntry.ID] = auditEntry
+	case collectionPipelines:
+		var pipeline model.Pipeline
+		if err := json.Unmarshal(entry.Doc, &pipeline); err != nil {
+			return fmt.Errorf("failed to decode pipeline %s: %w", entry.Key, err)
+		}
+		s.pipelines[pipeline.OutreachType] = pipeline
+	case collectionSequences:
+		id, err := primitive.ObjectIDFromHex(entry.Key)
+		if err != nil {
+			return fmt.Errorf("invalid sequence ID %s: %w", entry.Key, err)
+		}
+		if entry.Doc == nil {
+			delete(s.sequences, id)
+			return nil
+		}
+		var sequence model.Sequence
+		if err := json.Unmarshal(entry.Doc, &sequence); err != nil {
+			return fmt.Errorf("failed to decode sequence %s: %w", entry.Key, err)
+		}
+		s.sequences[id] = sequence
+	case collectionScoring:
+		var config model.ScoringConfig
+		if err := json.Unmarshal(entry.Doc, &config); err != nil {
+			return fmt.Errorf("failed to decode scoring config: %w", err)
+		}
+		s.scoring = &config
+	case collectionFields:
+		id, err := primitive.ObjectIDFromHex(entry.Key)
+		if err != nil {
+			return fmt.Errorf("invalid custom field ID %s: %w", entry.Key, err)
+		}
+		if entry.Doc == nil {
+			delete(s.fields, id)
+			return nil
+		}
+		var field model.CustomField
+		if err := json.Unmarshal(entry.Doc, &field); err != nil {
+			return fmt.Errorf("failed to decode custom field %s: %w", entry.Key, err)
+		}
+		s.fields[id] = field
+	case collectionCompanies:
+		id, err := primitive.ObjectIDFromHex(entry.Key)
+		if err != nil {
+			return fmt.Errorf("invalid company ID %s: %w", entry.Key, err)
+		}
+		if entry.Doc == nil {
+			delete(s.companies, id)
+			return nil
+		}
+		var company model.Company
+		if err := json.Unmarshal(entry.Doc, &company); err != nil {
+			return fmt.Errorf("failed to decode company %s: %w", entry.Key, err)
+		}
+		s.companies[id] = company
+	case collectionCampaigns:
+		id, err := primitive.ObjectIDFromHex(entry.Key)
+		if err != nil {
+			return fmt.Errorf("invalid campaign ID %s: %w", entry.Key, err)
+		}
+		if entry.Doc == nil {
+			delete(s.campaigns, id)
+			return nil
+		}
+		var campaign model.Campaign
+		if err := json.Unmarshal(entry.Doc, &campaign); err != nil {
+			return fmt.Errorf("failed to decode campaign %s: %w", entry.Key, err)
+		}
+		s.campaigns[id] = campaign
+	case collectionTemplates:
+		id, err := primitive.ObjectIDFromHex(entry.Key)
+		if err != nil {
+			return fmt.Errorf("invalid template ID %s: %w", entry.Key, err)
+		}
+		if entry.Doc == nil {
+			delete(s.templates, id)
+			return nil
+		}
+		var messageTemplate model.MessageTemplate
+		if err := json.Unmarshal(entry.Doc, &messageTemplate); err != nil {
+			return fmt.Errorf("failed to decode template %s: %w", entry.Key, err)
+		}
+		s.templates[id] = messageTemplate
+	default:
+		return fmt.Errorf("unknown collection: %s", entry.Collection)
+	}
+	return nil
+}
+
+// documents returns every stored document as a journal entry, used to write snapshots
+func (s *MemoryStore) documents() ([]journalEntry, error) {
+	var entries []journalEntry
+	add := func(collection string, key string, doc any) error {
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s document %s: %w", collection, key, err)
+		}
+		entries = append(entries, journalEntry{Collection: collection, Key: key, Doc: raw})
+		return nil
+	}
+
+	for id, lead := range s.leads {
+		if err := add(collectionLeads, id.Hex(), lead); err != nil {
+			return nil, err
+		}
+	}
+	if err := add(collectionStats, totalStatsKey, s.totalStats); err != nil {
+		return nil, err
+	}
+	for date, stats := range s.dailyStats {
+		if err := add(collectionDailyStats, date, stats); err != nil {
+			return nil, err
+		}
+	}
+	for id, auditEntry := range s.audit {
+		if err := add(collectionAudit, id.Hex(), auditEntry); err != nil {
+			return nil, err
+		}
+	}
+	for outreachType, pipeline := range s.pipelines {
+		if err := add(collectionPipelines, string(outreachType), pipeline); err != nil {
+			return nil, err
+		}
+	}
+	for id, sequence := range s.sequences {
+		if err := add(collectionSequences, id.Hex(), sequence); err != nil {
+			return nil, err
+		}
+	}
+	for id, field := range s.fields {
+		if err := add(collectionFields, id.Hex(), field); err != nil {
+			return nil, err
+		}
+	}
+	for id, company := range s.companies {
+		if err := add(collectionCompanies, id.Hex(), company); err != nil {
+			return nil, err
+		}
+	}
+	for id, campaign := range s.campaigns {
+		if err := add(collectionCampaigns, id.Hex(), campaign); err != nil {
+			return nil, err
+		}
+	}
+	for id, messageTemplate := range s.templates {
+		if err := add(collectionTemplates, id.Hex(), messageTemplate); err != nil {
+			return nil, err
+		}
+	}
+	if s.scoring != nil {
+		if err := add(collectionScoring, scoringConfigKey, s.scoring); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"leadgentracker/internals/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoMessageTemplateRepository struct {
+	db  *mongo.Client
+	col *mongo.Collection
+}
+
+func NewMessageTemplateRepository(client *mongo.Client) *MongoMessageTemplateRepository {
+	return &MongoMessageTemplateRepository{
+		db:  client,
+		col: client.Database(os.Getenv("MONGO_DB")).Collection(collectionTemplates),
+	}
+}
+
+func (r *MongoMessageTemplateRepository) Create(ctx context.Context, messageTemplate *model.MessageTemplate) error {
+	if _, err := r.col.InsertOne(ctx, messageTemplate); err != nil {
+		return fmt.Errorf("failed to create template: %w", err)
+	}
+	return nil
+}
+
+func (r *MongoMessageTemplateRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.MessageTemplate, error) {
+	var messageTemplate model.MessageTemplate
+	if err := r.col.FindOne(ctx, bson.D{{Key: MongoFieldID, Value: id}}).Decode(&messageTemplate); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", ErrMessageTemplateNotFound, id.Hex())
+		}
+		return nil, fmt.Errorf("failed to find template: %w", err)
+	}
+	return &messageTemplate, nil
+}
+
+// List returns the templates ordered by name
+func (r *MongoMessageTemplateRepository) List(ctx context.Context) ([]model.MessageTemplate, error) {
+	cursor, err := r.col.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: MongoFieldName, Value: 1}, {Key: MongoFieldID, Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var templates []model.MessageTemplate
+	if err := cursor.All(ctx, &templates); err != nil {
+		return nil, fmt.Errorf("failed to decode templates: %w", err)
+	}
+	return templates, nil
+}
+
+func (r *MongoMessageTemplateRepository) Update(ctx context.Context, messageTemplate *model.MessageTemplate) error {
+	result, err := r.col.ReplaceOne(ctx, bson.D{{Key: MongoFieldID, Value: messageTemplate.ID}}, messageTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to update template: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrMessageTemplateNotFound, messageTemplate.ID.Hex())
+	}
+	return nil
+}
+
+func (r *MongoMessageTemplateRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := r.col.DeleteOne(ctx, bson.D{{Key: MongoFieldID, Value: id}}); err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"leadgentracker/internals/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoPipelineRepository struct {
+	db  *mongo.Client
+	col *mongo.Collection
+}
+
+func NewPipelineRepository(client *mongo.Client) *MongoPipelineRepository {
+	return &MongoPipelineRepository{
+		db:  client,
+		col: client.Database(os.Getenv("MONGO_DB")).Collection(collectionPipelines),
+	}
+}
+
+func (r *MongoPipelineRepository) List(ctx context.Context) ([]model.Pipeline, error) {
+	cursor, err := r.col.Find(ctx, bson.D{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pipelines: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var pipelines []model.Pipeline
+	if err := cursor.All(ctx, &pipelines); err != nil {
+		return nil, fmt.Errorf("failed to decode pipelines: %w", err)
+	}
+	return pipelines, nil
+}
+
+// Save creates or replaces the pipeline of its outreach type
+func (r *MongoPipelineRepository) Save(ctx context.Context, pipeline *model.Pipeline) error {
+	filter := bson.D{{Key: MongoFieldID, Value: pipeline.OutreachType}}
+	_, err := r.col.ReplaceOne(ctx, filter, pipeline, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save pipeline: %w", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"leadgentracker/internals/model"
 	"leadgentracker/internals/model/constants"
 	"leadgentracker/internals/model/dto"
@@ -10,15 +11,187 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ErrLeadNotFound is returned by every LeadRepository implementation when the
+// requested lead does not exist
+var ErrLeadNotFound = errors.New("lead not found")
+
+// ErrNoteNotFound is returned by every LeadRepository implementation when the
+// lead has no note with the requested ID
+var ErrNoteNotFound = errors.New("note not found")
+
+// ErrSequenceNotFound is returned by every SequenceRepository implementation
+// when the requested sequence does not exist
+var ErrSequenceNotFound = errors.New("sequence not found")
+
+// ErrCustomFieldNotFound is returned by every CustomFieldRepository
+// implementation when the requested custom field does not exist
+var ErrCustomFieldNotFound = errors.New("custom field not found")
+
+// ErrCompanyNotFound is returned by every CompanyRepository implementation
+// when the requested company does not exist
+var ErrCompanyNotFound = errors.New("company not found")
+
+// ErrCampaignNotFound is returned by every CampaignRepository implementation
+// when the requested campaign does not exist
+var ErrCampaignNotFound = errors.New("campaign not found")
+
+// ErrMessageTemplateNotFound is returned by every MessageTemplateRepository
+// implementation when the requested template does not exist
+var ErrMessageTemplateNotFound = errors.New("message template not found")
+
+// ErrDuplicateProfileURL is returned by LeadRepository.Create when another
+// lead already holds the normalized profile URL of the new lead
+var ErrDuplicateProfileURL = errors.New("duplicate profile URL")
+
 type LeadRepository interface {
 	Create(ctx context.Context, lead *model.Lead) error
+	// CreateBatch stores the new leads in one round trip and returns the IDs
+	// of the leads it stored. Leads whose profile URL another lead holds are
+	// left out, see ErrDuplicateProfileURL. A failed write returns the IDs of
+	// the leads stored anyway along with its error.
+	CreateBatch(ctx context.Context, leads []*model.Lead) ([]primitive.ObjectID, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.Lead, error)
+	// FindByNormalizedURL returns the lead holding the normalized profile URL, including a trashed one
+	FindByNormalizedURL(ctx context.Context, normalizedURL string) (*model.Lead, error)
+	// FindByNormalizedURLs returns the leads holding the normalized profile URLs, including trashed ones
+	FindByNormalizedURLs(ctx context.Context, normalizedURLs []string) ([]model.Lead, error)
+	// Update overwrites the pipeline details of the lead at the version the
+	// update was made from. It fails with ErrLeadNotFound when the lead is
+	// missing, in the trash or was changed since.
 	Update(ctx context.Context, updateProperties *dto.UpdateLeadProperties) (*model.Lead, error)
+	UpdateProfile(ctx context.Context, profileProperties *dto.LeadProfileProperties) (*model.Lead, error)
+	// UpdateScore stores the score of the lead version it was rated from. It
+	// fails with ErrLeadNotFound when the lead is missing or was changed since.
+	UpdateScore(ctx context.Context, scoreProperties *dto.LeadScoreProperties) (*model.Lead, error)
+	// AddNote appends the note to the lead's notes
+	AddNote(ctx context.Context, leadID primitive.ObjectID, note model.Note) (*model.Lead, error)
+	// UpdateNote overwrites the type, text and edit time of the lead's note with the same ID
+	UpdateNote(ctx context.Context, leadID primitive.ObjectID, note model.Note) (*model.Lead, error)
+	DeleteNote(ctx context.Context, leadID primitive.ObjectID, noteID primitive.ObjectID) (*model.Lead, error)
+	// AddMessageTemplateUsage records a message sent to the lead with a template
+	// as its last one and appends it to its template history
+	AddMessageTemplateUsage(ctx context.Context, leadID primitive.ObjectID, usage *model.MessageTemplateUsage) (*model.Lead, error)
+	// FindByIDs returns the leads with the IDs that exist, including trashed ones, in no particular order
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]model.Lead, error)
+	// UpdateBatch applies the changes to their leads in one round trip and
+	// returns the IDs of the leads it changed. Leads in the trash and leads no
+	// longer in the state the change was made for are left unchanged, see
+	// dto.LeadBatchUpdate.
+	UpdateBatch(ctx context.Context, updates []dto.LeadBatchUpdate) ([]primitive.ObjectID, error)
+	// UpdateScores stores the scores of many leads in one round trip, skipping
+	// the leads changed since the version their score was rated from
+	UpdateScores(ctx context.Context, scores []*dto.LeadScoreProperties) error
+	// UnsetCustomField removes the values of the custom field from every lead
+	UnsetCustomField(ctx context.Context, key string) error
+	// UnlinkCompany removes the link to the company from every lead, including trashed ones
+	UnlinkCompany(ctx context.Context, companyID primitive.ObjectID) error
+	// UnassignCampaign removes the campaign from every lead, including trashed ones
+	UnassignCampaign(ctx context.Context, campaignID primitive.ObjectID) error
+	// Delete removes the lead permanently, see SoftDelete for moving it to the
+	// trash. It fails with ErrLeadNotFound when there was no lead to remove.
 	Delete(ctx context.Context, id primitive.ObjectID) error
+	// DeleteTrashed removes the lead permanently if it is in the trash, it
+	// fails with ErrLeadNotFound when the lead is missing or was restored
+	DeleteTrashed(ctx context.Context, id primitive.ObjectID) error
+	// SoftDelete moves the lead to the trash, it fails with ErrLeadNotFound
+	// when the lead is missing or in the trash already
+	SoftDelete(ctx context.Context, id primitive.ObjectID, deletedAt time.Time) (*model.Lead, error)
+	// SoftDeleteBatch moves the active leads among the IDs to the trash in one
+	// round trip and returns the IDs of the leads it moved
+	SoftDeleteBatch(ctx context.Context, ids []primitive.ObjectID, deletedAt time.Time) ([]primitive.ObjectID, error)
+	Restore(ctx context.Context, id primitive.ObjectID) (*model.Lead, error)
+	// AdvanceSequence replaces the sequence progress of a lead whose sequence is
+	// still at fromStep and appends the change to its status history. It fails
+	// with ErrLeadNotFound when the lead is missing or was advanced meanwhile.
+	AdvanceSequence(ctx context.Context, id primitive.ObjectID, fromStep int, progress *model.SequenceProgress, change model.StatusChange) (*model.Lead, error)
+	ListTrashedBefore(ctx context.Context, before time.Time) ([]model.Lead, error)
+	// ListActive returns every lead that is not in the trash
+	ListActive(ctx context.Context) ([]model.Lead, error)
 	ListPaged(ctx context.Context, filter *dto.LeadFilter) ([]model.Lead, int, error)
+	// ListStatusesInUse returns the distinct statuses of the outreach type's leads, including trashed ones
+	ListStatusesInUse(ctx context.Context, outreachType constants.OutreachType) ([]constants.ConnectionStatus, error)
+	// ListCustomFieldTextsInUse returns the distinct text values of the custom field, including the ones of trashed leads
+	ListCustomFieldTextsInUse(ctx context.Context, key string) ([]string, error)
+	// CountTags returns how many leads outside the trash carry each tag, the most used tags first
+	CountTags(ctx context.Context) ([]model.TagCount, error)
+	// CountByMessageTemplate returns how many leads outside the trash were last sent a message with each template
+	CountByMessageTemplate(ctx context.Context) (map[primitive.ObjectID]int, error)
+	// CountByCampaignStage counts the leads outside the trash assigned to a
+	// campaign by campaign, outreach type, stage and earlier moves
+	CountByCampaignStage(ctx context.Context) ([]model.CampaignStageCount, error)
+	// CountByCompany returns how many leads outside the trash are linked to each company
+	CountByCompany(ctx context.Context) (map[primitive.ObjectID]int, error)
+	AggregateStats(ctx context.Context) (*model.StatsSnapshot, error)
 }
 
 type StatsRepository interface {
-	Update(cctx context.Context, outreach constants.OutreachType) error
+	Update(ctx context.Context, outreach constants.OutreachType, date time.Time, delta int) error
+	// UpdateDay adjusts the total and the day's stats by the counts of delta,
+	// so the leads of a day are counted with a single update
+	UpdateDay(ctx context.Context, date time.Time, delta model.Stats) error
 	GetTotal(ctx context.Context) (*model.Stats, error)
 	GetForDate(ctx context.Context, date time.Time) (*model.Stats, error)
+	GetSnapshot(ctx context.Context) (*model.StatsSnapshot, error)
+	// Adjust adds the counters of delta to the stored ones in one update
+	Adjust(ctx context.Context, delta *model.StatsSnapshot) error
+}
+
+type AuditRepository interface {
+	Create(ctx context.Context, entry *model.AuditEntry) error
+	ListPaged(ctx context.Context, filter *dto.AuditFilter) ([]model.AuditEntry, int, error)
+}
+
+type PipelineRepository interface {
+	List(ctx context.Context) ([]model.Pipeline, error)
+	// Save creates or replaces the pipeline of its outreach type
+	Save(ctx context.Context, pipeline *model.Pipeline) error
+}
+
+type SequenceRepository interface {
+	Create(ctx context.Context, sequence *model.Sequence) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.Sequence, error)
+	FindDefault(ctx context.Context, outreachType constants.OutreachType) (*model.Sequence, error)
+	List(ctx context.Context) ([]model.Sequence, error)
+	Update(ctx context.Context, sequence *model.Sequence) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type ScoringRepository interface {
+	// Get returns the saved scoring config, nil when none was saved yet
+	Get(ctx context.Context) (*model.ScoringConfig, error)
+	Save(ctx context.Context, config *model.ScoringConfig) error
+}
+
+type CustomFieldRepository interface {
+	Create(ctx context.Context, field *model.CustomField) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.CustomField, error)
+	List(ctx context.Context) ([]model.CustomField, error)
+	Update(ctx context.Context, field *model.CustomField) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type CompanyRepository interface {
+	Create(ctx context.Context, company *model.Company) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.Company, error)
+	// List returns the companies ordered by name
+	List(ctx context.Context) ([]model.Company, error)
+	Update(ctx context.Context, company *model.Company) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type CampaignRepository interface {
+	Create(ctx context.Context, campaign *model.Campaign) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.Campaign, error)
+	// List returns the campaigns, the newest first
+	List(ctx context.Context) ([]model.Campaign, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type MessageTemplateRepository interface {
+	Create(ctx context.Context, messageTemplate *model.MessageTemplate) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.MessageTemplate, error)
+	// List returns the templates ordered by name
+	List(ctx context.Context) ([]model.MessageTemplate, error)
+	Update(ctx context.Context, messageTemplate *model.MessageTemplate) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
 }
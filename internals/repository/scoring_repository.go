@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"leadgentracker/internals/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// scoringConfigKey is the ID of the single scoring config document
+const scoringConfigKey = "leadScore"
+
+type MongoScoringRepository struct {
+	db  *mongo.Client
+	col *mongo.Collection
+}
+
+func NewScoringRepository(client *mongo.Client) *MongoScoringRepository {
+	return &MongoScoringRepository{
+		db:  client,
+		col: client.Database(os.Getenv("MONGO_DB")).Collection(collectionScoring),
+	}
+}
+
+// Get returns the saved scoring config, nil when none was saved yet
+func (r *MongoScoringRepository) Get(ctx context.Context) (*model.ScoringConfig, error) {
+	var config model.ScoringConfig
+	err := r.col.FindOne(ctx, bson.D{{Key: MongoFieldID, Value: scoringConfigKey}}).Decode(&config)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find scoring config: %w", err)
+	}
+	return &config, nil
+}
+
+func (r *MongoScoringRepository) Save(ctx context.Context, config *model.ScoringConfig) error {
+	filter := bson.D{{Key: MongoFieldID, Value: scoringConfigKey}}
+	_, err := r.col.ReplaceOne(ctx, filter, config, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save scoring config: %w", err)
+	}
+	return nil
+}
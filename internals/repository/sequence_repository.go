@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	MongoFieldSequenceName         = "name"
+	MongoFieldSequenceOutreachType = "outreachType"
+	MongoFieldSequenceDefault      = "default"
+)
+
+type MongoSequenceRepository struct {
+	db  *mongo.Client
+	col *mongo.Collection
+}
+
+func NewSequenceRepository(client *mongo.Client) *MongoSequenceRepository {
+	return &MongoSequenceRepository{
+		db:  client,
+		col: client.Database(os.Getenv("MONGO_DB")).Collection(collectionSequences),
+	}
+}
+
+func (r *MongoSequenceRepository) Create(ctx context.Context, sequence *model.Sequence) error {
+	if _, err := r.col.InsertOne(ctx, sequence); err != nil {
+		return fmt.Errorf("failed to create sequence: %w", err)
+	}
+	return nil
+}
+
+func (r *MongoSequenceRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Sequence, error) {
+	return r.findOne(ctx, bson.D{{Key: MongoFieldID, Value: id}}, id.Hex())
+}
+
+// FindDefault returns the default sequence of the outreach type
+func (r *MongoSequenceRepository) FindDefault(ctx context.Context, outreachType constants.OutreachType) (*model.Sequence, error) {
+	filter := bson.D{
+		{Key: MongoFieldSequenceDefault, Value: true},
+		{Key: MongoFieldSequenceOutreachType, Value: outreachType},
+	}
+	return r.findOne(ctx, filter, "default "+string(outreachType))
+}
+
+func (r *MongoSequenceRepository) findOne(ctx context.Context, filter bson.D, description string) (*model.Sequence, error) {
+	var sequence model.Sequence
+	if err := r.col.FindOne(ctx, filter).Decode(&sequence); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", ErrSequenceNotFound, description)
+		}
+		return nil, fmt.Errorf("failed to find sequence: %w", err)
+	}
+	return &sequence, nil
+}
+
+func (r *MongoSequenceRepository) List(ctx context.Context) ([]model.Sequence, error) {
+	cursor, err := r.col.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: MongoFieldSequenceName, Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sequences: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var sequences []model.Sequence
+	if err := cursor.All(ctx, &sequences); err != nil {
+		return nil, fmt.Errorf("failed to decode sequences: %w", err)
+	}
+	return sequences, nil
+}
+
+func (r *MongoSequenceRepository) Update(ctx context.Context, sequence *model.Sequence) error {
+	result, err := r.col.ReplaceOne(ctx, bson.D{{Key: MongoFieldID, Value: sequence.ID}}, sequence)
+	if err != nil {
+		return fmt.Errorf("failed to update sequence: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrSequenceNotFound, sequence.ID.Hex())
+	}
+	return nil
+}
+
+func (r *MongoSequenceRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := r.col.DeleteOne(ctx, bson.D{{Key: MongoFieldID, Value: id}}); err != nil {
+		return fmt.Errorf("failed to delete sequence: %w", err)
+	}
+	return nil
+}
@@ -21,88 +21,80 @@ type MongoStatsRepository struct {
 func NewStatsRepository(client *mongo.Client) *MongoStatsRepository {
 	return &MongoStatsRepository{
 		db:  client,
-		col: client.Database(os.Getenv("MONGO_DB")).Collection("stats"),
+		col: client.Database(os.Getenv("MONGO_DB")).Collection(collectionStats),
 	}
 }
 
-// Update method updates both totalStats and today's stats in dailyStats based on outreachType
-func (r *MongoStatsRepository) Update(ctx context.Context, outreachType constants.OutreachType) error {
-	// Prepare the increment field based on the outreach type
-	var totalStatsField string
-	var dailyStatsField string
-
+// Update adjusts both totalStats and the given day's entry in dailyStats by delta based on outreachType
+func (r *MongoStatsRepository) Update(ctx context.Context, outreachType constants.OutreachType, date time.Time, delta int) error {
+	var stats model.Stats
 	switch outreachType {
 	case constants.OutreachTypeConnection:
-		totalStatsField = "totalStats.connections"
-		dailyStatsField = "dailyStats.$.connections"
+		stats.Connections = delta
 	case constants.OutreachTypeInMail:
-		totalStatsField = "totalStats.inMails"
-		dailyStatsField = "dailyStats.$.inMails"
+		stats.InMails = delta
 	default:
 		return fmt.Errorf("unsupported outreach type: %v", outreachType)
 	}
+	return r.UpdateDay(ctx, date, stats)
+}
 
-	// Update or insert Total Stats with upsert to handle first-time setup
-	_, err := r.col.UpdateOne(
-		ctx,
-		bson.M{"_id": "stats"},
-		bson.M{
-			"$inc": bson.M{
-				totalStatsField: 1, // Increment only the relevant totalStats field
-			},
-		},
-		options.Update().SetUpsert(true),
-	)
-	if err != nil {
-		return fmt.Errorf("failed to update total stats: %w", err)
-	}
-
-	today := time.Now().Format("2006-01-02") // format as YYYY-MM-DD
-
-	// Attempt to update today's stats within dailyStats
-	updateResult, err := r.col.UpdateOne(
-		ctx,
-		bson.M{"_id": "stats", "dailyStats.date": today},
-		bson.M{
-			"$inc": bson.M{
-				dailyStatsField: 1, // Increment only the relevant dailyStats field
-			},
-		},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to update today's stats: %w", err)
+// UpdateDay adjusts totalStats and the day's entry in dailyStats by the counts
+// of delta. The total and the day are always adjusted by the same update, so
+// neither is changed without the other.
+func (r *MongoStatsRepository) UpdateDay(ctx context.Context, date time.Time, delta model.Stats) error {
+	day := date.Format("2006-01-02") // format as YYYY-MM-DD
+	incTotal := bson.M{
+		"totalStats.connections": delta.Connections,
+		"totalStats.inMails":     delta.InMails,
 	}
 
-	// If no document was modified, add today's date as a new entry in dailyStats
-	if updateResult.ModifiedCount == 0 {
-		newDailyStats := bson.M{
-			"date":        today,
-			"connections": 0,
-			"inMails":     0,
-		}
+	// A decrement for a day that was never counted is dropped, so only the
+	// total is adjusted when the day has no entry
+	addsDay := delta.Connections > 0 || delta.InMails > 0
 
-		// Set the initial count based on the outreach type
-		if outreachType == constants.OutreachTypeConnection {
-			newDailyStats["connections"] = 1
-		} else if outreachType == constants.OutreachTypeInMail {
-			newDailyStats["inMails"] = 1
-		}
-
-		_, err = r.col.UpdateOne(
+	// The day may be added by another request between the two updates, the
+	// entry is then adjusted on the next attempt
+	for attempt := 0; attempt < 2; attempt++ {
+		updateResult, err := r.col.UpdateOne(
 			ctx,
-			bson.M{"_id": "stats"},
+			bson.M{"_id": "stats", "dailyStats.date": day},
 			bson.M{
-				"$push": bson.M{
-					"dailyStats": newDailyStats,
+				"$inc": bson.M{
+					"totalStats.connections":   delta.Connections,
+					"totalStats.inMails":       delta.InMails,
+					"dailyStats.$.connections": delta.Connections,
+					"dailyStats.$.inMails":     delta.InMails,
 				},
 			},
 		)
 		if err != nil {
-			return fmt.Errorf("failed to insert new daily stats for today: %w", err)
+			return fmt.Errorf("failed to update stats: %w", err)
+		}
+		if updateResult.MatchedCount > 0 {
+			return nil
 		}
-	}
 
-	return nil
+		// The day has no entry yet. It is only added while it still has none,
+		// the upsert creates the stats document on the first update.
+		filter := bson.M{"_id": "stats", "dailyStats.date": bson.M{"$ne": day}}
+		update := bson.M{"$inc": incTotal}
+		if addsDay {
+			update["$push"] = bson.M{
+				"dailyStats": bson.M{"date": day, "connections": delta.Connections, "inMails": delta.InMails},
+			}
+		}
+		_, err = r.col.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		if err == nil {
+			return nil
+		}
+		// The stats document exists and holds the day, so the upsert failed
+		// to insert a second one
+		if !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("failed to update stats: %w", err)
+		}
+	}
+	return fmt.Errorf("failed to update stats of %s: the day kept changing", day)
 }
 
 // GetTotal retrieves the totalStats from the stats document
@@ -165,3 +157,67 @@ func (r *MongoStatsRepository) GetForDate(ctx context.Context, date time.Time) (
 	// Return a zero-value Stats object if no daily stats entry is found for the date
 	return &model.Stats{Connections: 0, InMails: 0}, nil
 }
+
+// GetSnapshot retrieves the whole stats document
+func (r *MongoStatsRepository) GetSnapshot(ctx context.Context) (*model.StatsSnapshot, error) {
+	var result struct {
+		TotalStats model.Stats        `bson:"totalStats"`
+		DailyStats []model.DailyStats `bson:"dailyStats"`
+	}
+
+	err := r.col.FindOne(ctx, bson.M{"_id": "stats"}).Decode(&result)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("failed to retrieve stats: %w", err)
+	}
+
+	snapshot := &model.StatsSnapshot{Total: result.TotalStats, Daily: result.DailyStats}
+	if snapshot.Daily == nil {
+		snapshot.Daily = []model.DailyStats{}
+	}
+	sortDailyStats(snapshot.Daily)
+	return snapshot, nil
+}
+
+// Adjust adds the counters of delta to the stored ones with a single
+// pipeline update of the stats document, so it applies all at once and keeps
+// the increments of other requests. Days missing from the stats are added.
+func (r *MongoStatsRepository) Adjust(ctx context.Context, delta *model.StatsSnapshot) error {
+	set := bson.M{
+		"totalStats.connections": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$totalStats.connections", 0}}, delta.Total.Connections}},
+		"totalStats.inMails":     bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$totalStats.inMails", 0}}, delta.Total.InMails}},
+	}
+	if len(delta.Daily) > 0 {
+		branches := make(bson.A, len(delta.Daily))
+		newDays := make(bson.A, len(delta.Daily))
+		for i, day := range delta.Daily {
+			branches[i] = bson.M{
+				"case": bson.M{"$eq": bson.A{"$$day.date", day.Date}},
+				"then": bson.M{
+					"date":        "$$day.date",
+					"connections": bson.M{"$add": bson.A{"$$day.connections", day.Connections}},
+					"inMails":     bson.M{"$add": bson.A{"$$day.inMails", day.InMails}},
+				},
+			}
+			newDays[i] = day
+		}
+		dailyStats := bson.M{"$ifNull": bson.A{"$dailyStats", bson.A{}}}
+		set["dailyStats"] = bson.M{"$concatArrays": bson.A{
+			bson.M{"$map": bson.M{
+				"input": dailyStats,
+				"as":    "day",
+				"in":    bson.M{"$switch": bson.M{"branches": branches, "default": "$$day"}},
+			}},
+			bson.M{"$filter": bson.M{
+				"input": bson.M{"$literal": newDays},
+				"as":    "day",
+				"cond":  bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$$day.date", bson.M{"$map": bson.M{"input": dailyStats, "in": "$$this.date"}}}}}},
+			}},
+		}}
+	}
+
+	_, err := r.col.UpdateOne(ctx, bson.M{"_id": "stats"}, mongo.Pipeline{{{Key: "$set", Value: set}}}, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to adjust stats: %w", err)
+	}
+	return nil
+}
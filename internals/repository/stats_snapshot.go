@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+)
+
+// statsAccumulator builds a StatsSnapshot out of lead counts per day and outreach type
+type statsAccumulator struct {
+	total model.Stats
+	daily map[string]*model.DailyStats
+}
+
+func newStatsAccumulator() *statsAccumulator {
+	return &statsAccumulator{daily: make(map[string]*model.DailyStats)}
+}
+
+func (a *statsAccumulator) add(day string, outreachType constants.OutreachType, count int) {
+	daily, exists := a.daily[day]
+	if !exists {
+		daily = &model.DailyStats{Date: day}
+		a.daily[day] = daily
+	}
+
+	switch outreachType {
+	case constants.OutreachTypeConnection:
+		a.total.Connections += count
+		daily.Connections += count
+	case constants.OutreachTypeInMail:
+		a.total.InMails += count
+		daily.InMails += count
+	}
+}
+
+// snapshot returns the accumulated stats with the days in chronological order
+func (a *statsAccumulator) snapshot() *model.StatsSnapshot {
+	snapshot := &model.StatsSnapshot{Total: a.total, Daily: make([]model.DailyStats, 0, len(a.daily))}
+	for _, daily := range a.daily {
+		snapshot.Daily = append(snapshot.Daily, *daily)
+	}
+	sortDailyStats(snapshot.Daily)
+	return snapshot
+}
+
+func sortDailyStats(daily []model.DailyStats) {
+	sort.Slice(daily, func(i, j int) bool {
+		return daily[i].Date < daily[j].Date
+	})
+}
+
+// statsTimezone is the timezone MongoDB uses to assign leads to days. It has
+// to match the local time the stats are counted in, see the TZ of the Dockerfile.
+func statsTimezone() string {
+	if tz := os.Getenv("TZ"); tz != "" {
+		return tz
+	}
+	return time.Now().Format("-07:00")
+}
@@ -0,0 +1,123 @@
+// Package search splits free text search queries into terms and finds those
+// terms in lead text the same way for the in-memory backend and the views.
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Segment is a piece of highlighted text, Match marks a word matching a search term
+type Segment struct {
+	Text  string
+	Match bool
+}
+
+// Terms splits a search query into lowercase words. Everything but letters
+// and digits separates words, so the terms never carry characters with a
+// special meaning in a regex or a Mongo $text search.
+func Terms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), isSeparator) {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// Count returns how many words of the text start with one of the terms.
+// Matching word prefixes stands in for the stemming of the Mongo text index,
+// so "conference" also finds "conferences", and for the partial word search
+// the Mongo repository falls back to, so "jan" also finds "Jane".
+func Count(text string, terms []string) int {
+	count := 0
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), isSeparator) {
+		if matchesAny(word, terms) {
+			count++
+		}
+	}
+	return count
+}
+
+// Highlight splits the text into segments, marking the words that start with one of the terms
+func Highlight(text string, terms []string) []Segment {
+	if len(terms) == 0 {
+		return []Segment{{Text: text}}
+	}
+
+	var segments []Segment
+	runes := []rune(text)
+	start := 0
+	for start < len(runes) {
+		end := start
+		inWord := !isSeparator(runes[start])
+		for end < len(runes) && !isSeparator(runes[end]) == inWord {
+			end++
+		}
+
+		piece := string(runes[start:end])
+		match := inWord && matchesAny(strings.ToLower(piece), terms)
+		// Merge neighbouring pieces that are highlighted the same way
+		if last := len(segments) - 1; last >= 0 && segments[last].Match == match {
+			segments[last].Text += piece
+		} else {
+			segments = append(segments, Segment{Text: piece, Match: match})
+		}
+		start = end
+	}
+	return segments
+}
+
+// Excerpt returns at most maxRunes runes of the text around its first word
+// matching one of the terms, or an empty string if no word matches
+func Excerpt(text string, terms []string, maxRunes int) string {
+	runes := []rune(text)
+	// Lowercase rune by rune, strings.ToLower may change the number of runes
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	for i := 0; i < len(lower); i++ {
+		if isSeparator(lower[i]) || (i > 0 && !isSeparator(lower[i-1])) {
+			continue
+		}
+		end := i
+		for end < len(lower) && !isSeparator(lower[end]) {
+			end++
+		}
+		if !matchesAny(string(lower[i:end]), terms) {
+			i = end
+			continue
+		}
+
+		// Keep a third of the excerpt as context before the match
+		from := max(i-maxRunes/3, 0)
+		to := min(from+maxRunes, len(runes))
+		excerpt := strings.TrimSpace(string(runes[from:to]))
+		if from > 0 {
+			excerpt = "…" + excerpt
+		}
+		if to < len(runes) {
+			excerpt += "…"
+		}
+		return excerpt
+	}
+	return ""
+}
+
+func matchesAny(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/model/dto"
+	"leadgentracker/internals/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ActorSystem is recorded for mutations made outside of a request, e.g. by commands or background jobs
+const ActorSystem = "system"
+
+type auditContextKey struct{}
+
+type auditContext struct {
+	actor   string
+	request model.RequestMetadata
+}
+
+// WithAuditContext attaches the actor and request that the audit log records for mutations made with ctx
+func WithAuditContext(ctx context.Context, actor string, request model.RequestMetadata) context.Context {
+	return context.WithValue(ctx, auditContextKey{}, auditContext{actor: actor, request: request})
+}
+
+// Actor returns the actor attached to ctx by WithAuditContext, ActorSystem when there is none
+func Actor(ctx context.Context) string {
+	if auditCtx, ok := ctx.Value(auditContextKey{}).(auditContext); ok {
+		return auditCtx.actor
+	}
+	return ActorSystem
+}
+
+type AuditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(auditRepository repository.AuditRepository) *AuditService {
+	return &AuditService{
+		repo: auditRepository,
+	}
+}
+
+// Record stores an audit entry for a mutation that already happened. before
+// and after are the states around it, nil when there is none. A failed audit
+// write is logged but does not undo the mutation.
+func (s *AuditService) Record(ctx context.Context, action constants.AuditAction, entityType constants.AuditEntity, entityID string, before any, after any) {
+	auditCtx, ok := ctx.Value(auditContextKey{}).(auditContext)
+	if !ok {
+		auditCtx.actor = ActorSystem
+	}
+
+	entry := &model.AuditEntry{
+		ID:         primitive.NewObjectID(),
+		Timestamp:  time.Now(),
+		Actor:      auditCtx.actor,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Request:    auditCtx.request,
+		Before:     auditSnapshot(before),
+		After:      auditSnapshot(after),
+	}
+
+	// The mutation is done, so record it even if the request was cancelled meanwhile
+	if err := s.repo.Create(context.WithoutCancel(ctx), entry); err != nil {
+		log.Printf("[ERROR] failed to record %s of %s %s in audit log: %s", action, entityType, entityID, err)
+	}
+}
+
+func (s *AuditService) GetEntriesPaged(ctx context.Context, filter *dto.AuditFilter) ([]model.AuditEntry, int, error) {
+	return s.repo.ListPaged(ctx, filter)
+}
+
+func auditSnapshot(state any) model.AuditSnapshot {
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		log.Printf("[ERROR] failed to encode audit snapshot: %s", err)
+		return ""
+	}
+	// A typed nil pointer encodes as null, which means there is no state
+	if string(encoded) == "null" {
+		return ""
+	}
+	return model.AuditSnapshot(encoded)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidCampaign is returned when saving a malformed campaign
+var ErrInvalidCampaign = errors.New("invalid campaign")
+
+// CampaignService manages the campaigns leads are assigned to and compares
+// their results
+type CampaignService struct {
+	repo      repository.CampaignRepository
+	leadRepo  repository.LeadRepository
+	pipelines *PipelineService
+	audit     *AuditService
+}
+
+func NewCampaignService(campaignRepository repository.CampaignRepository, leadRepository repository.LeadRepository, pipelineService *PipelineService, auditService *AuditService) *CampaignService {
+	return &CampaignService{
+		repo:      campaignRepository,
+		leadRepo:  leadRepository,
+		pipelines: pipelineService,
+		audit:     auditService,
+	}
+}
+
+// GetAll returns the campaigns, the newest first
+func (s *CampaignService) GetAll(ctx context.Context) (model.Campaigns, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *CampaignService) Get(ctx context.Context, id primitive.ObjectID) (*model.Campaign, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+// GetStats returns the totals, acceptance and response rates of the leads
+// outside the trash by campaign ID
+func (s *CampaignService) GetStats(ctx context.Context) (map[primitive.ObjectID]model.CampaignStats, error) {
+	counts, err := s.leadRepo.CountByCampaignStage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pipelines, err := s.pipelines.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return model.NewCampaignStats(counts, pipelines), nil
+}
+
+func (s *CampaignService) Create(ctx context.Context, campaign *model.Campaign) error {
+	campaign.Normalize()
+	if err := campaign.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidCampaign, err)
+	}
+
+	campaign.ID = primitive.NewObjectID()
+	campaign.CreatedAt = time.Now()
+	if err := s.repo.Create(ctx, campaign); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, constants.AuditActionCreate, constants.AuditEntityCampaign, campaign.ID.Hex(), nil, campaign)
+	return nil
+}
+
+// Delete removes the campaign, its leads are kept without a campaign. The
+// leads are unassigned after the campaign is gone, assigning a lead to a
+// missing campaign fails, so none can be assigned to it in between.
+func (s *CampaignService) Delete(ctx context.Context, id primitive.ObjectID) error {
+	current, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, constants.AuditActionDelete, constants.AuditEntityCampaign, id.Hex(), current, nil)
+
+	return s.leadRepo.UnassignCampaign(ctx, id)
+}
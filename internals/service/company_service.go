@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/model/dto"
+	"leadgentracker/internals/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidCompany is returned when saving a malformed company
+var ErrInvalidCompany = errors.New("invalid company")
+
+// CompanyService manages the companies leads work at
+type CompanyService struct {
+	repo      repository.CompanyRepository
+	leadRepo  repository.LeadRepository
+	pipelines *PipelineService
+	audit     *AuditService
+}
+
+func NewCompanyService(companyRepository repository.CompanyRepository, leadRepository repository.LeadRepository, pipelineService *PipelineService, auditService *AuditService) *CompanyService {
+	return &CompanyService{
+		repo:      companyRepository,
+		leadRepo:  leadRepository,
+		pipelines: pipelineService,
+		audit:     auditService,
+	}
+}
+
+// GetAll returns the companies ordered by name
+func (s *CompanyService) GetAll(ctx context.Context) (model.Companies, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *CompanyService) Get(ctx context.Context, id primitive.ObjectID) (*model.Company, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+// CountLeads returns how many leads outside the trash work at each company
+func (s *CompanyService) CountLeads(ctx context.Context) (map[primitive.ObjectID]int, error) {
+	return s.leadRepo.CountByCompany(ctx)
+}
+
+// GetOverview returns the company with its leads outside the trash and their stats
+func (s *CompanyService) GetOverview(ctx context.Context, id primitive.ObjectID) (*dto.CompanyOverview, error) {
+	company, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	leads, _, err := s.leadRepo.ListPaged(ctx, dto.NewCompanyLeadFilter(id))
+	if err != nil {
+		return nil, err
+	}
+
+	pipelines, err := s.pipelines.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.CompanyOverview{
+		Company: company,
+		Leads:   leads,
+		Stats:   model.NewCompanyStats(leads, pipelines, time.Now()),
+	}, nil
+}
+
+func (s *CompanyService) Create(ctx context.Context, company *model.Company) error {
+	company.ID = primitive.NewObjectID()
+	if err := s.validate(ctx, company); err != nil {
+		return err
+	}
+
+	if err := s.repo.Create(ctx, company); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, constants.AuditActionCreate, constants.AuditEntityCompany, company.ID.Hex(), nil, company)
+	return nil
+}
+
+func (s *CompanyService) Update(ctx context.Context, company *model.Company) error {
+	current, err := s.repo.FindByID(ctx, company.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.validate(ctx, company); err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(ctx, company); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, constants.AuditActionUpdate, constants.AuditEntityCompany, company.ID.Hex(), current, company)
+	return nil
+}
+
+// Delete removes the company, its leads are kept without a company
+func (s *CompanyService) Delete(ctx context.Context, id primitive.ObjectID) error {
+	current, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.leadRepo.UnlinkCompany(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, constants.AuditActionDelete, constants.AuditEntityCompany, id.Hex(), current, nil)
+	return nil
+}
+
+// validate normalizes the company and checks it, two companies can't share a domain
+func (s *CompanyService) validate(ctx context.Context, company *model.Company) error {
+	company.Normalize()
+	if err := company.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidCompany, err)
+	}
+	if company.Domain == "" {
+		return nil
+	}
+
+	companies, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, existing := range companies {
+		if existing.ID != company.ID && existing.Domain == company.Domain {
+			return fmt.Errorf("%w: the domain %s belongs to %s", ErrInvalidCompany, company.Domain, existing.Name)
+		}
+	}
+	return nil
+}
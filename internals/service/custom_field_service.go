@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidCustomField is returned when saving a malformed custom field
+var ErrInvalidCustomField = errors.New("invalid custom field")
+
+// ErrInvalidCustomFieldValue is returned when a lead update brings a value
+// its custom field can't hold
+var ErrInvalidCustomFieldValue = errors.New("invalid custom field value")
+
+// CustomFieldService manages the definitions of the custom fields tracked on leads
+type CustomFieldService struct {
+	repo     repository.CustomFieldRepository
+	leadRepo repository.LeadRepository
+	audit    *AuditService
+}
+
+func NewCustomFieldService(customFieldRepository repository.CustomFieldRepository, leadRepository repository.LeadRepository, auditService *AuditService) *CustomFieldService {
+	return &CustomFieldService{
+		repo:     customFieldRepository,
+		leadRepo: leadRepository,
+		audit:    auditService,
+	}
+}
+
+// GetAll returns the custom fields in the order they were created
+func (s *CustomFieldService) GetAll(ctx context.Context) ([]model.CustomField, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *CustomFieldService) Create(ctx context.Context, field *model.CustomField) error {
+	field.ID = primitive.NewObjectID()
+	if err := field.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidCustomField, err)
+	}
+
+	fields, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, existing := range fields {
+		if existing.Key == field.Key {
+			return fmt.Errorf("%w: the key %s is already used by %s", ErrInvalidCustomField, field.Key, existing.Label)
+		}
+	}
+
+	if err := s.repo.Create(ctx, field); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, constants.AuditActionCreate, constants.AuditEntityCustomField, field.ID.Hex(), nil, field)
+	return nil
+}
+
+// Update changes the label and options of the field. Its key and type can't
+// change, as the values stored on the leads depend on them, and an option
+// can't be removed while leads, including trashed ones, still hold it.
+func (s *CustomFieldService) Update(ctx context.Context, field *model.CustomField) error {
+	current, err := s.repo.FindByID(ctx, field.ID)
+	if err != nil {
+		return err
+	}
+	if field.Key != current.Key || field.Type != current.Type {
+		return fmt.Errorf("%w: the key and type of %s can't change", ErrInvalidCustomField, current.Key)
+	}
+	if err := field.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidCustomField, err)
+	}
+
+	if field.Type == constants.CustomFieldTypeSelect {
+		inUse, err := s.leadRepo.ListCustomFieldTextsInUse(ctx, field.Key)
+		if err != nil {
+			return err
+		}
+		for _, option := range inUse {
+			if !slices.Contains(field.Options, option) {
+				return fmt.Errorf("%w: option %s of %s still has leads", ErrInvalidCustomField, option, field.Key)
+			}
+		}
+	}
+
+	if err := s.repo.Update(ctx, field); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, constants.AuditActionUpdate, constants.AuditEntityCustomField, field.ID.Hex(), current, field)
+	return nil
+}
+
+// Delete removes the field together with its values on every lead
+func (s *CustomFieldService) Delete(ctx context.Context, id primitive.ObjectID) error {
+	current, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.leadRepo.UnsetCustomField(ctx, current.Key); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, constants.AuditActionDelete, constants.AuditEntityCustomField, id.Hex(), current, nil)
+	return nil
+}
+
+// applyValues returns the lead's custom field values with the entered ones
+// applied. Fields without an entered value keep theirs, an empty value
+// removes it.
+func (s *CustomFieldService) applyValues(ctx context.Context, current map[string]model.CustomFieldValue, input map[string]string) (map[string]model.CustomFieldValue, error) {
+	if len(input) == 0 {
+		return current, nil
+	}
+
+	fields, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	values := maps.Clone(current)
+	if values == nil {
+		values = make(map[string]model.CustomFieldValue)
+	}
+	for _, field := range fields {
+		raw, entered := input[field.Key]
+		if !entered {
+			continue
+		}
+		value, err := field.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCustomFieldValue, err)
+		}
+		if value == nil {
+			delete(values, field.Key)
+		} else {
+			values[field.Key] = *value
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/model/dto"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidBulkAction is returned for a bulk action without leads, with
+// more leads than a page holds or without the value it sets
+var ErrInvalidBulkAction = errors.New("invalid bulk action")
+
+// bulkReasonNotFound is the reason of the selected leads that don't exist or are in the trash
+const bulkReasonNotFound = "lead not found"
+
+// bulkReasonChanged is the reason of the leads another request changed or
+// deleted after they were read, which the action is no longer valid for
+const bulkReasonChanged = "lead was changed or deleted meanwhile, please try again"
+
+// ApplyBulkAction applies the action to every selected lead. The leads are
+// read, changed and scored in one round trip each, a lead the action can't
+// change is reported in the result instead of failing the others. Only an
+// invalid action or a failed round trip fails the whole action.
+func (s *LeadService) ApplyBulkAction(ctx context.Context, action *dto.BulkLeadAction) (*dto.BulkResult, error) {
+	if err := validateBulkAction(action); err != nil {
+		return nil, err
+	}
+
+	ids := uniqueIDs(action.IDs)
+	found, err := s.repo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	leads := make(map[primitive.ObjectID]*model.Lead, len(found))
+	for i := range found {
+		if !found[i].IsTrashed() {
+			leads[found[i].ID] = &found[i]
+		}
+	}
+
+	result := &dto.BulkResult{Action: action.Action, Items: make([]dto.BulkItemResult, len(ids))}
+	for i, id := range ids {
+		result.Items[i] = dto.BulkItemResult{ID: id, Outcome: constants.BulkOutcomeFailed, Reason: bulkReasonNotFound}
+		if lead, exists := leads[id]; exists {
+			result.Items[i].Name = lead.Name
+		}
+	}
+
+	if action.Action == constants.BulkActionDelete {
+		return result, s.deleteBatch(ctx, leads, result)
+	}
+	return result, s.updateBatch(ctx, action, leads, result)
+}
+
+// updateBatch writes the changes of the action to the leads and rescores them
+func (s *LeadService) updateBatch(ctx context.Context, action *dto.BulkLeadAction, leads map[primitive.ObjectID]*model.Lead, result *dto.BulkResult) error {
+	now := time.Now()
+	pipelines := make(map[constants.OutreachType]*model.Pipeline)
+	var updates []dto.LeadBatchUpdate
+	for i := range result.Items {
+		item := &result.Items[i]
+		lead, exists := leads[item.ID]
+		if !exists {
+			continue
+		}
+
+		update := dto.LeadBatchUpdate{ID: lead.ID}
+		switch action.Action {
+		case constants.BulkActionSetTemperature:
+			item.Outcome, item.Reason = bulkSetTemperature(lead, action.LeadTemperature, now, &update)
+		case constants.BulkActionSetStatus:
+			pipeline, cached := pipelines[lead.OutreachType]
+			if !cached {
+				var err error
+				if pipeline, err = s.pipelines.Get(ctx, lead.OutreachType); err != nil {
+					return err
+				}
+				pipelines[lead.OutreachType] = pipeline
+			}
+			item.Outcome, item.Reason = s.bulkSetStatus(pipeline, lead, action.ConnectionStatus, now, &update)
+			// The move was validated from the stage the lead was read at
+			update.ExpectedStatus = lead.ConnectionStatus
+		case constants.BulkActionMarkFollowupSent:
+			item.Outcome, item.Reason = bulkMarkFollowupSent(lead, now, &update)
+		case constants.BulkActionAddTag:
+			item.Outcome, item.Reason = bulkAddTag(lead, action.Tag, &update)
+		}
+
+		if item.Outcome == constants.BulkOutcomeUpdated {
+			updates = append(updates, update)
+		}
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+	ids, err := s.repo.UpdateBatch(ctx, updates)
+	if err != nil {
+		return err
+	}
+	changed := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		changed[id] = true
+	}
+	for i := range result.Items {
+		item := &result.Items[i]
+		if item.Outcome == constants.BulkOutcomeUpdated && !changed[item.ID] {
+			item.Outcome, item.Reason = constants.BulkOutcomeFailed, bulkReasonChanged
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	updated, err := s.repo.FindByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	updated, err = s.scoring.RescoreBatch(ctx, updated)
+	if err != nil {
+		return err
+	}
+
+	for i := range updated {
+		lead := &updated[i]
+		s.audit.Record(ctx, constants.AuditActionUpdate, constants.AuditEntityLead, lead.ID.Hex(), leads[lead.ID], lead)
+	}
+	return nil
+}
+
+// bulkSetTemperature sets the temperature of the lead by hand. Like the other
+// bulk changes, it fills in the update of a single lead and returns the
+// outcome for it, with the reason when it leaves the lead unchanged or fails.
+// A temperature set by hand is recorded in the status history right away, the
+// one the score decides once the override is cleared is recorded by the rescore.
+func bulkSetTemperature(lead *model.Lead, temperature constants.LeadTemperature, now time.Time, update *dto.LeadBatchUpdate) (constants.BulkOutcome, string) {
+	if lead.TemperatureOverride == temperature {
+		if temperature == "" {
+			return constants.BulkOutcomeUnchanged, "temperature already follows the score"
+		}
+		return constants.BulkOutcomeUnchanged, fmt.Sprintf("temperature already set to %s", temperature)
+	}
+	update.TemperatureOverride = &temperature
+	if temperature != "" && temperature != lead.LeadTemperature {
+		update.LeadTemperature = temperature
+		update.ExpectedTemperature = lead.LeadTemperature
+		update.StatusChanges = []model.StatusChange{*temperatureChange(lead.LeadTemperature, temperature, now)}
+	}
+	return constants.BulkOutcomeUpdated, ""
+}
+
+// bulkSetStatus moves the lead to the stage like UpdateLead would, its
+// pipeline must allow the move
+func (s *LeadService) bulkSetStatus(pipeline *model.Pipeline, lead *model.Lead, status constants.ConnectionStatus, now time.Time, update *dto.LeadBatchUpdate) (constants.BulkOutcome, string) {
+	if lead.ConnectionStatus == status {
+		return constants.BulkOutcomeUnchanged, fmt.Sprintf("already at %s", pipeline.Label(status))
+	}
+
+	// The single lead update decides the transition, follow-up and history
+	properties := &dto.UpdateLeadProperties{
+		ID:               lead.ID,
+		ConnectionStatus: status,
+		FollowupSent:     lead.FollowupSent,
+	}
+	if err := validateTransition(pipeline, lead, properties); err != nil {
+		return constants.BulkOutcomeFailed, err.Error()
+	}
+	s.scheduleFollowup(lead, properties, now)
+	// scheduleFollowup only sets a due date when the lead is accepted
+	if properties.FollowupDueAt != nil && lead.FollowupDueAt == nil {
+		update.FollowupDueAt = properties.FollowupDueAt
+	}
+
+	update.ConnectionStatus = status
+	update.StatusChanges = statusChanges(lead, properties, now)
+	return constants.BulkOutcomeUpdated, ""
+}
+
+func bulkMarkFollowupSent(lead *model.Lead, now time.Time, update *dto.LeadBatchUpdate) (constants.BulkOutcome, string) {
+	if lead.FollowupSent {
+		return constants.BulkOutcomeUnchanged, "follow-up already sent"
+	}
+
+	sent := true
+	update.FollowupSent = &sent
+	update.StatusChanges = []model.StatusChange{{
+		Field:     constants.LeadFieldFollowupSent,
+		OldValue:  "false",
+		NewValue:  "true",
+		ChangedAt: now,
+	}}
+	return constants.BulkOutcomeUpdated, ""
+}
+
+func bulkAddTag(lead *model.Lead, tag string, update *dto.LeadBatchUpdate) (constants.BulkOutcome, string) {
+	if slices.Contains(lead.Tags, tag) {
+		return constants.BulkOutcomeUnchanged, fmt.Sprintf("already tagged %s", tag)
+	}
+	update.AddTag = tag
+	return constants.BulkOutcomeUpdated, ""
+}
+
+// deleteBatch moves the leads to the trash and takes them out of the stats,
+// with a single stats update per day. Only the leads this request moved are
+// taken out, a lead trashed by another request meanwhile was taken out by it.
+// The leads of a day whose stats can't be updated are restored and reported
+// as failed.
+func (s *LeadService) deleteBatch(ctx context.Context, leads map[primitive.ObjectID]*model.Lead, result *dto.BulkResult) error {
+	if len(leads) == 0 {
+		return nil
+	}
+
+	deletedAt := time.Now()
+	ids := make([]primitive.ObjectID, 0, len(leads))
+	for i := range result.Items {
+		if _, exists := leads[result.Items[i].ID]; exists {
+			ids = append(ids, result.Items[i].ID)
+		}
+	}
+	trashedIDs, err := s.repo.SoftDeleteBatch(ctx, ids, deletedAt)
+	if err != nil {
+		return err
+	}
+
+	trashed := make(map[primitive.ObjectID]*model.Lead, len(trashedIDs))
+	trashedLeads := make([]*model.Lead, 0, len(trashedIDs))
+	for _, id := range trashedIDs {
+		deleted := *leads[id]
+		deleted.DeletedAt = &deletedAt
+		trashed[id] = &deleted
+		trashedLeads = append(trashedLeads, &deleted)
+	}
+	failedDays := s.updateStatsBatch(ctx, trashedLeads, -1)
+
+	for i := range result.Items {
+		item := &result.Items[i]
+		deleted, exists := trashed[item.ID]
+		if !exists {
+			continue
+		}
+
+		if err, failed := failedDays[deleted.Date.Format("2006-01-02")]; failed {
+			item.Reason = fmt.Sprintf("failed to update stats: %s", err)
+			// The rollback has to run even if the request was cancelled meanwhile
+			if _, rollbackErr := s.repo.Restore(context.WithoutCancel(ctx), item.ID); rollbackErr != nil {
+				item.Reason = fmt.Sprintf("%s (restoring lead failed: %s)", item.Reason, rollbackErr)
+			}
+			continue
+		}
+
+		item.Outcome, item.Reason = constants.BulkOutcomeUpdated, ""
+		s.audit.Record(ctx, constants.AuditActionDelete, constants.AuditEntityLead, item.ID.Hex(), leads[item.ID], deleted)
+	}
+	return nil
+}
+
+func validateBulkAction(action *dto.BulkLeadAction) error {
+	if err := constants.ValidateBulkAction(action.Action); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidBulkAction, err)
+	}
+	if len(action.IDs) == 0 {
+		return fmt.Errorf("%w: no leads selected", ErrInvalidBulkAction)
+	}
+	if len(action.IDs) > dto.MaxLeadsPerPage {
+		return fmt.Errorf("%w: more than %d leads selected", ErrInvalidBulkAction, dto.MaxLeadsPerPage)
+	}
+
+	switch action.Action {
+	case constants.BulkActionSetTemperature:
+		if action.LeadTemperature != "" && !slices.Contains(constants.LeadTemperatures, action.LeadTemperature) {
+			return fmt.Errorf("%w: invalid temperature %s", ErrInvalidBulkAction, action.LeadTemperature)
+		}
+	case constants.BulkActionSetStatus:
+		if action.ConnectionStatus == "" {
+			return fmt.Errorf("%w: no status selected", ErrInvalidBulkAction)
+		}
+	case constants.BulkActionAddTag:
+		tags := model.NormalizeTags([]string{action.Tag})
+		if len(tags) != 1 {
+			return fmt.Errorf("%w: the tag must be a single tag", ErrInvalidBulkAction)
+		}
+		action.Tag = tags[0]
+	}
+	return nil
+}
+
+// uniqueIDs drops the repeated IDs, keeping the order they were first given in
+func uniqueIDs(ids []primitive.ObjectID) []primitive.ObjectID {
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	unique := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,471 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/model/dto"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidImport is returned for an import whose columns are mapped to
+// unknown fields, to a field twice or not to the name and profile URL
+var ErrInvalidImport = errors.New("invalid import")
+
+// importDateHour is the hour of the day imported dates are stored at. Noon
+// keeps the day of a lead whose date is read back in another time zone, so
+// the stats of the right day are changed when it is deleted.
+const importDateHour = 12
+
+// importBatchSize is how many new leads an import stores per round trip
+const importBatchSize = 500
+
+// mergedImportFields are the fields of a row mergeLead takes over into the
+// tracked lead, it keeps the lead's own outreach, stage, tags and notes
+var mergedImportFields = map[constants.ImportField]bool{
+	constants.ImportFieldName:        true,
+	constants.ImportFieldURL:         true,
+	constants.ImportFieldProfileType: true,
+	constants.ImportFieldPictureURL:  true,
+}
+
+// importRow is a row of an import read into the lead it adds
+type importRow struct {
+	result *dto.ImportRowResult
+	lead   *model.Lead
+}
+
+// ImportLeads adds the leads of the CSV rows as if they had been added on the
+// date of each row, so they are counted in the stats of that day. Invalid
+// rows are reported and left out, the others are imported like CreateLead
+// adds a lead, leaving out the sequences. Repeated profile URLs within the
+// file make the later rows invalid. Merged rows report the columns the merge
+// left out. A dry run only reports what an import would do.
+func (s *LeadService) ImportLeads(ctx context.Context, leadImport *dto.LeadImport) (*dto.ImportResult, error) {
+	resolution := leadImport.OnDuplicate
+	if resolution == "" {
+		resolution = constants.DuplicateResolutionReject
+	}
+	if err := constants.ValidateDuplicateResolution(resolution); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidImport, err)
+	}
+	fields, err := s.fields.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateImportMapping(leadImport, fields); err != nil {
+		return nil, err
+	}
+	pipelines, err := s.pipelines.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rows := make([]importRow, len(leadImport.Rows))
+	firstRows := make(map[string]int)
+	var normalizedURLs []string
+	for i, row := range leadImport.Rows {
+		lead, errs := s.readImportRow(ctx, leadImport, row, pipelines, fields, now)
+		rows[i] = importRow{
+			result: &dto.ImportRowResult{Row: row.Number, Name: lead.Name, Outcome: constants.ImportOutcomeCreated},
+			lead:   lead,
+		}
+		if lead.NormalizedURL != "" {
+			if first, repeated := firstRows[lead.NormalizedURL]; repeated {
+				errs = append(errs, fmt.Sprintf("the profile URL is the one of row %d", first))
+			} else {
+				firstRows[lead.NormalizedURL] = row.Number
+				normalizedURLs = append(normalizedURLs, lead.NormalizedURL)
+			}
+		}
+		if len(errs) > 0 {
+			rows[i].result.Outcome, rows[i].result.Errors = constants.ImportOutcomeInvalid, errs
+		}
+	}
+
+	duplicates, err := s.repo.FindByNormalizedURLs(ctx, normalizedURLs)
+	if err != nil {
+		return nil, err
+	}
+	duplicateOf := make(map[string]*model.Lead, len(duplicates))
+	for i := range duplicates {
+		// Leads stored before profile URLs were normalized hold their URL's key
+		key := duplicates[i].NormalizedURL
+		if key == "" {
+			key = model.NormalizeProfileURL(duplicates[i].URL)
+		}
+		duplicateOf[key] = &duplicates[i]
+	}
+	for _, row := range rows {
+		duplicate, exists := duplicateOf[row.lead.NormalizedURL]
+		if row.lead.NormalizedURL == "" || !exists {
+			continue
+		}
+		row.result.Duplicate = duplicate
+		if row.result.Outcome != constants.ImportOutcomeInvalid {
+			row.result.Outcome = importOutcome(resolution)
+		}
+	}
+
+	if !leadImport.DryRun {
+		s.importRows(ctx, rows, resolution)
+	}
+	result := &dto.ImportResult{DryRun: leadImport.DryRun, Rows: make([]dto.ImportRowResult, len(rows))}
+	for i, row := range rows {
+		if row.result.Outcome == constants.ImportOutcomeMerged {
+			row.result.Ignored = mergeIgnoredColumns(leadImport, leadImport.Rows[i])
+		}
+		result.Rows[i] = *row.result
+	}
+	return result, nil
+}
+
+// importRows adds the leads of the valid rows. Rows whose profile URL is
+// tracked are resolved one by one, the others are stored in batches of
+// importBatchSize, each counted in the stats with one update per day.
+func (s *LeadService) importRows(ctx context.Context, rows []importRow, resolution constants.DuplicateResolution) {
+	var valid []importRow
+	var leads []*model.Lead
+	for _, row := range rows {
+		if row.result.Outcome != constants.ImportOutcomeInvalid {
+			valid = append(valid, row)
+			leads = append(leads, row.lead)
+		}
+	}
+	if err := s.scoring.ScoreNew(ctx, leads...); err != nil {
+		for _, row := range valid {
+			row.result.Outcome, row.result.Errors = constants.ImportOutcomeFailed, []string{err.Error()}
+		}
+		return
+	}
+
+	var batch []importRow
+	for _, row := range valid {
+		if row.result.Duplicate != nil {
+			s.importRow(ctx, row, resolution)
+			continue
+		}
+		batch = append(batch, row)
+		if len(batch) == importBatchSize {
+			s.importBatch(ctx, batch, resolution)
+			batch = nil
+		}
+	}
+	s.importBatch(ctx, batch, resolution)
+}
+
+// importBatch stores the leads of rows whose profile URL wasn't tracked when
+// the import was read. The rows whose profile URL another request added
+// meanwhile are left out of the batch and resolved one by one.
+func (s *LeadService) importBatch(ctx context.Context, rows []importRow, resolution constants.DuplicateResolution) {
+	if len(rows) == 0 {
+		return
+	}
+
+	leads := make([]*model.Lead, len(rows))
+	for i, row := range rows {
+		leads[i] = row.lead
+	}
+	ids, err := s.repo.CreateBatch(ctx, leads)
+	stored := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		stored[id] = true
+	}
+	storedLeads := make([]*model.Lead, 0, len(ids))
+	for _, lead := range leads {
+		if stored[lead.ID] {
+			storedLeads = append(storedLeads, lead)
+		}
+	}
+	failedDays := s.updateStatsBatch(ctx, storedLeads, 1)
+
+	for _, row := range rows {
+		switch {
+		case stored[row.lead.ID]:
+		case err != nil:
+			row.result.Outcome, row.result.Errors = constants.ImportOutcomeFailed, []string{err.Error()}
+			continue
+		default:
+			s.importRow(ctx, row, resolution)
+			continue
+		}
+
+		if statsErr, failed := failedDays[row.lead.Date.Format("2006-01-02")]; failed {
+			reason := fmt.Sprintf("failed to update stats: %s", statsErr)
+			// The rollback has to run even if the request was cancelled meanwhile
+			if rollbackErr := s.repo.Delete(context.WithoutCancel(ctx), row.lead.ID); rollbackErr != nil {
+				reason = fmt.Sprintf("%s (rolling back lead failed: %s)", reason, rollbackErr)
+			}
+			row.result.Outcome, row.result.Errors = constants.ImportOutcomeFailed, []string{reason}
+			continue
+		}
+		row.result.Outcome, row.result.Lead = constants.ImportOutcomeCreated, row.lead
+		s.audit.Record(ctx, constants.AuditActionCreate, constants.AuditEntityLead, row.lead.ID.Hex(), nil, row.lead)
+	}
+}
+
+// importRow adds the lead of a valid row like CreateLead. The duplicate is
+// looked up again, another request may have added or removed it since the
+// import was read.
+func (s *LeadService) importRow(ctx context.Context, row importRow, resolution constants.DuplicateResolution) {
+	created, err := s.addLead(ctx, row.lead, resolution)
+	var duplicateErr *DuplicateLeadError
+	if errors.As(err, &duplicateErr) {
+		row.result.Outcome, row.result.Duplicate = constants.ImportOutcomeSkipped, duplicateErr.Existing
+		return
+	}
+	if err != nil {
+		row.result.Outcome, row.result.Errors = constants.ImportOutcomeFailed, []string{err.Error()}
+		return
+	}
+
+	row.result.Outcome, row.result.Duplicate, row.result.Lead = constants.ImportOutcomeCreated, created.Duplicate, created.Lead
+	if created.Duplicate != nil {
+		row.result.Outcome = importOutcome(created.Resolution)
+	}
+}
+
+// readImportRow reads the lead of a row and lists why the row is invalid.
+// Cells left empty get the values of a lead added today.
+func (s *LeadService) readImportRow(ctx context.Context, leadImport *dto.LeadImport, row dto.ImportRow, pipelines model.Pipelines, fields []model.CustomField, now time.Time) (*model.Lead, []string) {
+	cells := make(map[string]string)
+	for i, target := range leadImport.Mapping {
+		if target != "" && i < len(row.Cells) {
+			cells[target] = row.Cells[i]
+		}
+	}
+	cell := func(field constants.ImportField) string {
+		return cells[string(field)]
+	}
+
+	var errs []string
+	addError := func(field constants.ImportField, message string) {
+		errs = append(errs, fmt.Sprintf("%s %s", field, message))
+	}
+
+	lead := &model.Lead{
+		ID:            primitive.NewObjectID(),
+		Name:          cell(constants.ImportFieldName),
+		URL:           cell(constants.ImportFieldURL),
+		PictureUrl:    cell(constants.ImportFieldPictureURL),
+		NormalizedURL: model.NormalizeProfileURL(cell(constants.ImportFieldURL)),
+		Date:          now,
+		Tags:          model.NormalizeTags([]string{cell(constants.ImportFieldTags)}),
+	}
+	if lead.Name == "" {
+		addError(constants.ImportFieldName, "is empty")
+	}
+	if lead.URL == "" {
+		addError(constants.ImportFieldURL, "is empty")
+	}
+
+	var ok bool
+	if lead.ProfileType, ok = matchImportValue(cell(constants.ImportFieldProfileType), leadImport.ProfileType, constants.ProfileTypes); !ok {
+		addError(constants.ImportFieldProfileType, importValueError(constants.ProfileTypes))
+	}
+	if lead.OutreachType, ok = matchImportValue(cell(constants.ImportFieldOutreachType), leadImport.OutreachType, constants.OutreachTypes); !ok {
+		addError(constants.ImportFieldOutreachType, importValueError(constants.OutreachTypes))
+	}
+
+	if raw := cell(constants.ImportFieldDateAdded); raw != "" {
+		date, err := parseImportDate(raw)
+		switch {
+		case err != nil:
+			addError(constants.ImportFieldDateAdded, err.Error())
+		case model.StartOfDay(date).After(now):
+			addError(constants.ImportFieldDateAdded, "is in the future")
+		case date.After(now):
+			// Noon of today is still ahead in the morning
+			lead.Date = now
+		default:
+			lead.Date = date
+		}
+	}
+
+	// Only a valid outreach type has a pipeline to read the stage with
+	if lead.OutreachType != "" {
+		pipeline := pipelines.For(lead.OutreachType)
+		lead.ConnectionStatus = pipeline.InitialStage()
+		if raw := cell(constants.ImportFieldStage); raw != "" {
+			index := slices.IndexFunc(pipeline.Stages, func(stage model.PipelineStage) bool {
+				return strings.EqualFold(raw, string(stage.Key)) || strings.EqualFold(raw, stage.Label)
+			})
+			if index < 0 {
+				addError(constants.ImportFieldStage, fmt.Sprintf("is not a stage of %s leads", lead.OutreachType))
+			} else {
+				lead.ConnectionStatus = pipeline.Stages[index].Key
+			}
+		}
+	}
+
+	if raw := cell(constants.ImportFieldFollowupSent); raw != "" {
+		sent, err := parseImportBool(raw)
+		if err != nil {
+			addError(constants.ImportFieldFollowupSent, err.Error())
+		}
+		lead.FollowupSent = sent
+	}
+	if raw := cell(constants.ImportFieldFollowupDueAt); raw != "" {
+		dueAt, err := parseImportDate(raw)
+		if err != nil {
+			addError(constants.ImportFieldFollowupDueAt, err.Error())
+		} else {
+			dueAt = model.StartOfDay(dueAt)
+			lead.FollowupDueAt = &dueAt
+		}
+	}
+
+	if text := cell(constants.ImportFieldNotes); text != "" {
+		lead.Notes = model.Notes{{
+			ID:        primitive.NewObjectID(),
+			Text:      text,
+			Author:    Actor(ctx),
+			CreatedAt: lead.Date,
+		}}
+	}
+
+	for _, field := range fields {
+		raw, mapped := cells[constants.FormFieldCustomFieldPrefix+field.Key]
+		if !mapped {
+			continue
+		}
+		value, err := field.Parse(raw)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if value != nil {
+			if lead.CustomFields == nil {
+				lead.CustomFields = make(map[string]model.CustomFieldValue)
+			}
+			lead.CustomFields[field.Key] = *value
+		}
+	}
+	return lead, errs
+}
+
+func validateImportMapping(leadImport *dto.LeadImport, fields []model.CustomField) error {
+	if len(leadImport.Mapping) != len(leadImport.Header) {
+		return fmt.Errorf("%w: %d columns are mapped, the file has %d", ErrInvalidImport, len(leadImport.Mapping), len(leadImport.Header))
+	}
+
+	mapped := make(map[string]bool)
+	for i, target := range leadImport.Mapping {
+		if target == "" {
+			continue
+		}
+		key, custom := strings.CutPrefix(target, constants.FormFieldCustomFieldPrefix)
+		known := slices.Contains(constants.ImportFields, constants.ImportField(target))
+		if custom {
+			known = slices.ContainsFunc(fields, func(field model.CustomField) bool { return field.Key == key })
+		}
+		if !known {
+			return fmt.Errorf("%w: column %s is mapped to the unknown field %s", ErrInvalidImport, leadImport.Header[i], target)
+		}
+		if mapped[target] {
+			return fmt.Errorf("%w: %s is mapped to more than one column", ErrInvalidImport, target)
+		}
+		mapped[target] = true
+	}
+
+	for _, required := range []constants.ImportField{constants.ImportFieldName, constants.ImportFieldURL} {
+		if !mapped[string(required)] {
+			return fmt.Errorf("%w: no column is mapped to %s", ErrInvalidImport, required)
+		}
+	}
+	if leadImport.ProfileType != "" {
+		if err := constants.ValidateProfileType(leadImport.ProfileType); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidImport, err)
+		}
+	}
+	if leadImport.OutreachType != "" {
+		if err := constants.ValidateOutReachType(leadImport.OutreachType); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidImport, err)
+		}
+	}
+	return nil
+}
+
+// matchImportValue returns the value the cell names regardless of its case,
+// or the fallback for an empty cell. It tells whether a value was found.
+func matchImportValue[T ~string](raw string, fallback T, values []T) (T, bool) {
+	if raw == "" {
+		return fallback, fallback != ""
+	}
+	for _, value := range values {
+		if strings.EqualFold(raw, string(value)) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func importValueError[T ~string](values []T) string {
+	names := make([]string, len(values))
+	for i, value := range values {
+		names[i] = string(value)
+	}
+	return "must be one of " + strings.Join(names, ", ")
+}
+
+// parseImportDate reads a date formatted as YYYY-MM-DD, as the export writes
+// it, or a timestamp formatted as RFC 3339
+func parseImportDate(raw string) (time.Time, error) {
+	if timestamp, err := time.Parse(time.RFC3339, raw); err == nil {
+		return timestamp, nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+	if err != nil {
+		return time.Time{}, errors.New("must be a date formatted as YYYY-MM-DD")
+	}
+	return date.Add(importDateHour * time.Hour), nil
+}
+
+// parseImportBool reads yes and no besides the values of strconv.ParseBool
+func parseImportBool(raw string) (bool, error) {
+	switch strings.ToLower(raw) {
+	case "yes", "y":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, errors.New("must be yes or no")
+	}
+	return value, nil
+}
+
+// mergeIgnoredColumns lists the columns of the row with a value that merging
+// the row into the tracked lead leaves out, see mergedImportFields
+func mergeIgnoredColumns(leadImport *dto.LeadImport, row dto.ImportRow) []string {
+	var ignored []string
+	for i, target := range leadImport.Mapping {
+		if target == "" || i >= len(row.Cells) || row.Cells[i] == "" || mergedImportFields[constants.ImportField(target)] {
+			continue
+		}
+		ignored = append(ignored, leadImport.Header[i])
+	}
+	return ignored
+}
+
+// importOutcome is the outcome of a row whose profile URL is tracked already
+func importOutcome(resolution constants.DuplicateResolution) constants.ImportOutcome {
+	switch resolution {
+	case constants.DuplicateResolutionMerge:
+		return constants.ImportOutcomeMerged
+	case constants.DuplicateResolutionCreate:
+		return constants.ImportOutcomeCreated
+	default:
+		return constants.ImportOutcomeSkipped
+	}
+}
@@ -2,6 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
 	"time"
 
 	"leadgentracker/internals/model"
@@ -12,40 +16,665 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// LeadService owns every lead write together with its effect on the stats
+// counters. The lead and stats are stored separately, so a failed stats
+// update is compensated by undoing the lead write, keeping both consistent.
+// Every mutation is recorded in the audit log.
 type LeadService struct {
-	repo repository.LeadRepository
+	repo      repository.LeadRepository
+	statsRepo repository.StatsRepository
+	pipelines *PipelineService
+	sequences *SequenceService
+	scoring   *ScoringService
+	fields    *CustomFieldService
+	companies *CompanyService
+	campaigns *CampaignService
+	audit     *AuditService
+	config    LeadServiceConfig
 }
 
-func NewLeadService(repository repository.LeadRepository) *LeadService {
+type LeadServiceConfig struct {
+	// TrashRetention is how long deleted leads stay in the trash before they
+	// are purged, zero keeps them until they are purged by hand
+	TrashRetention time.Duration
+	// FollowupDelay is how long after a connection was accepted its follow-up
+	// falls due, zero schedules no follow-up automatically
+	FollowupDelay time.Duration
+}
+
+// followupQueueSize is how many overdue and due follow-ups the queue lists each
+const followupQueueSize = 5
+
+// sequenceCompleted is the new value of the status change of a lead's last sequence step
+const sequenceCompleted = "completed"
+
+// ErrNoSequenceStep is returned when completing a sequence step of a lead
+// that has no sequence or has done all of its steps
+var ErrNoSequenceStep = errors.New("lead has no open sequence step")
+
+// ErrInvalidNote is returned when logging a note without text or with an unknown type
+var ErrInvalidNote = errors.New("invalid note")
+
+// ErrLeadChanged is returned when updating a lead another request changed
+// since it was read, the update was validated against its old state
+var ErrLeadChanged = errors.New("lead was changed meanwhile")
+
+// ErrLeadNotTrashed is returned when purging a lead that was not moved to the trash first
+var ErrLeadNotTrashed = errors.New("lead is not in the trash")
+
+// DuplicateLeadError is returned by CreateLead when a lead with the same
+// profile URL exists and duplicates are rejected
+type DuplicateLeadError struct {
+	Existing *model.Lead
+}
+
+func (e *DuplicateLeadError) Error() string {
+	return fmt.Sprintf("lead %s already exists for profile %s", e.Existing.ID.Hex(), e.Existing.NormalizedURL)
+}
+
+// statsAdjustment is the audit snapshot of a change of the stats counters
+type statsAdjustment struct {
+	OutreachType constants.OutreachType `json:"outreachType"`
+	Date         string                 `json:"date"`
+	Delta        int                    `json:"delta"`
+}
+
+func NewLeadService(repository repository.LeadRepository, statsRepository repository.StatsRepository, pipelineService *PipelineService, sequenceService *SequenceService, scoringService *ScoringService, customFieldService *CustomFieldService, companyService *CompanyService, campaignService *CampaignService, auditService *AuditService, config LeadServiceConfig) *LeadService {
 	return &LeadService{
-		repo: repository,
+		repo:      repository,
+		statsRepo: statsRepository,
+		pipelines: pipelineService,
+		sequences: sequenceService,
+		scoring:   scoringService,
+		fields:    customFieldService,
+		companies: companyService,
+		campaigns: campaignService,
+		audit:     auditService,
+		config:    config,
 	}
 }
 
-func (s *LeadService) CreateLead(ctx context.Context, leadProperties *dto.NewLeadProperties) error {
-	return s.repo.Create(ctx, &model.Lead{
+func (s *LeadService) Config() LeadServiceConfig {
+	return s.config
+}
+
+// CreateLead adds a new lead unless a lead with the same normalized profile
+// URL exists, in which case leadProperties.OnDuplicate decides whether the
+// request is rejected with a DuplicateLeadError, merged into the existing lead
+// or added anyway as a lead referencing the existing one. A new lead is
+// attached to the requested sequence, or to its outreach type's default one,
+// and assigned to the requested campaign. A merged lead keeps its campaign
+// unless a campaign was requested.
+func (s *LeadService) CreateLead(ctx context.Context, leadProperties *dto.NewLeadProperties) (*dto.CreateLeadResult, error) {
+	resolution := leadProperties.OnDuplicate
+	if resolution == "" {
+		resolution = constants.DuplicateResolutionReject
+	}
+	if err := constants.ValidateDuplicateResolution(resolution); err != nil {
+		return nil, err
+	}
+
+	pipeline, err := s.pipelines.Get(ctx, leadProperties.OutreachType)
+	if err != nil {
+		return nil, err
+	}
+
+	sequence, err := s.sequences.ForNewLead(ctx, leadProperties.SequenceID, leadProperties.OutreachType)
+	if err != nil {
+		return nil, err
+	}
+	// Fails with ErrCampaignNotFound when assigning the lead to a missing campaign
+	if leadProperties.CampaignID != nil {
+		if _, err := s.campaigns.Get(ctx, *leadProperties.CampaignID); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	lead := &model.Lead{
 		ID:               primitive.NewObjectID(),
-		ConnectionStatus: constants.ConnectionStatusPending,
-		LeadTemperature:  constants.LeadTemperatureCold,
+		ConnectionStatus: pipeline.InitialStage(),
 		ProfileType:      leadProperties.ProfileType,
 		OutreachType:     leadProperties.OutreachType,
-		Date:             time.Now(),
+		Date:             now,
 		URL:              leadProperties.Url,
 		Name:             leadProperties.Name,
 		FollowupSent:     false,
-		Notes:            "",
 		PictureUrl:       leadProperties.PictureUrl,
+		NormalizedURL:    model.NormalizeProfileURL(leadProperties.Url),
+		CampaignID:       leadProperties.CampaignID,
+	}
+	if sequence != nil {
+		lead.Sequence = sequence.Start(now)
+	}
+	if err := s.scoring.ScoreNew(ctx, lead); err != nil {
+		return nil, err
+	}
+	return s.addLead(ctx, lead, resolution)
+}
+
+// addLead inserts the scored lead unless its profile URL is tracked already,
+// in which case the resolution decides, see CreateLead
+func (s *LeadService) addLead(ctx context.Context, lead *model.Lead, resolution constants.DuplicateResolution) (*dto.CreateLeadResult, error) {
+	// A concurrent request can add the same profile between the lookup and the
+	// insert. The insert then fails on the unique profile URL and the lookup is
+	// repeated once, finding the lead the other request added.
+	for attempt := 0; ; attempt++ {
+		if lead.NormalizedURL != "" {
+			existing, err := s.repo.FindByNormalizedURL(ctx, lead.NormalizedURL)
+			if err == nil {
+				return s.resolveDuplicate(ctx, lead, existing, resolution)
+			}
+			if !errors.Is(err, repository.ErrLeadNotFound) {
+				return nil, err
+			}
+		}
+
+		err := s.insertLead(ctx, lead)
+		if errors.Is(err, repository.ErrDuplicateProfileURL) && attempt == 0 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &dto.CreateLeadResult{Lead: lead}, nil
+	}
+}
+
+func (s *LeadService) resolveDuplicate(ctx context.Context, lead *model.Lead, existing *model.Lead, resolution constants.DuplicateResolution) (*dto.CreateLeadResult, error) {
+	result := &dto.CreateLeadResult{Duplicate: existing, Resolution: resolution}
+
+	switch resolution {
+	case constants.DuplicateResolutionMerge:
+		merged, err := s.mergeLead(ctx, existing, lead)
+		if err != nil {
+			return nil, err
+		}
+		result.Lead = merged
+	case constants.DuplicateResolutionCreate:
+		// Only the original keeps the profile URL key, the duplicate points to it
+		lead.NormalizedURL = ""
+		lead.DuplicateOf = &existing.ID
+		if err := s.insertLead(ctx, lead); err != nil {
+			return nil, err
+		}
+		result.Lead = lead
+	default:
+		return nil, &DuplicateLeadError{Existing: existing}
+	}
+	return result, nil
+}
+
+// mergeLead takes the profile details of the new lead over into the existing
+// one. The outreach was already counted for the existing lead, so the stats
+// only change when the existing lead has to be restored from the trash.
+func (s *LeadService) mergeLead(ctx context.Context, existing *model.Lead, lead *model.Lead) (*model.Lead, error) {
+	if existing.IsTrashed() {
+		if err := s.RestoreLead(ctx, existing.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	before, err := s.repo.FindByID(ctx, existing.ID)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := s.repo.UpdateProfile(ctx, &dto.LeadProfileProperties{
+		ID:          existing.ID,
+		ProfileType: lead.ProfileType,
+		Name:        lead.Name,
+		PictureUrl:  lead.PictureUrl,
+		CampaignID:  lead.CampaignID,
 	})
+	if err != nil {
+		return nil, err
+	}
+	// The profile type is one of the scored signals
+	merged, err = s.scoring.Rescore(ctx, merged)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, constants.AuditActionUpdate, constants.AuditEntityLead, merged.ID.Hex(), before, merged)
+	return merged, nil
 }
 
+// insertLead stores a new lead and counts it in the stats of the day it was added
+func (s *LeadService) insertLead(ctx context.Context, lead *model.Lead) error {
+	if err := s.repo.Create(ctx, lead); err != nil {
+		return err
+	}
+
+	if err := s.updateStats(ctx, lead, 1); err != nil {
+		// The rollback has to run even if the request was cancelled meanwhile
+		if rollbackErr := s.repo.Delete(context.WithoutCancel(ctx), lead.ID); rollbackErr != nil {
+			return fmt.Errorf("failed to update stats: %w (rolling back lead %s failed: %s)", err, lead.ID.Hex(), rollbackErr)
+		}
+		return fmt.Errorf("failed to update stats: %w", err)
+	}
+
+	s.audit.Record(ctx, constants.AuditActionCreate, constants.AuditEntityLead, lead.ID.Hex(), nil, lead)
+	return nil
+}
+
+// UpdateLead applies the update and records every changed status field in the
+// lead's history. Moves its pipeline does not allow fail with a TransitionError.
+// Accepting a connection schedules its follow-up unless one was set by hand.
+// Entered custom field values that don't fit their field fail with
+// ErrInvalidCustomFieldValue. The lead is rescored with its updated stage and
+// follow-up. Notes are not part of the update, see AddNote. A lead in the
+// trash fails with repository.ErrLeadNotFound, one changed by another request
+// while it was updated fails with ErrLeadChanged.
 func (s *LeadService) UpdateLead(ctx context.Context, updatedLead *dto.UpdateLeadProperties) (*model.Lead, error) {
-	return s.repo.Update(ctx, updatedLead)
+	current, err := s.repo.FindByID(ctx, updatedLead.ID)
+	if err != nil {
+		return nil, err
+	}
+	// A lead in the trash is restored before it can be changed
+	if current.IsTrashed() {
+		return nil, fmt.Errorf("%w: %s", repository.ErrLeadNotFound, current.ID.Hex())
+	}
+	updatedLead.Version = current.Version
+
+	pipeline, err := s.pipelines.Get(ctx, current.OutreachType)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTransition(pipeline, current, updatedLead); err != nil {
+		return nil, err
+	}
+	updatedLead.CustomFields, err = s.fields.applyValues(ctx, current.CustomFields, updatedLead.CustomFieldInput)
+	if err != nil {
+		return nil, err
+	}
+	// Fails with ErrCompanyNotFound when linking the lead to a missing company
+	if updatedLead.CompanyID != nil {
+		if _, err := s.companies.Get(ctx, *updatedLead.CompanyID); err != nil {
+			return nil, err
+		}
+	}
+	// Fails with ErrCampaignNotFound when assigning the lead to a missing campaign
+	if updatedLead.CampaignID != nil {
+		if _, err := s.campaigns.Get(ctx, *updatedLead.CampaignID); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	s.scheduleFollowup(current, updatedLead, now)
+	updatedLead.StatusChanges = statusChanges(current, updatedLead, now)
+	lead, err := s.repo.Update(ctx, updatedLead)
+	if errors.Is(err, repository.ErrLeadNotFound) {
+		return nil, s.updateConflict(ctx, current)
+	}
+	if err != nil {
+		return nil, err
+	}
+	lead, err = s.scoring.Rescore(ctx, lead)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, constants.AuditActionUpdate, constants.AuditEntityLead, lead.ID.Hex(), current, lead)
+	return lead, nil
+}
+
+// updateConflict tells why the update of the lead found it no longer as it was
+// read: ErrLeadChanged when it was changed, ErrLeadNotFound when it was
+// deleted or moved to the trash meanwhile
+func (s *LeadService) updateConflict(ctx context.Context, read *model.Lead) error {
+	latest, err := s.repo.FindByID(ctx, read.ID)
+	if err != nil {
+		return err
+	}
+	if latest.IsTrashed() {
+		return fmt.Errorf("%w: %s", repository.ErrLeadNotFound, read.ID.Hex())
+	}
+	return fmt.Errorf("%w: %s", ErrLeadChanged, read.ID.Hex())
+}
+
+// AddNote logs a note on the lead, written by the actor of ctx. Malformed
+// notes fail with ErrInvalidNote. Like all note changes, the lead is rescored
+// for the keywords of its notes.
+func (s *LeadService) AddNote(ctx context.Context, properties *dto.NoteProperties) (*model.Lead, error) {
+	note := model.Note{
+		ID:        primitive.NewObjectID(),
+		Type:      properties.Type,
+		Text:      properties.Text,
+		Author:    Actor(ctx),
+		CreatedAt: time.Now(),
+	}
+	if err := validateNote(&note); err != nil {
+		return nil, err
+	}
+
+	return s.changeNotes(ctx, properties.LeadID, func() (*model.Lead, error) {
+		return s.repo.AddNote(ctx, properties.LeadID, note)
+	})
+}
+
+// UpdateNote edits the type and text of one of the lead's notes, keeping its
+// author and time. A note the lead doesn't have fails with ErrNoteNotFound.
+func (s *LeadService) UpdateNote(ctx context.Context, properties *dto.NoteProperties) (*model.Lead, error) {
+	now := time.Now()
+	note := model.Note{
+		ID:        properties.NoteID,
+		Type:      properties.Type,
+		Text:      properties.Text,
+		UpdatedAt: &now,
+	}
+	if err := validateNote(&note); err != nil {
+		return nil, err
+	}
+
+	return s.changeNotes(ctx, properties.LeadID, func() (*model.Lead, error) {
+		return s.repo.UpdateNote(ctx, properties.LeadID, note)
+	})
+}
+
+func (s *LeadService) DeleteNote(ctx context.Context, leadID primitive.ObjectID, noteID primitive.ObjectID) (*model.Lead, error) {
+	return s.changeNotes(ctx, leadID, func() (*model.Lead, error) {
+		return s.repo.DeleteNote(ctx, leadID, noteID)
+	})
+}
+
+// changeNotes applies a change of the lead's notes, rescores the lead and
+// records the change in the audit log
+func (s *LeadService) changeNotes(ctx context.Context, leadID primitive.ObjectID, change func() (*model.Lead, error)) (*model.Lead, error) {
+	current, err := s.repo.FindByID(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+
+	lead, err := change()
+	if err != nil {
+		return nil, err
+	}
+	lead, err = s.scoring.Rescore(ctx, lead)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, constants.AuditActionUpdate, constants.AuditEntityLead, lead.ID.Hex(), current, lead)
+	return lead, nil
+}
+
+func validateNote(note *model.Note) error {
+	note.Normalize()
+	if err := note.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidNote, err)
+	}
+	return nil
+}
+
+// scheduleFollowup sets the follow-up of a connection that the update accepts
+// to the configured delay after now, unless the update brings its own due date
+func (s *LeadService) scheduleFollowup(current *model.Lead, update *dto.UpdateLeadProperties, now time.Time) {
+	accepted := current.OutreachType == constants.OutreachTypeConnection &&
+		current.ConnectionStatus != constants.ConnectionStatusAccepted &&
+		update.ConnectionStatus == constants.ConnectionStatusAccepted
+	if !accepted || update.FollowupDueAt != nil || s.config.FollowupDelay <= 0 {
+		return
+	}
+
+	dueAt := model.StartOfDay(now.Add(s.config.FollowupDelay))
+	update.FollowupDueAt = &dueAt
+}
+
+// CompleteSequenceStep marks the lead's current sequence step as done and
+// schedules the next one, recording the move in the lead's history
+func (s *LeadService) CompleteSequenceStep(ctx context.Context, id primitive.ObjectID) (*model.Lead, error) {
+	current, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if current.Sequence == nil || current.Sequence.Current() == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSequenceStep, id.Hex())
+	}
+
+	now := time.Now()
+	progress := current.Sequence.Advance(now)
+	change := model.StatusChange{
+		Field:     constants.LeadFieldSequenceStep,
+		OldValue:  current.Sequence.Current().Name,
+		NewValue:  sequenceCompleted,
+		ChangedAt: now,
+	}
+	if next := progress.Current(); next != nil {
+		change.NewValue = next.Name
+	}
+
+	// Fails with ErrLeadNotFound if the step was completed by another request meanwhile
+	lead, err := s.repo.AdvanceSequence(ctx, id, current.Sequence.NextStep, progress, change)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, constants.AuditActionUpdate, constants.AuditEntityLead, lead.ID.Hex(), current, lead)
+	return lead, nil
 }
 
+// DeleteLead moves the lead to the trash and takes it out of the stats of the
+// day it was added. A lead that is missing or in the trash already fails with
+// ErrLeadNotFound and leaves the stats alone, so a repeated delete only
+// counts once.
 func (s *LeadService) DeleteLead(ctx context.Context, id primitive.ObjectID) error {
-	return s.repo.Delete(ctx, id)
+	lead, err := s.repo.SoftDelete(ctx, id, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := s.updateStats(ctx, lead, -1); err != nil {
+		// The rollback has to run even if the request was cancelled meanwhile
+		if _, rollbackErr := s.repo.Restore(context.WithoutCancel(ctx), lead.ID); rollbackErr != nil {
+			return fmt.Errorf("failed to update stats: %w (restoring lead %s failed: %s)", err, lead.ID.Hex(), rollbackErr)
+		}
+		return fmt.Errorf("failed to update stats: %w", err)
+	}
+
+	before := *lead
+	before.DeletedAt = nil
+	s.audit.Record(ctx, constants.AuditActionDelete, constants.AuditEntityLead, lead.ID.Hex(), &before, lead)
+	return nil
+}
+
+// RestoreLead moves the lead out of the trash and counts it in the stats again
+func (s *LeadService) RestoreLead(ctx context.Context, id primitive.ObjectID) error {
+	trashed, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Fails with ErrLeadNotFound unless the lead is in the trash
+	lead, err := s.repo.Restore(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.updateStats(ctx, lead, 1); err != nil {
+		// The rollback has to run even if the request was cancelled meanwhile
+		if _, rollbackErr := s.repo.SoftDelete(context.WithoutCancel(ctx), lead.ID, *trashed.DeletedAt); rollbackErr != nil {
+			return fmt.Errorf("failed to update stats: %w (moving lead %s back to trash failed: %s)", err, lead.ID.Hex(), rollbackErr)
+		}
+		return fmt.Errorf("failed to update stats: %w", err)
+	}
+
+	s.audit.Record(ctx, constants.AuditActionRestore, constants.AuditEntityLead, lead.ID.Hex(), trashed, lead)
+	return nil
+}
+
+// PurgeLead permanently removes a lead from the trash. It was already taken
+// out of the stats when it was moved there.
+func (s *LeadService) PurgeLead(ctx context.Context, id primitive.ObjectID) error {
+	lead, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !lead.IsTrashed() {
+		return fmt.Errorf("%w: %s", ErrLeadNotTrashed, id.Hex())
+	}
+
+	purged, err := s.purge(ctx, lead)
+	if err != nil {
+		return err
+	}
+	if !purged {
+		return fmt.Errorf("%w: %s", ErrLeadNotTrashed, id.Hex())
+	}
+	return nil
+}
+
+// PurgeExpiredTrash permanently removes the leads that stayed in the trash
+// longer than the configured retention and returns how many were removed
+func (s *LeadService) PurgeExpiredTrash(ctx context.Context) (int, error) {
+	if s.config.TrashRetention <= 0 {
+		return 0, nil
+	}
+
+	expired, err := s.repo.ListTrashedBefore(ctx, time.Now().Add(-s.config.TrashRetention))
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range expired {
+		purged, err := s.purge(ctx, &expired[i])
+		if err != nil {
+			return count, err
+		}
+		if purged {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// purge removes the trashed lead and tells whether it did. A lead restored
+// since it was read is live again and counted in the stats, so it is kept.
+func (s *LeadService) purge(ctx context.Context, lead *model.Lead) (bool, error) {
+	err := s.repo.DeleteTrashed(ctx, lead.ID)
+	if errors.Is(err, repository.ErrLeadNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	s.audit.Record(ctx, constants.AuditActionPurge, constants.AuditEntityLead, lead.ID.Hex(), lead, nil)
+	return true, nil
+}
+
+// GetLead returns the lead, also when it is in the trash
+func (s *LeadService) GetLead(ctx context.Context, id primitive.ObjectID) (*model.Lead, error) {
+	return s.repo.FindByID(ctx, id)
 }
 
 func (s *LeadService) GetAllLeadsPaged(ctx context.Context, filter *dto.LeadFilter) ([]model.Lead, int, error) {
 	return s.repo.ListPaged(ctx, filter)
 }
+
+// ExportLeads returns every lead matching the filter, ignoring its paging
+func (s *LeadService) ExportLeads(ctx context.Context, filter *dto.LeadFilter) ([]model.Lead, error) {
+	exportFilter := *filter
+	exportFilter.Page = 1
+	exportFilter.LeadsPerPage = math.MaxInt32
+	leads, _, err := s.repo.ListPaged(ctx, &exportFilter)
+	return leads, err
+}
+
+// GetTagCounts returns how many leads carry each tag, the most used tags first
+func (s *LeadService) GetTagCounts(ctx context.Context) ([]model.TagCount, error) {
+	return s.repo.CountTags(ctx)
+}
+
+// GetFollowupQueue returns the overdue follow-ups and the ones due today
+func (s *LeadService) GetFollowupQueue(ctx context.Context) (*dto.FollowupQueue, error) {
+	overdue, overduePages, err := s.repo.ListPaged(ctx, dto.NewPagedFollowupFilter(constants.FollowupStateOverdue, followupQueueSize))
+	if err != nil {
+		return nil, err
+	}
+
+	due, duePages, err := s.repo.ListPaged(ctx, dto.NewPagedFollowupFilter(constants.FollowupStateDue, followupQueueSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.FollowupQueue{
+		Overdue:     overdue,
+		MoreOverdue: overduePages > 1,
+		Due:         due,
+		MoreDue:     duePages > 1,
+	}, nil
+}
+
+func (s *LeadService) GetTrashPaged(ctx context.Context, page int) ([]model.Lead, int, error) {
+	return s.repo.ListPaged(ctx, dto.NewPagedTrashFilter(page))
+}
+
+// updateStats adjusts the stats counters of the day the lead was added by delta
+func (s *LeadService) updateStats(ctx context.Context, lead *model.Lead, delta int) error {
+	if err := s.statsRepo.Update(ctx, lead.OutreachType, lead.Date, delta); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, constants.AuditActionStatsUpdate, constants.AuditEntityStats, lead.ID.Hex(), nil, statsAdjustment{
+		OutreachType: lead.OutreachType,
+		Date:         lead.Date.Format("2006-01-02"),
+		Delta:        delta,
+	})
+	return nil
+}
+
+// statsDayAdjustment is the audit snapshot of a change of a day's stats counters
+type statsDayAdjustment struct {
+	Date        string `json:"date"`
+	Connections int    `json:"connections"`
+	InMails     int    `json:"inMails"`
+}
+
+// updateStatsBatch adjusts the stats counters of the days the leads were added
+// by delta, with a single update per day. It returns the errors of the days
+// that failed by date, the leads of the other days are counted.
+func (s *LeadService) updateStatsBatch(ctx context.Context, leads []*model.Lead, delta int) map[string]error {
+	days := make(map[string]*statsDayAdjustment)
+	dates := make(map[string]time.Time)
+	for _, lead := range leads {
+		date := lead.Date.Format("2006-01-02")
+		adjustment, exists := days[date]
+		if !exists {
+			adjustment = &statsDayAdjustment{Date: date}
+			days[date], dates[date] = adjustment, lead.Date
+		}
+		switch lead.OutreachType {
+		case constants.OutreachTypeConnection:
+			adjustment.Connections += delta
+		case constants.OutreachTypeInMail:
+			adjustment.InMails += delta
+		}
+	}
+
+	failed := make(map[string]error)
+	for date, adjustment := range days {
+		err := s.statsRepo.UpdateDay(ctx, dates[date], model.Stats{Connections: adjustment.Connections, InMails: adjustment.InMails})
+		if err != nil {
+			failed[date] = err
+			continue
+		}
+		s.audit.Record(ctx, constants.AuditActionStatsUpdate, constants.AuditEntityStats, date, nil, adjustment)
+	}
+	return failed
+}
+
+// statusChanges lists the tracked fields the update changes
+func statusChanges(current *model.Lead, update *dto.UpdateLeadProperties, now time.Time) []model.StatusChange {
+	var changes []model.StatusChange
+	addChange := func(field constants.LeadField, oldValue string, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, model.StatusChange{Field: field, OldValue: oldValue, NewValue: newValue, ChangedAt: now})
+		}
+	}
+
+	addChange(constants.LeadFieldConnectionStatus, string(current.ConnectionStatus), string(update.ConnectionStatus))
+	addChange(constants.LeadFieldFollowupSent, strconv.FormatBool(current.FollowupSent), strconv.FormatBool(update.FollowupSent))
+	return changes
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidMessageTemplate is returned when saving a malformed template or
+// using a template made for another outreach type
+var ErrInvalidMessageTemplate = errors.New("invalid message template")
+
+// MessageTemplateService manages the library of message templates and writes
+// the messages to leads with them
+type MessageTemplateService struct {
+	repo      repository.MessageTemplateRepository
+	leadRepo  repository.LeadRepository
+	fields    *CustomFieldService
+	companies *CompanyService
+	audit     *AuditService
+}
+
+func NewMessageTemplateService(templateRepository repository.MessageTemplateRepository, leadRepository repository.LeadRepository, customFieldService *CustomFieldService, companyService *CompanyService, auditService *AuditService) *MessageTemplateService {
+	return &MessageTemplateService{
+		repo:      templateRepository,
+		leadRepo:  leadRepository,
+		fields:    customFieldService,
+		companies: companyService,
+		audit:     auditService,
+	}
+}
+
+// GetAll returns the templates ordered by name
+func (s *MessageTemplateService) GetAll(ctx context.Context) (model.MessageTemplates, error) {
+	return s.repo.List(ctx)
+}
+
+// CountUsage returns how many leads outside the trash were last sent a
+// message with each template, by template ID
+func (s *MessageTemplateService) CountUsage(ctx context.Context) (map[primitive.ObjectID]int, error) {
+	return s.leadRepo.CountByMessageTemplate(ctx)
+}
+
+func (s *MessageTemplateService) Create(ctx context.Context, messageTemplate *model.MessageTemplate) error {
+	messageTemplate.ID = primitive.NewObjectID()
+	if err := s.validate(ctx, messageTemplate); err != nil {
+		return err
+	}
+
+	if err := s.repo.Create(ctx, messageTemplate); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, constants.AuditActionCreate, constants.AuditEntityTemplate, messageTemplate.ID.Hex(), nil, messageTemplate)
+	return nil
+}
+
+func (s *MessageTemplateService) Update(ctx context.Context, messageTemplate *model.MessageTemplate) error {
+	current, err := s.repo.FindByID(ctx, messageTemplate.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.validate(ctx, messageTemplate); err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(ctx, messageTemplate); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, constants.AuditActionUpdate, constants.AuditEntityTemplate, messageTemplate.ID.Hex(), current, messageTemplate)
+	return nil
+}
+
+// Delete removes the template, the leads written with it keep its name
+func (s *MessageTemplateService) Delete(ctx context.Context, id primitive.ObjectID) error {
+	current, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, constants.AuditActionDelete, constants.AuditEntityTemplate, id.Hex(), current, nil)
+	return nil
+}
+
+// Preview returns the lead and the template its message would be written
+// with, without recording anything. A template for another outreach type or
+// one that can't be rendered for the lead fails with ErrInvalidMessageTemplate.
+func (s *MessageTemplateService) Preview(ctx context.Context, leadID primitive.ObjectID, templateID primitive.ObjectID) (*model.Lead, *model.MessageTemplate, error) {
+	lead, err := s.leadRepo.FindByID(ctx, leadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	messageTemplate, err := s.repo.FindByID(ctx, templateID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if messageTemplate.OutreachType != "" && messageTemplate.OutreachType != lead.OutreachType {
+		return nil, nil, fmt.Errorf("%w: %s is a template for %s leads", ErrInvalidMessageTemplate, messageTemplate.Name, messageTemplate.OutreachType)
+	}
+
+	// The message is rendered by the caller, this only makes sure it can be
+	if _, err := s.Render(ctx, lead, messageTemplate); err != nil {
+		return nil, nil, err
+	}
+	return lead, messageTemplate, nil
+}
+
+// Use records that the message written with the template was sent to the
+// lead, the template can be previewed for the lead first, see Preview
+func (s *MessageTemplateService) Use(ctx context.Context, leadID primitive.ObjectID, templateID primitive.ObjectID) (*model.Lead, error) {
+	current, messageTemplate, err := s.Preview(ctx, leadID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	lead, err := s.leadRepo.AddMessageTemplateUsage(ctx, leadID, &model.MessageTemplateUsage{
+		TemplateID: messageTemplate.ID,
+		Name:       messageTemplate.Name,
+		UsedAt:     time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, constants.AuditActionUpdate, constants.AuditEntityLead, lead.ID.Hex(), current, lead)
+	return lead, nil
+}
+
+// Render fills the template in for the lead, its company and custom fields.
+// A template referring to values leads don't have fails with ErrInvalidMessageTemplate.
+func (s *MessageTemplateService) Render(ctx context.Context, lead *model.Lead, messageTemplate *model.MessageTemplate) (string, error) {
+	fields, err := s.fields.GetAll(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var company *model.Company
+	if lead.CompanyID != nil {
+		company, err = s.companies.Get(ctx, *lead.CompanyID)
+		if err != nil && !errors.Is(err, repository.ErrCompanyNotFound) {
+			return "", err
+		}
+	}
+
+	message, err := messageTemplate.Render(model.NewMessageData(lead, company, fields))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidMessageTemplate, err)
+	}
+	return message, nil
+}
+
+// validate normalizes the template and checks it renders with the current custom fields
+func (s *MessageTemplateService) validate(ctx context.Context, messageTemplate *model.MessageTemplate) error {
+	messageTemplate.Normalize()
+	fields, err := s.fields.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	if err := messageTemplate.Validate(fields); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidMessageTemplate, err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/repository"
+)
+
+// ErrInvalidPipeline is returned when saving a pipeline that is malformed or
+// drops a stage that leads are still in
+var ErrInvalidPipeline = errors.New("invalid pipeline")
+
+// PipelineService manages the stored pipeline of every outreach type
+type PipelineService struct {
+	repo     repository.PipelineRepository
+	leadRepo repository.LeadRepository
+	audit    *AuditService
+}
+
+func NewPipelineService(pipelineRepository repository.PipelineRepository, leadRepository repository.LeadRepository, auditService *AuditService) *PipelineService {
+	return &PipelineService{
+		repo:     pipelineRepository,
+		leadRepo: leadRepository,
+		audit:    auditService,
+	}
+}
+
+// EnsureDefaults stores the default pipeline of every outreach type that has none yet
+func (s *PipelineService) EnsureDefaults(ctx context.Context) error {
+	stored, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[constants.OutreachType]bool, len(stored))
+	for _, pipeline := range stored {
+		existing[pipeline.OutreachType] = true
+	}
+
+	for _, pipeline := range model.DefaultPipelines() {
+		if existing[pipeline.OutreachType] {
+			continue
+		}
+		if err := s.repo.Save(ctx, &pipeline); err != nil {
+			return err
+		}
+		s.audit.Record(ctx, constants.AuditActionCreate, constants.AuditEntityPipeline, string(pipeline.OutreachType), nil, pipeline)
+	}
+	return nil
+}
+
+// GetAll returns the pipeline of every outreach type. An outreach type
+// without a stored pipeline gets its default one.
+func (s *PipelineService) GetAll(ctx context.Context) (model.Pipelines, error) {
+	stored, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pipelines := make(model.Pipelines)
+	for _, pipeline := range model.DefaultPipelines() {
+		pipelines[pipeline.OutreachType] = &pipeline
+	}
+	for _, pipeline := range stored {
+		pipelines[pipeline.OutreachType] = &pipeline
+	}
+	return pipelines, nil
+}
+
+// Get returns the pipeline of the outreach type, see GetAll
+func (s *PipelineService) Get(ctx context.Context, outreachType constants.OutreachType) (*model.Pipeline, error) {
+	pipelines, err := s.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pipelines.For(outreachType), nil
+}
+
+// Save replaces the pipeline of its outreach type. Stages can be added,
+// renamed and reordered, but a stage can only be removed once no lead is in it.
+func (s *PipelineService) Save(ctx context.Context, pipeline *model.Pipeline) error {
+	if err := pipeline.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPipeline, err)
+	}
+
+	inUse, err := s.leadRepo.ListStatusesInUse(ctx, pipeline.OutreachType)
+	if err != nil {
+		return err
+	}
+	for _, status := range inUse {
+		if !pipeline.HasStage(status) {
+			return fmt.Errorf("%w: stage %s still has leads", ErrInvalidPipeline, status)
+		}
+	}
+
+	current, err := s.Get(ctx, pipeline.OutreachType)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Save(ctx, pipeline); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, constants.AuditActionUpdate, constants.AuditEntityPipeline, string(pipeline.OutreachType), current, pipeline)
+	return nil
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/model/dto"
+	"leadgentracker/internals/repository"
+)
+
+// ErrInvalidScoring is returned when saving a malformed scoring config
+var ErrInvalidScoring = errors.New("invalid scoring config")
+
+// scoringAuditID is the entity ID of the scoring config in the audit log
+const scoringAuditID = "leadScore"
+
+// ScoringService rates leads by the signals of the scoring config. Scores
+// depend on the time since a lead's last response and on its follow-up
+// state, so they are kept current by rescoring the leads periodically.
+type ScoringService struct {
+	repo     repository.ScoringRepository
+	leadRepo repository.LeadRepository
+	audit    *AuditService
+}
+
+func NewScoringService(scoringRepository repository.ScoringRepository, leadRepository repository.LeadRepository, auditService *AuditService) *ScoringService {
+	return &ScoringService{
+		repo:     scoringRepository,
+		leadRepo: leadRepository,
+		audit:    auditService,
+	}
+}
+
+// Get returns the saved scoring config, or the default one when none was saved
+func (s *ScoringService) Get(ctx context.Context) (*model.ScoringConfig, error) {
+	config, err := s.repo.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		defaults := model.DefaultScoringConfig()
+		return &defaults, nil
+	}
+	return config, nil
+}
+
+// Save replaces the scoring config and rescores every lead with it
+func (s *ScoringService) Save(ctx context.Context, config *model.ScoringConfig) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidScoring, err)
+	}
+
+	current, err := s.Get(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Save(ctx, config); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, constants.AuditActionUpdate, constants.AuditEntityScoring, scoringAuditID, current, config)
+
+	_, err = s.RescoreAll(ctx)
+	return err
+}
+
+// ScoreNew sets the scores of leads that are not stored yet, reading the
+// scoring config once for all of them
+func (s *ScoringService) ScoreNew(ctx context.Context, leads ...*model.Lead) error {
+	config, err := s.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, lead := range leads {
+		scored := scoreLead(config, lead, now)
+		lead.Score = scored.Score
+		lead.ScoreBreakdown = scored.Breakdown
+		lead.LeadTemperature = scored.LeadTemperature
+	}
+	return nil
+}
+
+// Rescore rates a stored lead again and returns it with its current score.
+// A lead changed by another request since it was read is read and rated again
+// once, so the score stored is the one of its latest version.
+func (s *ScoringService) Rescore(ctx context.Context, lead *model.Lead) (*model.Lead, error) {
+	config, err := s.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	rescored, _, err := s.rescore(ctx, config, lead, now)
+	if !errors.Is(err, repository.ErrLeadNotFound) {
+		return rescored, err
+	}
+
+	latest, err := s.leadRepo.FindByID(ctx, lead.ID)
+	if err != nil {
+		return nil, err
+	}
+	rescored, _, err = s.rescore(ctx, config, latest, now)
+	return rescored, err
+}
+
+// RescoreAll rates every lead outside the trash again and returns how many
+// scores changed. A lead changed or deleted since the leads were listed is
+// skipped, the request changing it rescored it already.
+func (s *ScoringService) RescoreAll(ctx context.Context) (int, error) {
+	config, err := s.Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	leads, err := s.leadRepo.ListActive(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	changed := 0
+	for i := range leads {
+		_, updated, err := s.rescore(ctx, config, &leads[i], now)
+		if errors.Is(err, repository.ErrLeadNotFound) {
+			continue
+		}
+		if err != nil {
+			return changed, err
+		}
+		if updated {
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// RescoreBatch rates the stored leads again, writes the changed scores in one
+// round trip and returns the leads with their new scores. The score of a lead
+// changed since it was read is not written, the request changing it rescores it.
+func (s *ScoringService) RescoreBatch(ctx context.Context, leads []model.Lead) ([]model.Lead, error) {
+	config, err := s.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rescored := slices.Clone(leads)
+	var changed []*dto.LeadScoreProperties
+	for i := range rescored {
+		scored := scoreLead(config, &rescored[i], now)
+		if scored.Score == rescored[i].Score &&
+			scored.LeadTemperature == rescored[i].LeadTemperature &&
+			slices.Equal(scored.Breakdown, rescored[i].ScoreBreakdown) {
+			continue
+		}
+		rescored[i].Score = scored.Score
+		rescored[i].ScoreBreakdown = scored.Breakdown
+		rescored[i].LeadTemperature = scored.LeadTemperature
+		changed = append(changed, scored)
+	}
+
+	if len(changed) == 0 {
+		return rescored, nil
+	}
+	if err := s.leadRepo.UpdateScores(ctx, changed); err != nil {
+		return nil, err
+	}
+	return rescored, nil
+}
+
+// rescore stores the lead's new score and tells whether it changed, an
+// unchanged lead is returned as is without writing it. It fails with
+// repository.ErrLeadNotFound when the lead was changed since it was read.
+func (s *ScoringService) rescore(ctx context.Context, config *model.ScoringConfig, lead *model.Lead, now time.Time) (*model.Lead, bool, error) {
+	scored := scoreLead(config, lead, now)
+	if scored.Score == lead.Score &&
+		scored.LeadTemperature == lead.LeadTemperature &&
+		slices.Equal(scored.Breakdown, lead.ScoreBreakdown) {
+		return lead, false, nil
+	}
+
+	rescored, err := s.leadRepo.UpdateScore(ctx, scored)
+	if err != nil {
+		return nil, false, err
+	}
+	return rescored, true, nil
+}
+
+// scoreLead rates the lead, a temperature set by hand overrides the one of its
+// score. A change of the temperature is recorded in the status history.
+func scoreLead(config *model.ScoringConfig, lead *model.Lead, now time.Time) *dto.LeadScoreProperties {
+	score, breakdown := config.Score(lead, now)
+	temperature := config.Temperature(score)
+	if lead.TemperatureOverride != "" {
+		temperature = lead.TemperatureOverride
+	}
+	scored := &dto.LeadScoreProperties{
+		ID:              lead.ID,
+		Version:         lead.Version,
+		Score:           score,
+		Breakdown:       breakdown,
+		LeadTemperature: temperature,
+	}
+	if temperature != lead.LeadTemperature {
+		scored.TemperatureChange = temperatureChange(lead.LeadTemperature, temperature, now)
+	}
+	return scored
+}
+
+// temperatureChange records the lead turning from one temperature to another
+func temperatureChange(from constants.LeadTemperature, to constants.LeadTemperature, now time.Time) *model.StatusChange {
+	return &model.StatusChange{
+		Field:     constants.LeadFieldLeadTemperature,
+		OldValue:  string(from),
+		NewValue:  string(to),
+		ChangedAt: now,
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidSequence is returned when saving a malformed sequence, or when
+// attaching a lead to a sequence of another outreach type
+var ErrInvalidSequence = errors.New("invalid sequence")
+
+// SequenceService manages the outreach sequences leads can be attached to
+type SequenceService struct {
+	repo  repository.SequenceRepository
+	audit *AuditService
+}
+
+func NewSequenceService(sequenceRepository repository.SequenceRepository, auditService *AuditService) *SequenceService {
+	return &SequenceService{
+		repo:  sequenceRepository,
+		audit: auditService,
+	}
+}
+
+func (s *SequenceService) GetAll(ctx context.Context) ([]model.Sequence, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *SequenceService) Get(ctx context.Context, id primitive.ObjectID) (*model.Sequence, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+// ForNewLead returns the sequence a new lead of the outreach type is attached
+// to: the given one, or the default one of the outreach type when id is nil.
+// It returns nil without an error when no id is given and there is no default.
+func (s *SequenceService) ForNewLead(ctx context.Context, id *primitive.ObjectID, outreachType constants.OutreachType) (*model.Sequence, error) {
+	if id == nil {
+		sequence, err := s.repo.FindDefault(ctx, outreachType)
+		if errors.Is(err, repository.ErrSequenceNotFound) {
+			return nil, nil
+		}
+		return sequence, err
+	}
+
+	sequence, err := s.repo.FindByID(ctx, *id)
+	if err != nil {
+		return nil, err
+	}
+	if sequence.OutreachType != "" && sequence.OutreachType != outreachType {
+		return nil, fmt.Errorf("%w: sequence %s is for %s leads", ErrInvalidSequence, sequence.Name, sequence.OutreachType)
+	}
+	return sequence, nil
+}
+
+func (s *SequenceService) Create(ctx context.Context, sequence *model.Sequence) error {
+	sequence.ID = primitive.NewObjectID()
+	if err := s.validate(ctx, sequence); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, sequence); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, constants.AuditActionCreate, constants.AuditEntitySequence, sequence.ID.Hex(), nil, sequence)
+	return nil
+}
+
+// Update replaces the sequence. Leads already attached keep the steps they
+// were attached with, see model.SequenceProgress.
+func (s *SequenceService) Update(ctx context.Context, sequence *model.Sequence) error {
+	current, err := s.repo.FindByID(ctx, sequence.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.validate(ctx, sequence); err != nil {
+		return err
+	}
+	if err := s.repo.Update(ctx, sequence); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, constants.AuditActionUpdate, constants.AuditEntitySequence, sequence.ID.Hex(), current, sequence)
+	return nil
+}
+
+func (s *SequenceService) Delete(ctx context.Context, id primitive.ObjectID) error {
+	current, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, constants.AuditActionDelete, constants.AuditEntitySequence, id.Hex(), current, nil)
+	return nil
+}
+
+// validate checks the sequence and that it is the only default sequence of its outreach type
+func (s *SequenceService) validate(ctx context.Context, sequence *model.Sequence) error {
+	if err := sequence.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSequence, err)
+	}
+	if !sequence.Default {
+		return nil
+	}
+	if sequence.OutreachType == "" {
+		return fmt.Errorf("%w: a default sequence needs an outreach type", ErrInvalidSequence)
+	}
+
+	existing, err := s.repo.FindDefault(ctx, sequence.OutreachType)
+	if errors.Is(err, repository.ErrSequenceNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.ID != sequence.ID {
+		return fmt.Errorf("%w: %s is already the default %s sequence", ErrInvalidSequence, existing.Name, sequence.OutreachType)
+	}
+	return nil
+}
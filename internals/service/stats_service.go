@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"time"
 
 	"leadgentracker/internals/model"
@@ -9,20 +11,25 @@ import (
 	"leadgentracker/internals/repository"
 )
 
+const (
+	statsFieldConnections = "connections"
+	statsFieldInMails     = "inMails"
+)
+
 type StatsService struct {
-	repo repository.StatsRepository
+	repo     repository.StatsRepository
+	leadRepo repository.LeadRepository
+	audit    *AuditService
 }
 
-func NewStatsService(leadRepository repository.StatsRepository) *StatsService {
+func NewStatsService(statsRepository repository.StatsRepository, leadRepository repository.LeadRepository, auditService *AuditService) *StatsService {
 	return &StatsService{
-		repo: leadRepository,
+		repo:     statsRepository,
+		leadRepo: leadRepository,
+		audit:    auditService,
 	}
 }
 
-func (s *StatsService) UpdateStats(ctx context.Context, outreachType constants.OutreachType) error {
-	return s.repo.Update(ctx, outreachType)
-}
-
 func (s *StatsService) GetCurrentDayStats(ctx context.Context) (*model.Stats, error) {
 	return s.repo.GetForDate(ctx, time.Now())
 }
@@ -30,3 +37,107 @@ func (s *StatsService) GetCurrentDayStats(ctx context.Context) (*model.Stats, er
 func (s *StatsService) GetTotalStats(ctx context.Context) (*model.Stats, error) {
 	return s.repo.GetTotal(ctx)
 }
+
+// RebuildStats recomputes all stats counters from the stored leads and
+// returns the counters that changed. In dry-run mode nothing is written.
+// The counters are corrected by the difference to the recomputed ones rather
+// than overwritten, so leads added or deleted after the recount keep being
+// counted. A lead added or deleted while the leads are recounted can still be
+// counted twice or not at all, until the next rebuild.
+func (s *StatsService) RebuildStats(ctx context.Context, dryRun bool) (*model.StatsRebuildResult, error) {
+	current, err := s.repo.GetSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rebuilt, err := s.leadRepo.AggregateStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.StatsRebuildResult{
+		DryRun:  dryRun,
+		Changes: diffStats(current, rebuilt),
+	}
+	if dryRun || len(result.Changes) == 0 {
+		return result, nil
+	}
+
+	if err := s.repo.Adjust(ctx, statsDelta(result.Changes)); err != nil {
+		return nil, fmt.Errorf("failed to store rebuilt stats: %w", err)
+	}
+
+	s.audit.Record(ctx, constants.AuditActionStatsRebuild, constants.AuditEntityStats, "", current, rebuilt)
+	return result, nil
+}
+
+// diffStats lists the changed total counters first, followed by the daily ones in chronological order
+func diffStats(current *model.StatsSnapshot, rebuilt *model.StatsSnapshot) []model.StatsChange {
+	changes := []model.StatsChange{}
+	appendChanges := func(date string, currentStats model.Stats, rebuiltStats model.Stats) {
+		if currentStats.Connections != rebuiltStats.Connections {
+			changes = append(changes, model.StatsChange{Date: date, Field: statsFieldConnections, Current: currentStats.Connections, Rebuilt: rebuiltStats.Connections})
+		}
+		if currentStats.InMails != rebuiltStats.InMails {
+			changes = append(changes, model.StatsChange{Date: date, Field: statsFieldInMails, Current: currentStats.InMails, Rebuilt: rebuiltStats.InMails})
+		}
+	}
+
+	appendChanges("", current.Total, rebuilt.Total)
+
+	currentDaily := dailyStatsByDate(current.Daily)
+	rebuiltDaily := dailyStatsByDate(rebuilt.Daily)
+
+	dates := make([]string, 0, len(currentDaily)+len(rebuiltDaily))
+	for date := range currentDaily {
+		dates = append(dates, date)
+	}
+	for date := range rebuiltDaily {
+		if _, exists := currentDaily[date]; !exists {
+			dates = append(dates, date)
+		}
+	}
+	sort.Strings(dates)
+
+	for _, date := range dates {
+		appendChanges(date, currentDaily[date], rebuiltDaily[date])
+	}
+
+	return changes
+}
+
+// statsDelta turns the changed counters into the amounts to add to them
+func statsDelta(changes []model.StatsChange) *model.StatsSnapshot {
+	delta := &model.StatsSnapshot{}
+	days := make(map[string]int)
+	for _, change := range changes {
+		var connections, inMails *int
+		if change.Date == "" {
+			connections, inMails = &delta.Total.Connections, &delta.Total.InMails
+		} else {
+			index, exists := days[change.Date]
+			if !exists {
+				index = len(delta.Daily)
+				days[change.Date] = index
+				delta.Daily = append(delta.Daily, model.DailyStats{Date: change.Date})
+			}
+			connections, inMails = &delta.Daily[index].Connections, &delta.Daily[index].InMails
+		}
+
+		switch change.Field {
+		case statsFieldConnections:
+			*connections += change.Rebuilt - change.Current
+		case statsFieldInMails:
+			*inMails += change.Rebuilt - change.Current
+		}
+	}
+	return delta
+}
+
+func dailyStatsByDate(daily []model.DailyStats) map[string]model.Stats {
+	byDate := make(map[string]model.Stats, len(daily))
+	for _, stats := range daily {
+		byDate[stats.Date] = model.Stats{Connections: stats.Connections, InMails: stats.InMails}
+	}
+	return byDate
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"fmt"
+
+	"leadgentracker/internals/model"
+	"leadgentracker/internals/model/constants"
+	"leadgentracker/internals/model/dto"
+)
+
+// TransitionError is returned when an update moves a lead into a state that
+// is invalid for its outreach type or not reachable from its current state
+type TransitionError struct {
+	OutreachType constants.OutreachType
+	Field        constants.LeadField
+	From         string
+	To           string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s lead cannot change %s from %q to %q", e.OutreachType, e.Field, e.From, e.To)
+}
+
+// validateTransition checks the update against the lead's pipeline. The
+// pipeline decides which stages exist for the lead's outreach type and which
+// moves between them are allowed, see model.Pipeline.CanMove.
+func validateTransition(pipeline *model.Pipeline, current *model.Lead, update *dto.UpdateLeadProperties) error {
+	if !pipeline.CanMove(current.ConnectionStatus, update.ConnectionStatus) {
+		return &TransitionError{
+			OutreachType: current.OutreachType,
+			Field:        constants.LeadFieldConnectionStatus,
+			From:         string(current.ConnectionStatus),
+			To:           string(update.ConnectionStatus),
+		}
+	}
+
+	return nil
+}
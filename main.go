@@ -5,9 +5,15 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/netip"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"leadgentracker/internals/handler"
+	"leadgentracker/internals/migration"
+	"leadgentracker/internals/openapi"
 	"leadgentracker/internals/repository"
 	"leadgentracker/internals/service"
 
@@ -15,41 +21,327 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const (
+	StorageBackendMongo  = "mongo"
+	StorageBackendMemory = "memory"
+	StorageBackendFile   = "file"
+
+	defaultStorageDir = "data"
+
+	defaultTrashRetentionDays = 30
+	defaultFollowupDelayDays  = 3
+	trashPurgeInterval        = time.Hour
+	scoreRefreshInterval      = time.Hour
+)
+
+// repositories groups the repository implementations of the selected storage backend
+type repositories struct {
+	leads     repository.LeadRepository
+	stats     repository.StatsRepository
+	audit     repository.AuditRepository
+	pipelines repository.PipelineRepository
+	sequences repository.SequenceRepository
+	scoring   repository.ScoringRepository
+	fields    repository.CustomFieldRepository
+	companies repository.CompanyRepository
+	campaigns repository.CampaignRepository
+	templates repository.MessageTemplateRepository
+	close     func()
+
+	// migrations is only set for the mongo backend, the others have no stored schema
+	migrations *migration.Runner
+}
+
+type services struct {
+	leads     *service.LeadService
+	stats     *service.StatsService
+	audit     *service.AuditService
+	pipelines *service.PipelineService
+	sequences *service.SequenceService
+	scoring   *service.ScoringService
+	fields    *service.CustomFieldService
+	companies *service.CompanyService
+	campaigns *service.CampaignService
+	templates *service.MessageTemplateService
+}
+
 func main() {
-	dbClient := configureDatabaseConnection()
-	defer func() {
-		if err := dbClient.Disconnect(context.Background()); err != nil {
-			log.Fatal("could not disconnect from database: ", err)
+	// Subcommands run a maintenance task instead of the server
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
+	repos := configureRepositories()
+	defer repos.close()
+
+	if repos.migrations != nil && os.Getenv("MONGO_AUTO_MIGRATE") != "false" {
+		if _, err := repos.migrations.Up(context.Background()); err != nil {
+			log.Fatal("could not migrate database: ", err)
 		}
-	}()
+	}
+
+	services := configureServices(repos)
+	if err := services.pipelines.EnsureDefaults(context.Background()); err != nil {
+		log.Fatal("could not store default pipelines: ", err)
+	}
 
 	sseBroadcaster := handler.NewSSEBroadcaster()
-	leadHandler := configureLeadHandler(dbClient, sseBroadcaster)
-	configureEndpointHandlers(leadHandler, sseBroadcaster)
+	leadHandler := handler.NewLeadHandler(services.leads, services.stats, services.pipelines, services.fields, services.companies, services.campaigns, services.templates, sseBroadcaster)
+	adminHandler := handler.NewAdminHandler(services.stats, services.pipelines, services.sequences, services.scoring, services.fields, sseBroadcaster, os.Getenv("ADMIN_TOKEN"))
+	auditHandler := handler.NewAuditHandler(services.audit)
+	companyHandler := handler.NewCompanyHandler(services.companies, services.pipelines, sseBroadcaster)
+	campaignHandler := handler.NewCampaignHandler(services.campaigns, sseBroadcaster)
+	templateHandler := handler.NewMessageTemplateHandler(services.templates, services.fields, services.companies, sseBroadcaster)
+	importHandler := handler.NewImportHandler(services.leads, services.fields, sseBroadcaster)
+	apiHandler := handler.NewAPIHandler(services.leads, services.stats, services.pipelines, services.fields, sseBroadcaster)
+	configureEndpointHandlers(leadHandler, adminHandler, auditHandler, companyHandler, campaignHandler, templateHandler, importHandler, apiHandler, sseBroadcaster)
+
+	go runTrashRetention(services.leads)
+	go runScoreRefresh(services.scoring, sseBroadcaster)
+
+	actorHeader := os.Getenv("AUDIT_ACTOR_HEADER")
+	if actorHeader == "" {
+		actorHeader = handler.DefaultActorHeader
+	}
+	trustedProxies := configureTrustedProxies()
 
 	// start server
 	log.Println("Server starting on :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	log.Fatal(http.ListenAndServe(":8080", handler.WithRequestMetadata(actorHeader, trustedProxies, http.DefaultServeMux)))
 }
 
-func configureEndpointHandlers(leadHandler *handler.LeadHandler, sseBroadcaster *handler.SSEBroadcaster) {
+func configureEndpointHandlers(leadHandler *handler.LeadHandler, adminHandler *handler.AdminHandler, auditHandler *handler.AuditHandler, companyHandler *handler.CompanyHandler, campaignHandler *handler.CampaignHandler, templateHandler *handler.MessageTemplateHandler, importHandler *handler.ImportHandler, apiHandler *handler.APIHandler, sseBroadcaster *handler.SSEBroadcaster) {
 	http.HandleFunc("/", leadHandler.ServeIndex)
 	http.HandleFunc("/add-lead", leadHandler.AddLead)
 	http.HandleFunc("/update-lead", leadHandler.UpdateLead)
 	http.HandleFunc("/delete-lead", leadHandler.DeleteLead)
+	http.HandleFunc("/bulk-leads", leadHandler.BulkUpdateLeads)
+	http.HandleFunc("/complete-sequence-step", leadHandler.CompleteSequenceStep)
+	http.HandleFunc("/add-note", leadHandler.AddNote)
+	http.HandleFunc("/update-note", leadHandler.UpdateNote)
+	http.HandleFunc("/delete-note", leadHandler.DeleteNote)
 	http.HandleFunc("/lead-stats", leadHandler.GetLeadStats)
 	http.HandleFunc("/leads", leadHandler.GetAllLeads)
+	http.HandleFunc("/leads/export", leadHandler.ExportLeads)
+	http.HandleFunc("/followups", leadHandler.GetFollowups)
+	http.HandleFunc("/import", importHandler.ServeImport)
+	http.HandleFunc("/import/preview", importHandler.PreviewImport)
+	http.HandleFunc("/import/commit", importHandler.CommitImport)
+	http.HandleFunc("/trash", leadHandler.ServeTrash)
+	http.HandleFunc("/trash/leads", leadHandler.GetTrash)
+	http.HandleFunc("/restore-lead", leadHandler.RestoreLead)
+	http.HandleFunc("/purge-lead", leadHandler.PurgeLead)
+	http.HandleFunc("/companies", companyHandler.ServeCompanies)
+	http.HandleFunc("/company", companyHandler.ServeCompany)
+	http.HandleFunc("/add-company", companyHandler.AddCompany)
+	http.HandleFunc("/update-company", companyHandler.UpdateCompany)
+	http.HandleFunc("/delete-company", companyHandler.DeleteCompany)
+	http.HandleFunc("/campaigns", campaignHandler.ServeCampaigns)
+	http.HandleFunc("/add-campaign", campaignHandler.AddCampaign)
+	http.HandleFunc("/delete-campaign", campaignHandler.DeleteCampaign)
+	http.HandleFunc("/templates", templateHandler.ServeTemplates)
+	http.HandleFunc("/add-template", templateHandler.AddTemplate)
+	http.HandleFunc("/update-template", templateHandler.UpdateTemplate)
+	http.HandleFunc("/delete-template", templateHandler.DeleteTemplate)
+	http.HandleFunc("/preview-template", templateHandler.PreviewTemplate)
+	http.HandleFunc("/use-template", templateHandler.UseTemplate)
+	http.HandleFunc(openapi.BasePath+"/", apiHandler.NotFound)
+	http.HandleFunc(openapi.BasePath+"/leads", apiHandler.Leads)
+	http.HandleFunc(openapi.BasePath+"/leads/bulk", apiHandler.BulkLeads)
+	http.HandleFunc(openapi.BasePath+"/leads/{id}", apiHandler.Lead)
+	http.HandleFunc(openapi.BasePath+"/stats", apiHandler.Stats)
+	http.HandleFunc(openapi.BasePath+"/openapi.json", apiHandler.Spec)
 	http.HandleFunc("/sse", sseBroadcaster.HandleSSE)
+	http.HandleFunc("/admin/rebuild-stats", adminHandler.RebuildStats)
+	http.HandleFunc("/admin/pipelines", adminHandler.Pipelines)
+	http.HandleFunc("/admin/sequences", adminHandler.Sequences)
+	http.HandleFunc("/admin/scoring", adminHandler.Scoring)
+	http.HandleFunc("/admin/custom-fields", adminHandler.CustomFields)
+	// The audit log shows who changed what, so only admins may read it
+	http.HandleFunc("/audit", adminHandler.RequireAdmin(auditHandler.ServeAuditLog))
+	http.HandleFunc("/audit/entries", adminHandler.RequireAdmin(auditHandler.GetAuditEntries))
+	http.HandleFunc("/audit.json", adminHandler.RequireAdmin(auditHandler.GetAuditEntriesJSON))
 }
 
-func configureLeadHandler(client *mongo.Client, sseBroadcaster *handler.SSEBroadcaster) *handler.LeadHandler {
-	leadRepo := repository.NewLeadRepository(client)
-	statsRepo := repository.NewStatsRepository(client)
+// configureTrustedProxies reads AUDIT_TRUSTED_PROXIES, the comma separated
+// addresses or CIDR ranges of the reverse proxies whose AUDIT_ACTOR_HEADER is
+// trusted. Without any, every change is recorded as made by an anonymous actor.
+func configureTrustedProxies() []netip.Prefix {
+	var trustedProxies []netip.Prefix
+	for _, value := range strings.Split(os.Getenv("AUDIT_TRUSTED_PROXIES"), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(value); err == nil {
+			trustedProxies = append(trustedProxies, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			log.Fatalf("invalid AUDIT_TRUSTED_PROXIES entry: %s", value)
+		}
+		trustedProxies = append(trustedProxies, prefix.Masked())
+	}
+	return trustedProxies
+}
 
-	statsService := service.NewStatsService(statsRepo)
-	leadService := service.NewLeadService(leadRepo)
+func configureServices(repos *repositories) *services {
+	auditService := service.NewAuditService(repos.audit)
+	pipelineService := service.NewPipelineService(repos.pipelines, repos.leads, auditService)
+	sequenceService := service.NewSequenceService(repos.sequences, auditService)
+	scoringService := service.NewScoringService(repos.scoring, repos.leads, auditService)
+	customFieldService := service.NewCustomFieldService(repos.fields, repos.leads, auditService)
+	companyService := service.NewCompanyService(repos.companies, repos.leads, pipelineService, auditService)
+	campaignService := service.NewCampaignService(repos.campaigns, repos.leads, pipelineService, auditService)
+	templateService := service.NewMessageTemplateService(repos.templates, repos.leads, customFieldService, companyService, auditService)
+
+	return &services{
+		leads:     service.NewLeadService(repos.leads, repos.stats, pipelineService, sequenceService, scoringService, customFieldService, companyService, campaignService, auditService, configureLeadService()),
+		stats:     service.NewStatsService(repos.stats, repos.leads, auditService),
+		audit:     auditService,
+		pipelines: pipelineService,
+		sequences: sequenceService,
+		scoring:   scoringService,
+		fields:    customFieldService,
+		companies: companyService,
+		campaigns: campaignService,
+		templates: templateService,
+	}
+}
 
-	return handler.NewLeadHandler(leadService, statsService, sseBroadcaster)
+// configureLeadService reads the lead settings, TRASH_RETENTION_DAYS of 0 keeps trashed
+// leads forever and FOLLOWUP_DELAY_DAYS of 0 schedules no follow-ups on accepted connections
+func configureLeadService() service.LeadServiceConfig {
+	retentionDays := defaultTrashRetentionDays
+	if value := os.Getenv("TRASH_RETENTION_DAYS"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			log.Fatalf("invalid TRASH_RETENTION_DAYS: %s", value)
+		}
+		retentionDays = days
+	}
+
+	followupDelayDays := defaultFollowupDelayDays
+	if value := os.Getenv("FOLLOWUP_DELAY_DAYS"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			log.Fatalf("invalid FOLLOWUP_DELAY_DAYS: %s", value)
+		}
+		followupDelayDays = days
+	}
+
+	return service.LeadServiceConfig{
+		TrashRetention: time.Duration(retentionDays) * 24 * time.Hour,
+		FollowupDelay:  time.Duration(followupDelayDays) * 24 * time.Hour,
+	}
+}
+
+// runTrashRetention periodically purges the leads that stayed in the trash for longer than the retention
+func runTrashRetention(leadService *service.LeadService) {
+	for {
+		purged, err := leadService.PurgeExpiredTrash(context.Background())
+		if err != nil {
+			log.Printf("[ERROR] failed to purge expired trash: %s", err)
+		} else if purged > 0 {
+			log.Printf("purged %d leads from the trash", purged)
+		}
+		time.Sleep(trashPurgeInterval)
+	}
+}
+
+// runScoreRefresh periodically rescores the leads, whose scores change as
+// their last response ages and their follow-ups fall due
+func runScoreRefresh(scoringService *service.ScoringService, sseBroadcaster *handler.SSEBroadcaster) {
+	for {
+		changed, err := scoringService.RescoreAll(context.Background())
+		if err != nil {
+			log.Printf("[ERROR] failed to rescore leads: %s", err)
+		} else if changed > 0 {
+			log.Printf("rescored %d leads", changed)
+			sseBroadcaster.Broadcast("refreshLeadList")
+		}
+		time.Sleep(scoreRefreshInterval)
+	}
+}
+
+// configureRepositories selects the storage backend from STORAGE_BACKEND, defaulting to MongoDB.
+// The file backend keeps its data in STORAGE_DIR. MongoDB is migrated to the
+// latest schema on startup unless MONGO_AUTO_MIGRATE is "false".
+func configureRepositories() *repositories {
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		backend = StorageBackendMongo
+	}
+
+	switch backend {
+	case StorageBackendMongo:
+		dbClient := configureDatabaseConnection()
+		return &repositories{
+			leads:     repository.NewLeadRepository(dbClient),
+			stats:     repository.NewStatsRepository(dbClient),
+			audit:     repository.NewAuditRepository(dbClient),
+			pipelines: repository.NewPipelineRepository(dbClient),
+			sequences: repository.NewSequenceRepository(dbClient),
+			scoring:   repository.NewScoringRepository(dbClient),
+			fields:    repository.NewCustomFieldRepository(dbClient),
+			companies: repository.NewCompanyRepository(dbClient),
+			campaigns: repository.NewCampaignRepository(dbClient),
+			templates: repository.NewMessageTemplateRepository(dbClient),
+			close: func() {
+				if err := dbClient.Disconnect(context.Background()); err != nil {
+					log.Fatal("could not disconnect from database: ", err)
+				}
+			},
+			migrations: migration.NewRunner(dbClient.Database(os.Getenv("MONGO_DB")), migration.Migrations),
+		}
+	case StorageBackendMemory:
+		log.Println("using in-memory storage, all data will be lost on shutdown")
+		store := repository.NewMemoryStore()
+		return &repositories{
+			leads:     repository.NewMemoryLeadRepository(store),
+			stats:     repository.NewMemoryStatsRepository(store),
+			audit:     repository.NewMemoryAuditRepository(store),
+			pipelines: repository.NewMemoryPipelineRepository(store),
+			sequences: repository.NewMemorySequenceRepository(store),
+			scoring:   repository.NewMemoryScoringRepository(store),
+			fields:    repository.NewMemoryCustomFieldRepository(store),
+			companies: repository.NewMemoryCompanyRepository(store),
+			campaigns: repository.NewMemoryCampaignRepository(store),
+			templates: repository.NewMemoryMessageTemplateRepository(store),
+			close:     func() {},
+		}
+	case StorageBackendFile:
+		dir := os.Getenv("STORAGE_DIR")
+		if dir == "" {
+			dir = defaultStorageDir
+		}
+		log.Printf("using file storage in %s", dir)
+		store, err := repository.OpenFileStore(dir)
+		if err != nil {
+			log.Fatal("could not open file storage: ", err)
+		}
+		return &repositories{
+			leads:     repository.NewMemoryLeadRepository(store),
+			stats:     repository.NewMemoryStatsRepository(store),
+			audit:     repository.NewMemoryAuditRepository(store),
+			pipelines: repository.NewMemoryPipelineRepository(store),
+			sequences: repository.NewMemorySequenceRepository(store),
+			scoring:   repository.NewMemoryScoringRepository(store),
+			fields:    repository.NewMemoryCustomFieldRepository(store),
+			companies: repository.NewMemoryCompanyRepository(store),
+			campaigns: repository.NewMemoryCampaignRepository(store),
+			templates: repository.NewMemoryMessageTemplateRepository(store),
+			close: func() {
+				if err := store.Close(); err != nil {
+					log.Fatal("could not close file storage: ", err)
+				}
+			},
+		}
+	default:
+		log.Fatalf("unknown storage backend: %s", backend)
+		return nil
+	}
 }
 
 func configureDatabaseConnection() *mongo.Client {
@@ -14,7 +14,7 @@ import (
 	"leadgentracker/internals/model/dto"
 )
 
-func Index(totalStats *model.Stats, todayStats *model.Stats, leads []model.Lead, totalPages int, filter *dto.LeadFilter) templ.Component {
+func Index(totalStats *model.Stats, todayStats *model.Stats, followups *dto.FollowupQueue, leads []model.Lead, totalPages int, filter *dto.LeadFilter, pipelines model.Pipelines, tags []model.TagCount, fields []model.CustomField, companies model.Companies, campaigns model.Campaigns, templates model.MessageTemplates) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -35,36 +35,62 @@ func Index(totalStats *model.Stats, todayStats *model.Stats, leads []model.Lead,
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<!doctype html><html lang=\"en\" class=\"bg-gray-50\"><head><title>Lead Tracker</title><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><script src=\"https://unpkg.com/htmx.org@2.0.3\" integrity=\"sha384-0895/pl2MU10Hqc6jd4RvrthNlDiE9U1tWmX7WRESftEDRosgxNsQG/Ze9YMRzHq\" crossorigin=\"anonymous\"></script><script src=\"https://cdn.tailwindcss.com\"></script><script>\n\t\t\t\tconst events = new EventSource(\"/sse\");\n                events.onmessage = function(event) {\n                    \n                    // Split the data in case multiple triggers were sent\n                    const triggers = event.data.split(',');\n                    \n                    // Trigger each event\n                    triggers.forEach(trigger => {\n                        console.log(\"Triggering:\", trigger);\n                        htmx.trigger(`[hx-trigger='${trigger}']`, trigger);\n                    });\n                };\n\n                events.onerror = function(error) {\n                    console.error(\"SSE error:\", error);\n                };\n\n                events.onopen = function() {\n                    console.log(\"SSE connection opened\");\n                };\n\t\t\t</script></head><body class=\"min-h-screen p-4 md:p-8\"><div class=\"max-w-7xl mx-auto space-y-8\"><div id=\"lead-stats\" hx-get=\"/lead-stats\" hx-trigger=\"refreshLeadStats\" hx-target=\"#lead-stats\">")
-		if templ_7745c5c3_Err != nil {
-			return templ_7745c5c3_Err
-		}
-		templ_7745c5c3_Err = LeadStats(totalStats, todayStats).Render(ctx, templ_7745c5c3_Buffer)
-		if templ_7745c5c3_Err != nil {
-			return templ_7745c5c3_Err
-		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</div><div id=\"lead-list\" hx-get=\"")
-		if templ_7745c5c3_Err != nil {
+		templ_7745c5c3_Var2 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+			templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+			templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+			if !templ_7745c5c3_IsBuffer {
+				defer func() {
+					templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+					if templ_7745c5c3_Err == nil {
+						templ_7745c5c3_Err = templ_7745c5c3_BufErr
+					}
+				}()
+			}
+			ctx = templ.InitializeContext(ctx)
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<script>\n\t\t\tconst events = new EventSource(\"/sse\");\n            events.onmessage = function(event) {\n                \n                // Split the data in case multiple triggers were sent\n                const triggers = event.data.split(',');\n                \n                // Trigger each event\n                triggers.forEach(trigger => {\n                    console.log(\"Triggering:\", trigger);\n                    htmx.trigger(`[hx-trigger='${trigger}']`, trigger);\n                });\n            };\n\n            events.onerror = function(error) {\n                console.error(\"SSE error:\", error);\n            };\n\n            events.onopen = function() {\n                console.log(\"SSE connection opened\");\n            };\n\t\t</script> <div id=\"lead-stats\" hx-get=\"/lead-stats\" hx-trigger=\"refreshLeadStats\" hx-target=\"#lead-stats\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = LeadStats(totalStats, todayStats).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</div><div id=\"followups\" hx-get=\"/followups\" hx-trigger=\"refreshFollowups\" hx-target=\"#followups\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = FollowupQueue(followups).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</div> <div id=\"bulk-results\"></div><div id=\"lead-list\" hx-get=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var3 string
+			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("/leads?page=%d", filter.Page))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/index.templ`, Line: 53, Col: 54}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("\" hx-trigger=\"refreshLeadList\" hx-target=\"#lead-list\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = LeadList(leads, totalPages, filter, pipelines, tags, fields, companies, campaigns, templates).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
 			return templ_7745c5c3_Err
-		}
-		var templ_7745c5c3_Var2 string
-		templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("/leads?page=%d", filter.Page))
-		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/index.templ`, Line: 52, Col: 56}
-		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
-		if templ_7745c5c3_Err != nil {
-			return templ_7745c5c3_Err
-		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("\" hx-trigger=\"refreshLeadList\" hx-target=\"#lead-list\">")
-		if templ_7745c5c3_Err != nil {
-			return templ_7745c5c3_Err
-		}
-		templ_7745c5c3_Err = LeadList(leads, totalPages, filter).Render(ctx, templ_7745c5c3_Buffer)
-		if templ_7745c5c3_Err != nil {
-			return templ_7745c5c3_Err
-		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</div></div></body></html>")
+		})
+		templ_7745c5c3_Err = page("Lead Tracker").Render(templ.WithChildren(ctx, templ_7745c5c3_Var2), templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}